@@ -1,14 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"shopify-app/internal/api/router"
 	"shopify-app/internal/config"
+	"shopify-app/internal/contract"
 	"shopify-app/internal/database"
 	"shopify-app/internal/database/seeder"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/notification"
+	"shopify-app/internal/payment"
 	"shopify-app/internal/repository"
 	"shopify-app/internal/service"
+	"shopify-app/internal/utils"
+	"syscall"
+	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -44,20 +56,162 @@ func main() {
 	cartRepo := repository.NewCartRepository(db)
 	orderRepo := repository.NewOrderRepository(db)
 	reportRepo := repository.NewReportRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	taxRuleRepo := repository.NewTaxRuleRepository(db)
+	promotionRepo := repository.NewPromotionRepository(db)
+	slotRepo := repository.NewSlotRepository(db)
+	addressRepo := repository.NewAddressRepository(db)
+	deliveryZoneRepo := repository.NewDeliveryZoneRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	stockHoldRepo := repository.NewStockHoldRepository(db)
+	txManager := repository.NewTransactionManager(db)
+
+	// Initialize the in-process order event hub
+	orderEventHub := service.NewOrderEventHub(cfg.OrderEventHistorySize)
 
 	// Initialize services
 	userService := service.NewUserService(userRepo, cfg)
-	menuService := service.NewMenuService(menuRepo)
-	cartService := service.NewCartService(cartRepo, menuRepo)
-	orderService := service.NewOrderService(orderRepo, cartService, menuRepo)
-	reportService := service.NewReportService(reportRepo)
+	addressService := service.NewAddressService(addressRepo, txManager)
+	deliveryZoneService := service.NewDeliveryZoneService(deliveryZoneRepo, txManager, cfg.StoreLocation)
+	stockHoldService := service.NewStockHoldService(stockHoldRepo, menuRepo, cfg.StockHoldTTL)
+	menuService := service.NewMenuService(menuRepo, txManager, stockHoldService)
+	taxService := service.NewTaxService(taxRuleRepo, menuRepo, cfg.TaxPricesIncludeTax, cfg.TaxRounding)
+	promotionService := service.NewPromotionService(promotionRepo, menuRepo, cfg.TaxRounding)
+	slotService := service.NewSlotService(slotRepo, service.SlotSettings{
+		Interval:    cfg.SlotInterval,
+		OpensAt:     cfg.SlotOpensAt,
+		ClosesAt:    cfg.SlotClosesAt,
+		MaxOrders:   cfg.SlotMaxOrders,
+		MaxItems:    cfg.SlotMaxItems,
+		BookingDays: cfg.SlotBookingDays,
+		LeadTime:    cfg.ScheduledOrderLeadTime,
+	})
+	cartService := service.NewCartService(cartRepo, menuRepo, taxService, promotionService, stockHoldService, txManager)
+	orderService := service.NewOrderService(orderRepo, cartService, menuRepo, promotionService, taxService, slotService, addressService, deliveryZoneService, stockHoldService, txManager, orderEventHub, cfg.OrderPrepTime, cfg.OrderNumberFormat, cfg.JWTSecret, cfg.QuoteTTL)
+	reportService := service.NewReportService(reportRepo, slotService)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyKeyTTL)
+	paymentProvider := payment.NewMockProvider(cfg.PaymentWebhookSecret)
+	refundService := service.NewRefundService(refundRepo, orderRepo, paymentRepo, menuRepo, txManager, paymentProvider)
+	paymentService := service.NewPaymentService(paymentRepo, orderRepo, orderService, txManager, paymentProvider, refundService, cfg.PaymentCurrency)
+	var cartReminderNotifier contract.CartReminderNotifier
+	if cfg.CartReminders {
+		cartReminderNotifier = notification.NewLogNotifier()
+	}
+	abandonedCartService := service.NewAbandonedCartService(cartRepo, txManager, cartReminderNotifier)
+
+	// Setup background jobs
+	scheduler := service.NewScheduler(jobRepo)
+	everyMinute, err := utils.Every(time.Minute)
+	if err != nil {
+		log.Fatalf("failed to create job schedule: %v", err)
+	}
+	hourly, err := utils.ParseCron("@hourly")
+	if err != nil {
+		log.Fatalf("failed to create job schedule: %v", err)
+	}
+
+	// Release scheduled orders to the kitchen once their slot is within the lead time
+	registerJob(scheduler, "release-scheduled-orders", everyMinute, func(ctx context.Context) *exception.AppError {
+		released, err := orderService.ReleaseDueScheduledOrders(ctx)
+		if released > 0 {
+			log.Printf("released %d scheduled orders", released)
+		}
+		return err
+	})
+	if cfg.PendingOrderTimeout > 0 {
+		// Give back the stock held by orders that were never confirmed
+		registerJob(scheduler, "expire-pending-orders", everyMinute, func(ctx context.Context) *exception.AppError {
+			expired, err := orderService.ExpireStalePendingOrders(ctx, cfg.PendingOrderTimeout)
+			if expired > 0 {
+				log.Printf("cancelled %d pending orders that were not confirmed in time", expired)
+			}
+			return err
+		})
+	}
+	// Ask the provider again about refunds it gave no clear answer on; a few minutes keep the
+	// retries clear of refunds still being issued
+	registerJob(scheduler, "retry-pending-refunds", everyMinute, func(ctx context.Context) *exception.AppError {
+		settled, err := refundService.RetryPendingRefunds(ctx, 5*time.Minute)
+		if settled > 0 {
+			log.Printf("settled %d pending refunds", settled)
+		}
+		return err
+	})
+	if cfg.CartRetention > 0 {
+		registerJob(scheduler, "purge-stale-carts", cfg.CartPurgeSchedule, func(ctx context.Context) *exception.AppError {
+			purged, err := cartService.PurgeStaleCarts(ctx, cfg.CartRetention)
+			if purged > 0 {
+				log.Printf("purged %d stale carts", purged)
+			}
+			return err
+		})
+	}
+	if cfg.CartAbandonAfter > 0 {
+		// Record carts left with items in them and remind their owners
+		registerJob(scheduler, "detect-abandoned-carts", everyMinute, func(ctx context.Context) *exception.AppError {
+			detected, err := abandonedCartService.DetectAbandonedCarts(ctx, cfg.CartAbandonAfter)
+			if detected > 0 {
+				log.Printf("found %d abandoned carts", detected)
+			}
+			return err
+		})
+	}
+	// Expired holds no longer count; this only clears them away
+	registerJob(scheduler, "release-expired-stock-holds", everyMinute, func(ctx context.Context) *exception.AppError {
+		_, err := stockHoldService.ReleaseExpiredHolds(ctx)
+		return err
+	})
+	registerJob(scheduler, "purge-idempotency-keys", hourly, func(ctx context.Context) *exception.AppError {
+		_, err := idempotencyService.PurgeExpiredKeys(ctx)
+		return err
+	})
+
+	if err := scheduler.Start(context.Background()); err != nil {
+		log.Fatalf("failed to start job scheduler: %v", err)
+	}
 
 	// Setup router
-	r := router.Setup(cfg, userService, menuService, cartService, orderService, reportService)
+	r := router.Setup(cfg, userService, addressService, menuService, cartService, orderService, reportService, paymentService, refundService, taxService, promotionService, slotService, deliveryZoneService, idempotencyService, orderEventHub, scheduler)
 
 	// Start server
-	log.Printf("Server starting on port %s", cfg.Port)
-	if err := r.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: r,
+	}
+	// Shutdown doesn't cancel request contexts, so end the event streams or it waits them out
+	srv.RegisterOnShutdown(orderEventHub.Close)
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("failed to start server: %v", err)
+		}
+	}()
+
+	// Wait for an interrupt, then let in-flight requests and running jobs finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shut down server gracefully: %v", err)
+	}
+	// Give running jobs their own grace period instead of whatever the server left over
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelStop()
+	if err := scheduler.Stop(stopCtx); err != nil {
+		log.Printf("failed to stop job scheduler gracefully: %v", err)
+	}
+	log.Println("Server stopped")
+}
+
+// registerJob adds a background job to the scheduler, stopping the program if that fails
+func registerJob(scheduler contract.JobScheduler, name string, schedule utils.Schedule, job contract.JobFunc) {
+	if err := scheduler.Register(name, schedule, job); err != nil {
+		log.Fatalf("failed to register job %s: %v", name, err)
 	}
 }
\ No newline at end of file
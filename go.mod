@@ -9,7 +9,6 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
 	golang.org/x/crypto v0.40.0
-	google.golang.org/genproto v0.0.0-20250721164621-a45f3dfb1074
 	gorm.io/driver/mysql v1.6.0
 	gorm.io/gorm v1.30.1
 )
@@ -0,0 +1,15 @@
+package dto
+
+// AddressRequest defines the request body for creating or updating an address book entry
+type AddressRequest struct {
+	Label        string   `json:"label" validate:"omitempty,max=50"`
+	Line1        string   `json:"line1" validate:"required,max=255"`
+	Line2        string   `json:"line2" validate:"omitempty,max=255"`
+	City         string   `json:"city" validate:"required,max=100"`
+	PostalCode   string   `json:"postal_code" validate:"required,max=20"`
+	Country      string   `json:"country" validate:"required,len=2"` // ISO 3166-1 alpha-2, e.g. "US"
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+	Instructions string   `json:"instructions" validate:"omitempty,max=255"`
+	IsDefault    bool     `json:"is_default"`
+}
@@ -4,11 +4,17 @@ import "shopify-app/internal/utils"
 
 // AddToCartRequest defines the request body for adding an item to the cart
 type AddToCartRequest struct {
-	MenuID   utils.BinaryUUID `json:"menu_id" validate:"required"`
-	Quantity int              `json:"quantity" validate:"required,gt=0"`
+	MenuID    utils.BinaryUUID   `json:"menu_id" validate:"required"`
+	Quantity  int                `json:"quantity" validate:"required,gt=0"`
+	OptionIDs []utils.BinaryUUID `json:"option_ids"` // Options chosen from the menu item's option groups
 }
 
 // UpdateCartItemRequest defines the request body for updating a cart item's quantity
 type UpdateCartItemRequest struct {
 	Quantity int `json:"quantity" validate:"required,gte=0"`
 }
+
+// ApplyCouponRequest defines the request body for entering a coupon code on the cart
+type ApplyCouponRequest struct {
+	Code string `json:"code" validate:"required,max=50"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"encoding/json"
+	"shopify-app/internal/utils"
+)
+
+// DeliveryZoneRequest defines the request body for creating or updating a delivery zone
+type DeliveryZoneRequest struct {
+	Name             string                   `json:"name" validate:"required,min=1,max=100"`
+	Type             string                   `json:"type" validate:"required,oneof=polygon radius"`
+	Area             json.RawMessage          `json:"area"`                                        // GeoJSON Polygon or MultiPolygon, polygon zones only
+	RadiusMeters     *int                     `json:"radius_meters" validate:"omitempty,gt=0"`     // Radius zones only
+	MinOrderAmount   *utils.GormDecimal       `json:"min_order_amount" validate:"omitempty,gte=0"` // Defaults to 0
+	EstimatedMinutes int                      `json:"estimated_minutes" validate:"gte=0"`
+	Priority         int                      `json:"priority"` // Lower wins where zones overlap
+	FeeTiers         []DeliveryFeeTierRequest `json:"fee_tiers" validate:"required,min=1,dive"`
+	IsActive         *bool                    `json:"is_active"` // Defaults to true
+}
+
+// DeliveryFeeTierRequest defines one step of a delivery zone's fee schedule
+type DeliveryFeeTierRequest struct {
+	UpToMeters int                `json:"up_to_meters" validate:"gt=0"`   // Distance from the store
+	Fee        *utils.GormDecimal `json:"fee" validate:"omitempty,gte=0"` // Defaults to 0
+}
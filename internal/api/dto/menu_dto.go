@@ -1,21 +1,44 @@
 package dto
 
+import "shopify-app/internal/utils"
+
 // CreateMenuRequest defines the request body for creating a new menu item
 type CreateMenuRequest struct {
-	Name        string  `json:"name" validate:"required,min=2,max=255"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	Category    string  `json:"category" validate:"required,min=2,max=100"`
-	Stock       int     `json:"stock" validate:"gte=0"`
-	ImageURL    string  `json:"image_url" validate:"omitempty,url"`
+	Name        string             `json:"name" validate:"required,min=2,max=255"`
+	Description string             `json:"description"`
+	Price       *utils.GormDecimal `json:"price" validate:"required,gt=0"`
+	Category    string             `json:"category" validate:"required,min=2,max=100"`
+	Stock       int                `json:"stock" validate:"gte=0"`
+	ImageURL    string             `json:"image_url" validate:"omitempty,url"`
 }
 
 // UpdateMenuRequest defines the request body for updating a menu item
 type UpdateMenuRequest struct {
-	Name        string  `json:"name" validate:"required,min=2,max=255"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	Category    string  `json:"category" validate:"required,min=2,max=100"`
-	Stock       int     `json:"stock" validate:"gte=0"`
-	ImageURL    string  `json:"image_url" validate:"omitempty,url"`
+	Name        string             `json:"name" validate:"required,min=2,max=255"`
+	Description string             `json:"description"`
+	Price       *utils.GormDecimal `json:"price" validate:"required,gt=0"`
+	Category    string             `json:"category" validate:"required,min=2,max=100"`
+	Stock       int                `json:"stock" validate:"gte=0"`
+	ImageURL    string             `json:"image_url" validate:"omitempty,url"`
+}
+
+// CreateOptionGroupRequest defines the request body for adding an option group to a menu item
+type CreateOptionGroupRequest struct {
+	Name      string                    `json:"name" validate:"required,min=1,max=100"`
+	MinSelect int                       `json:"min_select" validate:"gte=0"`
+	MaxSelect int                       `json:"max_select" validate:"required,gte=1,gtefield=MinSelect"`
+	SortOrder int                       `json:"sort_order"`
+	Options   []CreateMenuOptionRequest `json:"options" validate:"required,min=1,dive"`
+}
+
+// CreateMenuOptionRequest defines a single option within a new option group
+type CreateMenuOptionRequest struct {
+	Name       string             `json:"name" validate:"required,min=1,max=100"`
+	PriceDelta *utils.GormDecimal `json:"price_delta"`                      // Defaults to 0
+	Stock      *int               `json:"stock" validate:"omitempty,gte=0"` // Omit to leave stock untracked
+}
+
+// UpdateMenuOptionStockRequest defines the request body for updating an option's stock
+type UpdateMenuOptionStockRequest struct {
+	Stock *int `json:"stock" validate:"omitempty,gte=0"` // Null stops tracking stock
 }
@@ -1,8 +1,48 @@
 package dto
 
-import "shopify-app/internal/entities"
+import (
+	"shopify-app/internal/entities"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// CheckoutRequest defines the optional request body for checking out the cart
+type CheckoutRequest struct {
+	FulfilmentType entities.FulfilmentType `json:"fulfilment_type" validate:"omitempty,oneof=pickup delivery dine_in"` // Defaults to pickup
+	AddressID      *utils.BinaryUUID       `json:"address_id"`                                                         // Delivery only; defaults to the default address
+	ScheduledFor   *time.Time              `json:"scheduled_for"`                                                      // Start of a time slot; omit to order for now
+	Tip            *utils.GormDecimal      `json:"tip" validate:"omitempty,gte=0"`
+	QuoteID        string                  `json:"quote_id"` // From POST /api/cart/quote; refuses the checkout if the price has changed since
+}
+
+// CheckoutResponse is the order placed by a checkout. When the payment could not be started,
+// PaymentError says so and the client starts it again through POST /api/orders/:id/payment.
+type CheckoutResponse struct {
+	*entities.Order
+	PaymentError string `json:"payment_error,omitempty"`
+}
+
+// QuoteRequest defines the optional request body for quoting the cart
+type QuoteRequest struct {
+	FulfilmentType entities.FulfilmentType `json:"fulfilment_type" validate:"omitempty,oneof=pickup delivery dine_in"` // Defaults to pickup
+	AddressID      *utils.BinaryUUID       `json:"address_id"`                                                         // Delivery only; defaults to the default address
+	Tip            *utils.GormDecimal      `json:"tip" validate:"omitempty,gte=0"`
+}
 
 // UpdateOrderStatusRequest defines the request body for updating an order's status
 type UpdateOrderStatusRequest struct {
-	Status entities.OrderStatus `json:"status" validate:"required,oneof=pending confirmed preparing ready delivered cancelled"`
+	Status entities.OrderStatus `json:"status" validate:"required,oneof=pending scheduled confirmed preparing ready delivered cancelled"`
+	Reason string               `json:"reason" validate:"omitempty,max=255"`
+}
+
+// AddOrderItemRequest defines the request body for adding an item to a pending order
+type AddOrderItemRequest struct {
+	MenuID    utils.BinaryUUID   `json:"menu_id" validate:"required"`
+	Quantity  int                `json:"quantity" validate:"required,gt=0"`
+	OptionIDs []utils.BinaryUUID `json:"option_ids"`
+}
+
+// UpdateOrderItemRequest defines the request body for changing the quantity of an item of a pending order
+type UpdateOrderItemRequest struct {
+	Quantity int `json:"quantity" validate:"gte=0"` // 0 removes the item
 }
@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// PromotionRequest defines the request body for creating or updating a promotion
+type PromotionRequest struct {
+	Name         string             `json:"name" validate:"required,min=1,max=100"`
+	Code         string             `json:"code" validate:"omitempty,max=50"` // Leave empty for an automatic promotion
+	Type         string             `json:"type" validate:"required,oneof=percentage fixed_amount buy_x_get_y free_item"`
+	Value        *utils.GormDecimal `json:"value" validate:"omitempty,gte=0"` // Percentage or amount off
+	Category     string             `json:"category" validate:"omitempty,max=100"`
+	BuyQuantity  int                `json:"buy_quantity" validate:"gte=0"`
+	GetQuantity  int                `json:"get_quantity" validate:"gte=0"`
+	FreeMenuID   *utils.BinaryUUID  `json:"free_menu_id"`
+	MinSubtotal  *utils.GormDecimal `json:"min_subtotal" validate:"omitempty,gte=0"`
+	StartsAt     *time.Time         `json:"starts_at"`
+	EndsAt       *time.Time         `json:"ends_at"`
+	UsageLimit   *int               `json:"usage_limit" validate:"omitempty,gte=1"`
+	PerUserLimit *int               `json:"per_user_limit" validate:"omitempty,gte=1"`
+	Stackable    bool               `json:"stackable"`
+	IsActive     *bool              `json:"is_active"` // Defaults to true
+}
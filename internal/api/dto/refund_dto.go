@@ -0,0 +1,16 @@
+package dto
+
+import "shopify-app/internal/utils"
+
+// RefundRequest defines the request body for refunding an order; leave items empty for a full refund
+type RefundRequest struct {
+	Items   []RefundItemRequest `json:"items" validate:"dive"`
+	Reason  string              `json:"reason" validate:"required,max=255"`
+	Restock bool                `json:"restock"` // Put the refunded items back into stock
+}
+
+// RefundItemRequest defines the quantity of an order item to refund
+type RefundItemRequest struct {
+	OrderItemID utils.BinaryUUID `json:"order_item_id" validate:"required"`
+	Quantity    int              `json:"quantity" validate:"required,gt=0"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "shopify-app/internal/utils"
+
+// TaxRuleRequest defines the request body for creating or updating a tax rule
+type TaxRuleRequest struct {
+	Name     string             `json:"name" validate:"required,min=1,max=100"`
+	Rate     *utils.GormDecimal `json:"rate" validate:"omitempty,gte=0,lte=100"` // Percentage, e.g. 8.25; defaults to 0
+	Category string             `json:"category" validate:"omitempty,max=100"`
+	MenuID   *utils.BinaryUUID  `json:"menu_id"`
+	IsActive *bool              `json:"is_active"` // Defaults to true
+}
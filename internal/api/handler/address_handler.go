@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"shopify-app/internal/api/dto"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/utils"
+	"shopify-app/pkg/gin_helper"
+	"shopify-app/pkg/web_response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AddressHandler struct {
+	addressService contract.AddressService
+}
+
+func NewAddressHandler(addressService contract.AddressService) *AddressHandler {
+	return &AddressHandler{addressService: addressService}
+}
+
+func (h *AddressHandler) GetAddresses(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	addresses, err := h.addressService.GetAddresses(c.Request.Context(), userID.(utils.BinaryUUID))
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, addresses)
+}
+
+func (h *AddressHandler) CreateAddress(c *gin.Context) {
+	var req dto.AddressRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	userID, _ := c.Get("userID")
+	address, err := h.addressService.CreateAddress(c.Request.Context(), userID.(utils.BinaryUUID), addressFromRequest(&req))
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, address)
+}
+
+func (h *AddressHandler) UpdateAddress(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	var req dto.AddressRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	address := addressFromRequest(&req)
+	address.ID = id
+	userID, _ := c.Get("userID")
+	updated, appErr := h.addressService.UpdateAddress(c.Request.Context(), userID.(utils.BinaryUUID), address)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, updated)
+}
+
+func (h *AddressHandler) DeleteAddress(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	userID, _ := c.Get("userID")
+	if appErr := h.addressService.DeleteAddress(c.Request.Context(), userID.(utils.BinaryUUID), id); appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, "address deleted successfully")
+}
+
+func (h *AddressHandler) SetDefaultAddress(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	userID, _ := c.Get("userID")
+	address, appErr := h.addressService.SetDefaultAddress(c.Request.Context(), userID.(utils.BinaryUUID), id)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, address)
+}
+
+// addressFromRequest builds an address entity from the request body
+func addressFromRequest(req *dto.AddressRequest) *entities.Address {
+	return &entities.Address{
+		Label: req.Label,
+		PostalAddress: entities.PostalAddress{
+			Line1:        req.Line1,
+			Line2:        req.Line2,
+			City:         req.City,
+			PostalCode:   req.PostalCode,
+			Country:      req.Country,
+			Latitude:     req.Latitude,
+			Longitude:    req.Longitude,
+			Instructions: req.Instructions,
+		},
+		IsDefault: req.IsDefault,
+	}
+}
@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"log"
 	"shopify-app/internal/api/dto"
 	"shopify-app/internal/contract"
 	"shopify-app/internal/entities"
+	"shopify-app/internal/middleware"
+	"shopify-app/internal/utils"
 	"shopify-app/pkg/gin_helper"
 	"shopify-app/pkg/web_response"
 
@@ -12,10 +15,11 @@ import (
 
 type AuthHandler struct {
 	userService contract.UserService
+	cartService contract.CartService
 }
 
-func NewAuthHandler(userService contract.UserService) *AuthHandler {
-	return &AuthHandler{userService: userService}
+func NewAuthHandler(userService contract.UserService, cartService contract.CartService) *AuthHandler {
+	return &AuthHandler{userService: userService, cartService: cartService}
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -36,7 +40,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	web_response.Success(c, gin.H{"user": user, "token": token})
+	merge := h.mergeGuestCart(c, user.ID)
+	web_response.Success(c, gin.H{"user": user, "token": token, "cart_merge": merge})
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -52,5 +57,36 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	web_response.Success(c, gin.H{"user": user, "token": token})
+	merge := h.mergeGuestCart(c, user.ID)
+	web_response.Success(c, gin.H{"user": user, "token": token, "cart_merge": merge})
+}
+
+// GuestToken issues a token for an anonymous visitor, to be sent in the X-Guest-Token header of cart requests
+func (h *AuthHandler) GuestToken(c *gin.Context) {
+	token, expiresAt, err := h.userService.CreateGuestToken(c.Request.Context())
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, gin.H{"guest_token": token, "expires_at": expiresAt})
+}
+
+// mergeGuestCart moves the cart of the guest token sent with a login or registration into the
+// user's cart. An expired or invalid guest token is ignored; there is nothing to merge then. The
+// user is signed in by now, so a failed merge is only logged and reported in the result.
+func (h *AuthHandler) mergeGuestCart(c *gin.Context, userID utils.BinaryUUID) *contract.CartMergeResult {
+	guestToken := c.GetHeader(middleware.GuestTokenHeader)
+	if guestToken == "" {
+		return nil
+	}
+	guestID, err := h.userService.ParseGuestToken(guestToken)
+	if err != nil {
+		return nil
+	}
+	merge, appErr := h.cartService.MergeGuestCart(c.Request.Context(), guestID, userID)
+	if appErr != nil {
+		log.Printf("failed to merge guest cart %s into the cart of user %s: %v", guestID, userID, appErr)
+		return &contract.CartMergeResult{Merged: []contract.ReorderItem{}, Skipped: []contract.ReorderItem{}, Error: "the guest cart could not be merged"}
+	}
+	return merge
 }
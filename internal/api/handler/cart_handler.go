@@ -24,8 +24,8 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 		web_response.HandleError(c, err)
 		return
 	}
-	userID, _ := c.Get("userID")
-	err := h.cartService.AddItemToCart(c.Request.Context(), userID.(utils.BinaryUUID), req.MenuID, req.Quantity)
+	owner := cartOwner(c)
+	err := h.cartService.AddItemToCart(c.Request.Context(), owner, req.MenuID, req.Quantity, req.OptionIDs)
 	if err != nil {
 		web_response.HandleError(c, err)
 		return
@@ -34,13 +34,62 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 }
 
 func (h *CartHandler) GetCart(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	cart, total, err := h.cartService.GetUserCart(c.Request.Context(), userID.(utils.BinaryUUID))
+	owner := cartOwner(c)
+	cart, _, err := h.cartService.GetUserCart(c.Request.Context(), owner)
 	if err != nil {
 		web_response.HandleError(c, err)
 		return
 	}
-	web_response.Success(c, gin.H{"cart": cart, "total": total})
+	pricing, err := h.cartService.PriceCart(c.Request.Context(), owner, cart)
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, gin.H{"cart": cart, "total": pricing.Total, "pricing": pricing})
+}
+
+// RevalidateCart updates the cart to the current menu and returns what changed together with the updated cart
+func (h *CartHandler) RevalidateCart(c *gin.Context) {
+	owner := cartOwner(c)
+	changes, err := h.cartService.RevalidateCart(c.Request.Context(), owner)
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	cart, _, err := h.cartService.GetUserCart(c.Request.Context(), owner)
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	pricing, err := h.cartService.PriceCart(c.Request.Context(), owner, cart)
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, gin.H{"changes": changes, "cart": cart, "total": pricing.Total, "pricing": pricing})
+}
+
+func (h *CartHandler) ApplyCoupon(c *gin.Context) {
+	var req dto.ApplyCouponRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	owner := cartOwner(c)
+	if err := h.cartService.ApplyCoupon(c.Request.Context(), owner, req.Code); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, "coupon applied")
+}
+
+func (h *CartHandler) RemoveCoupon(c *gin.Context) {
+	owner := cartOwner(c)
+	if err := h.cartService.RemoveCoupon(c.Request.Context(), owner); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, "coupon removed")
 }
 
 func (h *CartHandler) UpdateCartItem(c *gin.Context) {
@@ -54,8 +103,8 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 		web_response.HandleError(c, err)
 		return
 	}
-	userID, _ := c.Get("userID")
-	appErr := h.cartService.UpdateCartItem(c.Request.Context(), userID.(utils.BinaryUUID), id, req.Quantity)
+	owner := cartOwner(c)
+	appErr := h.cartService.UpdateCartItem(c.Request.Context(), owner, id, req.Quantity)
 	if appErr != nil {
 		web_response.HandleError(c, appErr)
 		return
@@ -69,8 +118,8 @@ func (h *CartHandler) RemoveCartItem(c *gin.Context) {
 		web_response.HandleError(c, err)
 		return
 	}
-	userID, _ := c.Get("userID")
-	appErr := h.cartService.RemoveCartItem(c.Request.Context(), userID.(utils.BinaryUUID), id)
+	owner := cartOwner(c)
+	appErr := h.cartService.RemoveCartItem(c.Request.Context(), owner, id)
 	if appErr != nil {
 		web_response.HandleError(c, appErr)
 		return
@@ -79,11 +128,21 @@ func (h *CartHandler) RemoveCartItem(c *gin.Context) {
 }
 
 func (h *CartHandler) ClearCart(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	err := h.cartService.ClearCart(c.Request.Context(), userID.(utils.BinaryUUID))
+	owner := cartOwner(c)
+	err := h.cartService.ClearCart(c.Request.Context(), owner)
 	if err != nil {
 		web_response.HandleError(c, err)
 		return
 	}
 	web_response.Success(c, "cart cleared")
 }
+
+// cartOwner returns whose cart a request works on: the signed-in user's, or the guest's set by
+// CartOwnerMiddleware
+func cartOwner(c *gin.Context) contract.CartOwner {
+	if userID, ok := c.Get("userID"); ok {
+		return contract.UserCartOwner(userID.(utils.BinaryUUID))
+	}
+	guestID, _ := c.Get("guestID")
+	return contract.GuestCartOwner(guestID.(utils.BinaryUUID))
+}
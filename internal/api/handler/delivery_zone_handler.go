@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"shopify-app/internal/api/dto"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"shopify-app/pkg/gin_helper"
+	"shopify-app/pkg/web_response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeliveryZoneHandler struct {
+	zoneService    contract.DeliveryZoneService
+	addressService contract.AddressService
+}
+
+func NewDeliveryZoneHandler(zoneService contract.DeliveryZoneService, addressService contract.AddressService) *DeliveryZoneHandler {
+	return &DeliveryZoneHandler{zoneService: zoneService, addressService: addressService}
+}
+
+func (h *DeliveryZoneHandler) GetDeliveryZones(c *gin.Context) {
+	zones, err := h.zoneService.GetDeliveryZones(c.Request.Context())
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, zones)
+}
+
+func (h *DeliveryZoneHandler) CreateDeliveryZone(c *gin.Context) {
+	var req dto.DeliveryZoneRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	zone := deliveryZoneFromRequest(&req)
+	created, appErr := h.zoneService.CreateDeliveryZone(c.Request.Context(), zone)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, created)
+}
+
+func (h *DeliveryZoneHandler) UpdateDeliveryZone(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	var req dto.DeliveryZoneRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	zone := deliveryZoneFromRequest(&req)
+	zone.ID = id
+	updated, appErr := h.zoneService.UpdateDeliveryZone(c.Request.Context(), zone)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, updated)
+}
+
+func (h *DeliveryZoneHandler) DeleteDeliveryZone(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	if appErr := h.zoneService.DeleteDeliveryZone(c.Request.Context(), id); appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, "delivery zone deleted successfully")
+}
+
+// QuoteDelivery tells a customer whether an address of their address book (address_id, default
+// address when omitted) can be delivered to, and at what fee
+func (h *DeliveryZoneHandler) QuoteDelivery(c *gin.Context) {
+	var addressID *utils.BinaryUUID
+	if v := c.Query("address_id"); v != "" {
+		id, err := utils.ParseBinaryUUID(v)
+		if err != nil {
+			web_response.HandleError(c, exception.NewValidationError("address_id must be a valid UUID"))
+			return
+		}
+		addressID = &id
+	}
+
+	userID, _ := c.Get("userID")
+	address, appErr := h.addressService.ResolveDeliveryAddress(c.Request.Context(), userID.(utils.BinaryUUID), addressID)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	quote, appErr := h.zoneService.QuoteDelivery(c.Request.Context(), &address.PostalAddress)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, quote)
+}
+
+// deliveryZoneFromRequest builds a delivery zone entity from the request body
+func deliveryZoneFromRequest(req *dto.DeliveryZoneRequest) *entities.DeliveryZone {
+	zone := &entities.DeliveryZone{
+		Name:             req.Name,
+		Type:             entities.DeliveryZoneType(req.Type),
+		Area:             string(req.Area),
+		RadiusMeters:     req.RadiusMeters,
+		MinOrderAmount:   amountOrZero(req.MinOrderAmount),
+		EstimatedMinutes: req.EstimatedMinutes,
+		Priority:         req.Priority,
+		IsActive:         true,
+	}
+	for _, tier := range req.FeeTiers {
+		zone.FeeTiers = append(zone.FeeTiers, entities.DeliveryFeeTier{UpToMeters: tier.UpToMeters, Fee: amountOrZero(tier.Fee)})
+	}
+	if req.IsActive != nil {
+		zone.IsActive = *req.IsActive
+	}
+	return zone
+}
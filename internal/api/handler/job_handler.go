@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"shopify-app/internal/contract"
+	"shopify-app/pkg/web_response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobHandler struct {
+	scheduler contract.JobScheduler
+}
+
+func NewJobHandler(scheduler contract.JobScheduler) *JobHandler {
+	return &JobHandler{scheduler: scheduler}
+}
+
+// GetJobs lists the background jobs with their last run and last error
+func (h *JobHandler) GetJobs(c *gin.Context) {
+	jobs, err := h.scheduler.GetJobs(c.Request.Context())
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, jobs)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"fmt"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"shopify-app/pkg/web_response"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeatInterval is how often an idle event stream sends a heartbeat
+const streamHeartbeatInterval = 15 * time.Second
+
+type KitchenHandler struct {
+	orderService contract.OrderService
+	eventHub     contract.OrderEventHub
+}
+
+func NewKitchenHandler(orderService contract.OrderService, eventHub contract.OrderEventHub) *KitchenHandler {
+	return &KitchenHandler{orderService: orderService, eventHub: eventHub}
+}
+
+// Stream pushes order events to the kitchen display as Server-Sent Events. The optional status
+// query parameter takes a comma separated list of statuses to receive events for.
+func (h *KitchenHandler) Stream(c *gin.Context) {
+	statuses := make(map[entities.OrderStatus]bool)
+	if v := c.Query("status"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			status := entities.OrderStatus(strings.TrimSpace(s))
+			if !status.IsValid() {
+				web_response.HandleError(c, exception.NewValidationError(fmt.Sprintf("invalid order status '%s'", status)))
+				return
+			}
+			statuses[status] = true
+		}
+	}
+
+	events, unsubscribe := h.eventHub.Subscribe(lastEventID(c), func(event contract.OrderEvent) bool {
+		return len(statuses) == 0 || statuses[event.Status]
+	})
+	defer unsubscribe()
+
+	web_response.StartEventStream(c)
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// Dropped for falling behind or shutting down; the client reconnects with Last-Event-ID
+				return
+			}
+			if err := web_response.WriteEvent(c, strconv.FormatUint(event.ID, 10), string(event.Type), event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := web_response.WriteHeartbeat(c); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// BumpOrder moves an order to the next status of the fulfilment flow
+func (h *KitchenHandler) BumpOrder(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	userID, _ := c.Get("userID")
+	staffID := userID.(utils.BinaryUUID)
+	order, appErr := h.orderService.AdvanceOrderStatus(c.Request.Context(), id, &staffID)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, order)
+}
+
+// lastEventID reads the ID of the last event a reconnecting client received. Browsers send the
+// Last-Event-ID header; the query parameter is for clients that can't set headers.
+func lastEventID(c *gin.Context) uint64 {
+	v := c.GetHeader("Last-Event-ID")
+	if v == "" {
+		v = c.Query("last_event_id")
+	}
+	id, _ := strconv.ParseUint(v, 10, 64)
+	return id
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"shopify-app/internal/api/dto"
 	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
 	"shopify-app/internal/utils"
 	"shopify-app/pkg/gin_helper"
 	"shopify-app/pkg/web_response"
@@ -25,8 +26,7 @@ func (h *MenuHandler) CreateMenu(c *gin.Context) {
 		web_response.HandleError(c, err)
 		return
 	}
-	price, _ := utils.Float64ToGormDecimal(req.Price)
-	menu, err := h.menuService.AddMenu(c.Request.Context(), req.Name, req.Description, price, req.Category, req.Stock, req.ImageURL)
+	menu, err := h.menuService.AddMenu(c.Request.Context(), req.Name, req.Description, req.Price, req.Category, req.Stock, req.ImageURL)
 	if err != nil {
 		web_response.HandleError(c, err)
 		return
@@ -74,8 +74,7 @@ func (h *MenuHandler) UpdateMenu(c *gin.Context) {
 		web_response.HandleError(c, err)
 		return
 	}
-	price, _ := utils.Float64ToGormDecimal(req.Price)
-	menu, appErr := h.menuService.UpdateMenu(c.Request.Context(), id, req.Name, req.Description, price, req.Category, req.Stock, req.ImageURL)
+	menu, appErr := h.menuService.UpdateMenu(c.Request.Context(), id, req.Name, req.Description, req.Price, req.Category, req.Stock, req.ImageURL)
 	if appErr != nil {
 		web_response.HandleError(c, appErr)
 		return
@@ -96,3 +95,89 @@ func (h *MenuHandler) DeleteMenu(c *gin.Context) {
 	}
 	web_response.Success(c, "menu deleted successfully")
 }
+
+func (h *MenuHandler) CreateOptionGroup(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	var req dto.CreateOptionGroupRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+
+	group := &entities.MenuOptionGroup{
+		Name:      req.Name,
+		MinSelect: req.MinSelect,
+		MaxSelect: req.MaxSelect,
+		SortOrder: req.SortOrder,
+	}
+	for _, option := range req.Options {
+		group.Options = append(group.Options, entities.MenuOption{
+			Name:       option.Name,
+			PriceDelta: amountOrZero(option.PriceDelta),
+			Stock:      option.Stock,
+			IsActive:   true,
+		})
+	}
+
+	created, appErr := h.menuService.AddOptionGroup(c.Request.Context(), id, group)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, created)
+}
+
+func (h *MenuHandler) DeleteOptionGroup(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	groupID, err := utils.UUIDFromParam(c, "groupId")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	appErr := h.menuService.DeleteOptionGroup(c.Request.Context(), id, groupID)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, "option group deleted successfully")
+}
+
+func (h *MenuHandler) UpdateMenuOptionStock(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	optionID, err := utils.UUIDFromParam(c, "optionId")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	var req dto.UpdateMenuOptionStockRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	option, appErr := h.menuService.UpdateMenuOptionStock(c.Request.Context(), id, optionID, req.Stock)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, option)
+}
+
+// amountOrZero returns an optional amount of a request body, or zero when it was left out
+func amountOrZero(amount *utils.GormDecimal) *utils.GormDecimal {
+	if amount == nil {
+		return utils.NewMoney(0, 2)
+	}
+	return amount
+}
@@ -1,39 +1,96 @@
 package handler
 
 import (
+	"log"
 	"shopify-app/internal/api/dto"
 	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
 	"shopify-app/internal/utils"
 	"shopify-app/pkg/gin_helper"
 	"shopify-app/pkg/web_response"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type OrderHandler struct {
-	orderService contract.OrderService
+	orderService   contract.OrderService
+	paymentService contract.PaymentService
+	eventHub       contract.OrderEventHub
 }
 
-func NewOrderHandler(orderService contract.OrderService) *OrderHandler {
-	return &OrderHandler{orderService: orderService}
+func NewOrderHandler(orderService contract.OrderService, paymentService contract.PaymentService, eventHub contract.OrderEventHub) *OrderHandler {
+	return &OrderHandler{orderService: orderService, paymentService: paymentService, eventHub: eventHub}
 }
 
 func (h *OrderHandler) Checkout(c *gin.Context) {
+	var req dto.CheckoutRequest
+	// The body is optional; an empty one checks out for now
+	if c.Request.ContentLength != 0 {
+		if err := gin_helper.BindAndValidate(c, &req); err != nil {
+			web_response.HandleError(c, err)
+			return
+		}
+	}
+
 	userID, _ := c.Get("userID")
-	order, err := h.orderService.CheckoutCart(c.Request.Context(), userID.(utils.BinaryUUID))
+	order, err := h.orderService.CheckoutCart(c.Request.Context(), userID.(utils.BinaryUUID), contract.CheckoutOptions{
+		FulfilmentType: req.FulfilmentType,
+		AddressID:      req.AddressID,
+		ScheduledFor:   req.ScheduledFor,
+		Tip:            req.Tip,
+		QuoteID:        req.QuoteID,
+	})
 	if err != nil {
 		web_response.HandleError(c, err)
 		return
 	}
-	web_response.Success(c, order)
+
+	// The order stands even if the provider is unavailable; the client can start the payment
+	// again through POST /api/orders/:id/payment
+	response := dto.CheckoutResponse{Order: order}
+	payment, err := h.paymentService.CreatePaymentIntent(c.Request.Context(), order.UserID, order.ID)
+	if err != nil {
+		log.Printf("failed to start payment for order %s: %v", order.ID, err)
+		response.PaymentError = "the payment could not be started; please try again"
+	} else {
+		order.Payments = append(order.Payments, *payment)
+	}
+	web_response.Success(c, response)
+}
+
+// QuoteCart prices the cart of a user or guest as checkout would charge it
+func (h *OrderHandler) QuoteCart(c *gin.Context) {
+	var req dto.QuoteRequest
+	// The body is optional; an empty one quotes a pickup order without a tip
+	if c.Request.ContentLength != 0 {
+		if err := gin_helper.BindAndValidate(c, &req); err != nil {
+			web_response.HandleError(c, err)
+			return
+		}
+	}
+
+	quote, err := h.orderService.QuoteCart(c.Request.Context(), cartOwner(c), contract.QuoteOptions{
+		FulfilmentType: req.FulfilmentType,
+		AddressID:      req.AddressID,
+		Tip:            req.Tip,
+	})
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, quote)
 }
 
 func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	orderNumber := strings.TrimSpace(c.Query("order_number"))
 	userID, _ := c.Get("userID")
-	orders, count, err := h.orderService.GetOrderHistory(c.Request.Context(), userID.(utils.BinaryUUID), offset, limit)
+	orders, count, err := h.orderService.GetOrderHistory(c.Request.Context(), userID.(utils.BinaryUUID), orderNumber, offset, limit)
 	if err != nil {
 		web_response.HandleError(c, err)
 		return
@@ -56,6 +113,59 @@ func (h *OrderHandler) GetOrderDetails(c *gin.Context) {
 	web_response.Success(c, order)
 }
 
+// StreamOrder pushes the progress of one of the customer's orders as Server-Sent Events. The
+// stream starts with the current state and ends once the order is delivered or cancelled.
+func (h *OrderHandler) StreamOrder(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	// Subscribe before reading the current state so no transition in between is missed
+	events, unsubscribe := h.eventHub.Subscribe(lastEventID(c), func(event contract.OrderEvent) bool {
+		return event.OrderID == id
+	})
+	defer unsubscribe()
+
+	tracking, appErr := h.orderService.GetOrderTracking(c.Request.Context(), userID.(utils.BinaryUUID), id)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+
+	web_response.StartEventStream(c)
+	if err := web_response.WriteEvent(c, "", "order.snapshot", tracking); err != nil || tracking.Status.IsFinal() {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			update := h.orderService.OrderTrackingFor(event.Order)
+			if err := web_response.WriteEvent(c, strconv.FormatUint(event.ID, 10), string(event.Type), update); err != nil {
+				return
+			}
+			if update.Status.IsFinal() {
+				return
+			}
+		case <-heartbeat.C:
+			if err := web_response.WriteHeartbeat(c); err != nil {
+				return
+			}
+		}
+	}
+}
+
 func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	id, err := utils.UUIDFromParam(c, "id")
 	if err != nil {
@@ -67,7 +177,9 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		web_response.HandleError(c, err)
 		return
 	}
-	order, appErr := h.orderService.UpdateOrderStatus(c.Request.Context(), id, req.Status)
+	userID, _ := c.Get("userID")
+	adminID := userID.(utils.BinaryUUID)
+	order, appErr := h.orderService.UpdateOrderStatus(c.Request.Context(), id, req.Status, &adminID, req.Reason)
 	if appErr != nil {
 		web_response.HandleError(c, appErr)
 		return
@@ -89,3 +201,169 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	}
 	web_response.Success(c, order)
 }
+
+// ReorderOrder copies the items of a past order into the cart
+func (h *OrderHandler) ReorderOrder(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	userID, _ := c.Get("userID")
+	result, appErr := h.orderService.ReorderOrder(c.Request.Context(), userID.(utils.BinaryUUID), id)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, result)
+}
+
+// AddOrderItem adds an item to a pending order
+func (h *OrderHandler) AddOrderItem(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	var req dto.AddOrderItemRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	h.editOrderItems(c, id, contract.OrderItemChange{MenuID: req.MenuID, Quantity: req.Quantity, OptionIDs: req.OptionIDs})
+}
+
+// UpdateOrderItem changes the quantity of an item of a pending order
+func (h *OrderHandler) UpdateOrderItem(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	itemID, err := utils.UUIDFromParam(c, "itemId")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	var req dto.UpdateOrderItemRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	h.editOrderItems(c, id, contract.OrderItemChange{ItemID: &itemID, Quantity: req.Quantity})
+}
+
+// RemoveOrderItem removes an item from a pending order
+func (h *OrderHandler) RemoveOrderItem(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	itemID, err := utils.UUIDFromParam(c, "itemId")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	h.editOrderItems(c, id, contract.OrderItemChange{ItemID: &itemID, Quantity: 0})
+}
+
+// editOrderItems applies a change to an order as its owner or as an admin
+func (h *OrderHandler) editOrderItems(c *gin.Context, orderID utils.BinaryUUID, change contract.OrderItemChange) {
+	userID, _ := c.Get("userID")
+	userRole, _ := c.Get("userRole")
+	order, appErr := h.orderService.EditOrderItems(c.Request.Context(), userID.(utils.BinaryUUID), orderID, entities.UserRole(userRole.(string)), []contract.OrderItemChange{change})
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, order)
+}
+
+// ListOrders lists all orders for admins, filtered by the query parameters
+func (h *OrderHandler) ListOrders(c *gin.Context) {
+	filter, appErr := parseOrderFilter(c)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	orders, count, appErr := h.orderService.SearchOrders(c.Request.Context(), filter)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, gin.H{"orders": orders, "count": count})
+}
+
+// GetAdminOrderDetails returns any order to an admin, regardless of who placed it
+func (h *OrderHandler) GetAdminOrderDetails(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	userID, _ := c.Get("userID")
+	userRole, _ := c.Get("userRole")
+	order, appErr := h.orderService.GetOrderDetailsWithAccess(c.Request.Context(), userID.(utils.BinaryUUID), id, entities.UserRole(userRole.(string)))
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, order)
+}
+
+// parseOrderFilter reads the order search filter from the query string. Dates are YYYY-MM-DD and
+// end_date is inclusive.
+func parseOrderFilter(c *gin.Context) (contract.OrderFilter, *exception.AppError) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	filter := contract.OrderFilter{
+		Status:        entities.OrderStatus(c.Query("status")),
+		Fulfilment:    entities.FulfilmentType(c.Query("fulfilment_type")),
+		CustomerEmail: c.Query("customer_email"),
+		OrderNumber:   strings.TrimSpace(c.Query("order_number")),
+		SortBy:        c.DefaultQuery("sort_by", "created_at"),
+		SortDesc:      c.DefaultQuery("sort_order", "desc") != "asc",
+		Offset:        offset,
+		Limit:         limit,
+	}
+
+	if v := c.Query("start_date"); v != "" {
+		startDate, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, exception.NewValidationError("start_date must be in YYYY-MM-DD format")
+		}
+		filter.StartDate = &startDate
+	}
+	if v := c.Query("end_date"); v != "" {
+		endDate, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, exception.NewValidationError("end_date must be in YYYY-MM-DD format")
+		}
+		endDate = endDate.AddDate(0, 0, 1)
+		filter.EndDate = &endDate
+	}
+	if v := c.Query("min_total"); v != "" {
+		minTotal, err := utils.StringToGormDecimal(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.MinTotal = minTotal
+	}
+	if v := c.Query("max_total"); v != "" {
+		maxTotal, err := utils.StringToGormDecimal(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.MaxTotal = maxTotal
+	}
+	if v := c.Query("menu_id"); v != "" {
+		menuID, err := utils.ParseBinaryUUID(v)
+		if err != nil {
+			return filter, exception.NewValidationError("menu_id must be a valid UUID")
+		}
+		filter.MenuID = &menuID
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"io"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"shopify-app/pkg/web_response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandler struct {
+	paymentService contract.PaymentService
+}
+
+func NewPaymentHandler(paymentService contract.PaymentService) *PaymentHandler {
+	return &PaymentHandler{paymentService: paymentService}
+}
+
+// CreatePayment starts the payment of a pending order, e.g. after a failed attempt
+func (h *PaymentHandler) CreatePayment(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	userID, _ := c.Get("userID")
+	payment, appErr := h.paymentService.CreatePaymentIntent(c.Request.Context(), userID.(utils.BinaryUUID), id)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, payment)
+}
+
+func (h *PaymentHandler) GetOrderPayments(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	userID, _ := c.Get("userID")
+	payments, appErr := h.paymentService.GetOrderPayments(c.Request.Context(), userID.(utils.BinaryUUID), id)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, payments)
+}
+
+// HandleWebhook receives payment notifications from the provider named in the URL. The raw body
+// is passed on untouched because the signature is computed over it.
+func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		web_response.HandleError(c, exception.NewAppError(err, "failed to read request body", exception.CodeValidation))
+		return
+	}
+	if appErr := h.paymentService.HandleWebhook(c.Request.Context(), c.Param("provider"), payload, c.Request.Header); appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, gin.H{"received": true})
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"shopify-app/internal/api/dto"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/utils"
+	"shopify-app/pkg/gin_helper"
+	"shopify-app/pkg/web_response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionHandler struct {
+	promotionService contract.PromotionService
+}
+
+func NewPromotionHandler(promotionService contract.PromotionService) *PromotionHandler {
+	return &PromotionHandler{promotionService: promotionService}
+}
+
+func (h *PromotionHandler) GetPromotions(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	promotions, count, err := h.promotionService.GetPromotions(c.Request.Context(), offset, limit)
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, gin.H{"promotions": promotions, "count": count})
+}
+
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var req dto.PromotionRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	promotion := promotionFromRequest(&req)
+	created, appErr := h.promotionService.CreatePromotion(c.Request.Context(), promotion)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, created)
+}
+
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	var req dto.PromotionRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	promotion := promotionFromRequest(&req)
+	promotion.ID = id
+	updated, appErr := h.promotionService.UpdatePromotion(c.Request.Context(), promotion)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, updated)
+}
+
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	if appErr := h.promotionService.DeletePromotion(c.Request.Context(), id); appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, "promotion deleted successfully")
+}
+
+// promotionFromRequest builds a promotion entity from the request body
+func promotionFromRequest(req *dto.PromotionRequest) *entities.Promotion {
+	promotion := &entities.Promotion{
+		Name:         req.Name,
+		Type:         entities.PromotionType(req.Type),
+		Value:        amountOrZero(req.Value),
+		Category:     req.Category,
+		BuyQuantity:  req.BuyQuantity,
+		GetQuantity:  req.GetQuantity,
+		FreeMenuID:   req.FreeMenuID,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		UsageLimit:   req.UsageLimit,
+		PerUserLimit: req.PerUserLimit,
+		MinSubtotal:  req.MinSubtotal,
+		Stackable:    req.Stackable,
+		IsActive:     true,
+	}
+	if req.Code != "" {
+		promotion.Code = &req.Code
+	}
+	if req.IsActive != nil {
+		promotion.IsActive = *req.IsActive
+	}
+	return promotion
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"shopify-app/internal/api/dto"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/utils"
+	"shopify-app/pkg/gin_helper"
+	"shopify-app/pkg/web_response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandler struct {
+	refundService contract.RefundService
+}
+
+func NewRefundHandler(refundService contract.RefundService) *RefundHandler {
+	return &RefundHandler{refundService: refundService}
+}
+
+func (h *RefundHandler) RefundOrder(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	var req dto.RefundRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+
+	request := contract.RefundRequest{Reason: req.Reason, Restock: req.Restock}
+	for _, item := range req.Items {
+		request.Items = append(request.Items, contract.RefundItemRequest{OrderItemID: item.OrderItemID, Quantity: item.Quantity})
+	}
+	userID, _ := c.Get("userID")
+	adminID := userID.(utils.BinaryUUID)
+	refund, appErr := h.refundService.RefundOrder(c.Request.Context(), id, &adminID, request)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, refund)
+}
+
+func (h *RefundHandler) GetOrderRefunds(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	refunds, appErr := h.refundService.GetOrderRefunds(c.Request.Context(), id)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, refunds)
+}
@@ -58,3 +58,50 @@ func (h *ReportHandler) GetBestSellingItems(c *gin.Context) {
 	}
 	web_response.Success(c, report)
 }
+
+// GetSlotLoad shows how full the upcoming time slots are, from start_date (default today) for
+// the following days up to end_date (default a week ahead)
+func (h *ReportHandler) GetSlotLoad(c *gin.Context) {
+	today := time.Now().Format("2006-01-02")
+	startDate, err := time.ParseInLocation("2006-01-02", c.DefaultQuery("start_date", today), time.Local)
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", c.DefaultQuery("end_date", startDate.AddDate(0, 0, 7).Format("2006-01-02")), time.Local)
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+
+	// Include every slot of the end date
+	load, appErr := h.reportService.GetSlotLoadReport(c.Request.Context(), startDate, endDate.AddDate(0, 0, 1))
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, load)
+}
+
+// GetAbandonedCarts shows the abandonment and recovery rates of the carts abandoned between
+// start_date and end_date, and the items left behind most often (limit, default 5)
+func (h *ReportHandler) GetAbandonedCarts(c *gin.Context) {
+	startDate, err := time.Parse("2006-01-02", c.Query("start_date"))
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", c.Query("end_date"))
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+
+	report, appErr := h.reportService.GetAbandonedCartReport(c.Request.Context(), startDate, endDate, limit)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, report)
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"shopify-app/internal/contract"
+	"shopify-app/pkg/web_response"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SlotHandler struct {
+	slotService contract.SlotService
+}
+
+func NewSlotHandler(slotService contract.SlotService) *SlotHandler {
+	return &SlotHandler{slotService: slotService}
+}
+
+// GetSlots lists the time slots of a day (YYYY-MM-DD, default today) that can be scheduled
+func (h *SlotHandler) GetSlots(c *gin.Context) {
+	day, err := time.ParseInLocation("2006-01-02", c.DefaultQuery("date", time.Now().Format("2006-01-02")), time.Local)
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+
+	slots, appErr := h.slotService.GetSlots(c.Request.Context(), day)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, slots)
+}
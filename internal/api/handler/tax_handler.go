@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"shopify-app/internal/api/dto"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/utils"
+	"shopify-app/pkg/gin_helper"
+	"shopify-app/pkg/web_response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxHandler struct {
+	taxService contract.TaxService
+}
+
+func NewTaxHandler(taxService contract.TaxService) *TaxHandler {
+	return &TaxHandler{taxService: taxService}
+}
+
+func (h *TaxHandler) GetTaxRules(c *gin.Context) {
+	rules, err := h.taxService.GetTaxRules(c.Request.Context())
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	web_response.Success(c, rules)
+}
+
+func (h *TaxHandler) CreateTaxRule(c *gin.Context) {
+	var req dto.TaxRuleRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	rule := taxRuleFromRequest(&req)
+	created, appErr := h.taxService.CreateTaxRule(c.Request.Context(), rule)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, created)
+}
+
+func (h *TaxHandler) UpdateTaxRule(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	var req dto.TaxRuleRequest
+	if err := gin_helper.BindAndValidate(c, &req); err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	rule := taxRuleFromRequest(&req)
+	rule.ID = id
+	updated, appErr := h.taxService.UpdateTaxRule(c.Request.Context(), rule)
+	if appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, updated)
+}
+
+func (h *TaxHandler) DeleteTaxRule(c *gin.Context) {
+	id, err := utils.UUIDFromParam(c, "id")
+	if err != nil {
+		web_response.HandleError(c, err)
+		return
+	}
+	if appErr := h.taxService.DeleteTaxRule(c.Request.Context(), id); appErr != nil {
+		web_response.HandleError(c, appErr)
+		return
+	}
+	web_response.Success(c, "tax rule deleted successfully")
+}
+
+// taxRuleFromRequest builds a tax rule entity from the request body
+func taxRuleFromRequest(req *dto.TaxRuleRequest) *entities.TaxRule {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	return &entities.TaxRule{
+		Name:     req.Name,
+		Rate:     amountOrZero(req.Rate),
+		Category: req.Category,
+		MenuID:   req.MenuID,
+		IsActive: isActive,
+	}
+}
@@ -13,27 +13,67 @@ import (
 func Setup(
 	cfg *config.Config,
 	userService contract.UserService,
+	addressService contract.AddressService,
 	menuService contract.MenuService,
 	cartService contract.CartService,
 	orderService contract.OrderService,
 	reportService contract.ReportService,
+	paymentService contract.PaymentService,
+	refundService contract.RefundService,
+	taxService contract.TaxService,
+	promotionService contract.PromotionService,
+	slotService contract.SlotService,
+	deliveryZoneService contract.DeliveryZoneService,
+	idempotencyService contract.IdempotencyService,
+	orderEventHub contract.OrderEventHub,
+	scheduler contract.JobScheduler,
 ) *gin.Engine {
 	r := gin.Default()
 
-	authHandler := handler.NewAuthHandler(userService)
+	authHandler := handler.NewAuthHandler(userService, cartService)
 	userHandler := handler.NewUserHandler(userService)
+	addressHandler := handler.NewAddressHandler(addressService)
 	menuHandler := handler.NewMenuHandler(menuService)
 	cartHandler := handler.NewCartHandler(cartService)
-	orderHandler := handler.NewOrderHandler(orderService)
+	orderHandler := handler.NewOrderHandler(orderService, paymentService, orderEventHub)
 	reportHandler := handler.NewReportHandler(reportService)
+	kitchenHandler := handler.NewKitchenHandler(orderService, orderEventHub)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	refundHandler := handler.NewRefundHandler(refundService)
+	taxHandler := handler.NewTaxHandler(taxService)
+	promotionHandler := handler.NewPromotionHandler(promotionService)
+	slotHandler := handler.NewSlotHandler(slotService)
+	deliveryZoneHandler := handler.NewDeliveryZoneHandler(deliveryZoneService, addressService)
+	jobHandler := handler.NewJobHandler(scheduler)
+
+	idempotency := middleware.IdempotencyMiddleware(idempotencyService)
 
 	// Public routes
 	authRoutes := r.Group("/auth")
 	{
 		authRoutes.POST("/register", authHandler.Register)
 		authRoutes.POST("/login", authHandler.Login)
+		authRoutes.POST("/guest", authHandler.GuestToken)
+	}
+
+	// Cart routes, for signed-in users and for guests with a guest token
+	cartRoutes := r.Group("/api/cart")
+	cartRoutes.Use(middleware.CartOwnerMiddleware(cfg))
+	{
+		cartRoutes.GET("/", cartHandler.GetCart)
+		cartRoutes.POST("/items", idempotency, cartHandler.AddToCart)
+		cartRoutes.PUT("/items/:id", cartHandler.UpdateCartItem)
+		cartRoutes.DELETE("/items/:id", cartHandler.RemoveCartItem)
+		cartRoutes.DELETE("/", cartHandler.ClearCart)
+		cartRoutes.POST("/revalidate", cartHandler.RevalidateCart)
+		cartRoutes.POST("/quote", orderHandler.QuoteCart)
+		cartRoutes.PUT("/coupon", cartHandler.ApplyCoupon)
+		cartRoutes.DELETE("/coupon", cartHandler.RemoveCoupon)
 	}
 
+	// Payment provider webhooks, authenticated by their signature
+	r.POST("/webhooks/payments/:provider", paymentHandler.HandleWebhook)
+
 	// Authenticated routes
 	api := r.Group("/api")
 	api.Use(middleware.AuthMiddleware(cfg))
@@ -44,6 +84,11 @@ func Setup(
 			userRoutes.GET("/profile", userHandler.GetProfile)
 			userRoutes.PUT("/profile", userHandler.UpdateProfile)
 			userRoutes.POST("/change-password", userHandler.ChangePassword)
+			userRoutes.GET("/addresses", addressHandler.GetAddresses)
+			userRoutes.POST("/addresses", addressHandler.CreateAddress)
+			userRoutes.PUT("/addresses/:id", addressHandler.UpdateAddress)
+			userRoutes.DELETE("/addresses/:id", addressHandler.DeleteAddress)
+			userRoutes.PUT("/addresses/:id/default", addressHandler.SetDefaultAddress)
 		}
 
 		// Menu routes (publicly readable)
@@ -60,32 +105,87 @@ func Setup(
 			adminMenuRoutes.POST("/", menuHandler.CreateMenu)
 			adminMenuRoutes.PUT("/:id", menuHandler.UpdateMenu)
 			adminMenuRoutes.DELETE("/:id", menuHandler.DeleteMenu)
+			adminMenuRoutes.POST("/:id/option-groups", menuHandler.CreateOptionGroup)
+			adminMenuRoutes.DELETE("/:id/option-groups/:groupId", menuHandler.DeleteOptionGroup)
+			adminMenuRoutes.PUT("/:id/options/:optionId/stock", menuHandler.UpdateMenuOptionStock)
 		}
 
-		// Cart routes
-		cartRoutes := api.Group("/cart")
-		{
-			cartRoutes.GET("/", cartHandler.GetCart)
-			cartRoutes.POST("/items", cartHandler.AddToCart)
-			cartRoutes.PUT("/items/:id", cartHandler.UpdateCartItem)
-			cartRoutes.DELETE("/items/:id", cartHandler.RemoveCartItem)
-			cartRoutes.DELETE("/", cartHandler.ClearCart)
-		}
+		// Time slot routes
+		api.GET("/slots", slotHandler.GetSlots)
+
+		// Delivery routes
+		api.GET("/delivery/quote", deliveryZoneHandler.QuoteDelivery)
 
 		// Order routes
 		orderRoutes := api.Group("/orders")
 		{
-			orderRoutes.POST("/checkout", orderHandler.Checkout)
+			orderRoutes.POST("/checkout", idempotency, orderHandler.Checkout)
 			orderRoutes.GET("/", orderHandler.GetOrderHistory)
 			orderRoutes.GET("/:id", orderHandler.GetOrderDetails)
-			orderRoutes.POST("/:id/cancel", orderHandler.CancelOrder)
+			orderRoutes.GET("/:id/stream", orderHandler.StreamOrder)
+			orderRoutes.POST("/:id/cancel", idempotency, orderHandler.CancelOrder)
+			orderRoutes.POST("/:id/reorder", idempotency, orderHandler.ReorderOrder)
+			orderRoutes.POST("/:id/items", idempotency, orderHandler.AddOrderItem)
+			orderRoutes.PUT("/:id/items/:itemId", orderHandler.UpdateOrderItem)
+			orderRoutes.DELETE("/:id/items/:itemId", orderHandler.RemoveOrderItem)
+			orderRoutes.POST("/:id/payment", paymentHandler.CreatePayment)
+			orderRoutes.GET("/:id/payments", paymentHandler.GetOrderPayments)
 		}
 
 		// Admin-only order routes
 		adminOrderRoutes := api.Group("/admin/orders")
 		adminOrderRoutes.Use(middleware.RoleMiddleware(entities.RoleAdmin))
 		{
+			adminOrderRoutes.GET("/", orderHandler.ListOrders)
+			adminOrderRoutes.GET("/:id", orderHandler.GetAdminOrderDetails)
 			adminOrderRoutes.PUT("/:id/status", orderHandler.UpdateOrderStatus)
+			adminOrderRoutes.POST("/:id/refunds", idempotency, refundHandler.RefundOrder)
+			adminOrderRoutes.GET("/:id/refunds", refundHandler.GetOrderRefunds)
+		}
+
+		// Admin-only tax rule routes
+		adminTaxRoutes := api.Group("/admin/tax-rules")
+		adminTaxRoutes.Use(middleware.RoleMiddleware(entities.RoleAdmin))
+		{
+			adminTaxRoutes.GET("/", taxHandler.GetTaxRules)
+			adminTaxRoutes.POST("/", taxHandler.CreateTaxRule)
+			adminTaxRoutes.PUT("/:id", taxHandler.UpdateTaxRule)
+			adminTaxRoutes.DELETE("/:id", taxHandler.DeleteTaxRule)
+		}
+
+		// Admin-only promotion routes
+		adminPromotionRoutes := api.Group("/admin/promotions")
+		adminPromotionRoutes.Use(middleware.RoleMiddleware(entities.RoleAdmin))
+		{
+			adminPromotionRoutes.GET("/", promotionHandler.GetPromotions)
+			adminPromotionRoutes.POST("/", promotionHandler.CreatePromotion)
+			adminPromotionRoutes.PUT("/:id", promotionHandler.UpdatePromotion)
+			adminPromotionRoutes.DELETE("/:id", promotionHandler.DeletePromotion)
+		}
+
+		// Admin-only delivery zone routes
+		adminDeliveryZoneRoutes := api.Group("/admin/delivery-zones")
+		adminDeliveryZoneRoutes.Use(middleware.RoleMiddleware(entities.RoleAdmin))
+		{
+			adminDeliveryZoneRoutes.GET("/", deliveryZoneHandler.GetDeliveryZones)
+			adminDeliveryZoneRoutes.POST("/", deliveryZoneHandler.CreateDeliveryZone)
+			adminDeliveryZoneRoutes.PUT("/:id", deliveryZoneHandler.UpdateDeliveryZone)
+			adminDeliveryZoneRoutes.DELETE("/:id", deliveryZoneHandler.DeleteDeliveryZone)
+		}
+
+		// Kitchen display routes (admin only)
+		kitchenRoutes := api.Group("/admin/kitchen")
+		kitchenRoutes.Use(middleware.RoleMiddleware(entities.RoleAdmin))
+		{
+			kitchenRoutes.GET("/stream", kitchenHandler.Stream)
+			kitchenRoutes.POST("/orders/:id/bump", kitchenHandler.BumpOrder)
+		}
+
+		// Background job routes (admin only)
+		adminJobRoutes := api.Group("/admin/jobs")
+		adminJobRoutes.Use(middleware.RoleMiddleware(entities.RoleAdmin))
+		{
+			adminJobRoutes.GET("/", jobHandler.GetJobs)
 		}
 
 		// Report routes (admin only)
@@ -94,6 +194,8 @@ func Setup(
 		{
 			reportRoutes.GET("/sales", reportHandler.GetSalesReport)
 			reportRoutes.GET("/bestsellers", reportHandler.GetBestSellingItems)
+			reportRoutes.GET("/slots", reportHandler.GetSlotLoad)
+			reportRoutes.GET("/abandoned-carts", reportHandler.GetAbandonedCarts)
 		}
 	}
 
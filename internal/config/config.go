@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log" // Added log for debug prints
 	"os"
+	"shopify-app/internal/utils"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,6 +20,64 @@ type Config struct {
 	DBName     string
 	JWTSecret  string
 	Port       string
+
+	// GuestTokenTTL is how long an anonymous visitor's cart token stays valid
+	GuestTokenTTL time.Duration
+	// QuoteTTL is how long checkout can pin to a cart quote
+	QuoteTTL time.Duration
+
+	// IdempotencyKeyTTL is how long a stored Idempotency-Key response can be replayed
+	IdempotencyKeyTTL time.Duration
+
+	// OrderEventHistorySize is how many order events are kept so reconnecting streams can resume
+	OrderEventHistorySize int
+
+	// OrderPrepTime is how long the kitchen usually needs to prepare an order, used for ready estimates
+	OrderPrepTime time.Duration
+
+	// OrderNumberFormat is the template of the human-friendly order numbers, counted per day
+	OrderNumberFormat utils.OrderNumberFormat
+
+	// PaymentWebhookSecret is the shared secret used to verify payment provider webhooks
+	PaymentWebhookSecret string
+	// PaymentCurrency is the ISO 4217 currency code payments are made in
+	PaymentCurrency string
+
+	// TaxPricesIncludeTax is true when menu prices already contain tax (VAT style)
+	TaxPricesIncludeTax bool
+	// TaxRounding is how each calculated tax and percentage discount amount is rounded to cents
+	TaxRounding utils.RoundingMode
+
+	// SlotInterval is the length of a pickup or delivery time slot
+	SlotInterval time.Duration
+	// SlotOpensAt and SlotClosesAt bound the slots of a day, as time since midnight
+	SlotOpensAt  time.Duration
+	SlotClosesAt time.Duration
+	// SlotMaxOrders and SlotMaxItems cap how much can be booked into one slot; 0 means no limit
+	SlotMaxOrders int
+	SlotMaxItems  int
+	// SlotBookingDays is how many days ahead customers can schedule an order
+	SlotBookingDays int
+	// ScheduledOrderLeadTime is how long before its slot a scheduled order is released to the kitchen
+	ScheduledOrderLeadTime time.Duration
+
+	// StoreLocation is where deliveries start from; radius zones and delivery fees are measured from it
+	StoreLocation utils.GeoPoint
+
+	// PendingOrderTimeout is how long an order may stay pending before it is cancelled; 0 keeps them forever
+	PendingOrderTimeout time.Duration
+	// CartRetention is how long an untouched cart is kept; 0 keeps them forever
+	CartRetention time.Duration
+	// StockHoldTTL is how long a cart keeps its contents aside after it last changed; 0 turns holds off
+	StockHoldTTL time.Duration
+	// CartAbandonAfter is how long a cart with items may go untouched before it counts as abandoned; 0 turns detection off
+	CartAbandonAfter time.Duration
+	// CartReminders is true when customers are reminded of the carts they abandoned
+	CartReminders bool
+	// CartPurgeSchedule is the cron expression the stale cart purge runs on
+	CartPurgeSchedule utils.Schedule
+	// ShutdownTimeout is how long in-flight requests, and then running jobs, each get to finish on shutdown
+	ShutdownTimeout time.Duration
 }
 
 // LoadConfig loads configuration from environment variables or a .env file.
@@ -43,6 +103,9 @@ func LoadConfig() (*Config, error) {
 		DBName:     getEnv("DB_NAME", "ticketbooking_db"),
 		JWTSecret:  getEnv("JWT_SECRET", "supersecretjwtkey"),
 		Port:       getEnv("PORT", "8080"),
+
+		PaymentWebhookSecret: getEnv("PAYMENT_WEBHOOK_SECRET", "supersecretwebhookkey"),
+		PaymentCurrency:      getEnv("PAYMENT_CURRENCY", "USD"),
 	}
 
 	// Debug print for loaded values
@@ -64,15 +127,178 @@ func LoadConfig() (*Config, error) {
 	if cfg.DBUser == "" || cfg.DBHost == "" || cfg.DBName == "" || cfg.JWTSecret == "" {
 		return nil, fmt.Errorf("critical database or JWT configuration missing. Please check DB_USER, DB_HOST, DB_NAME, JWT_SECRET")
 	}
+	if cfg.PaymentWebhookSecret == "" || len(cfg.PaymentCurrency) != 3 {
+		return nil, fmt.Errorf("invalid payment configuration. Please check PAYMENT_WEBHOOK_SECRET, PAYMENT_CURRENCY")
+	}
 
 	// Optional: Validate port is a number
 	if _, err := strconv.Atoi(cfg.Port); err != nil {
 		return nil, fmt.Errorf("invalid PORT value: %v", err)
 	}
 
+	idempotencyKeyTTL, err := time.ParseDuration(getEnv("IDEMPOTENCY_KEY_TTL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_KEY_TTL value: %v", err)
+	}
+	cfg.IdempotencyKeyTTL = idempotencyKeyTTL
+
+	rawGuestTokenTTL := getEnv("GUEST_TOKEN_TTL", "720h")
+	guestTokenTTL, err := time.ParseDuration(rawGuestTokenTTL)
+	if err != nil || guestTokenTTL <= 0 {
+		return nil, fmt.Errorf("invalid GUEST_TOKEN_TTL value: %q", rawGuestTokenTTL)
+	}
+	cfg.GuestTokenTTL = guestTokenTTL
+
+	rawQuoteTTL := getEnv("QUOTE_TTL", "15m")
+	quoteTTL, err := time.ParseDuration(rawQuoteTTL)
+	if err != nil || quoteTTL <= 0 {
+		return nil, fmt.Errorf("invalid QUOTE_TTL value: %q", rawQuoteTTL)
+	}
+	cfg.QuoteTTL = quoteTTL
+
+	rawHistorySize := getEnv("ORDER_EVENT_HISTORY_SIZE", "1000")
+	orderEventHistorySize, err := strconv.Atoi(rawHistorySize)
+	if err != nil || orderEventHistorySize < 0 {
+		return nil, fmt.Errorf("invalid ORDER_EVENT_HISTORY_SIZE value: %q", rawHistorySize)
+	}
+	cfg.OrderEventHistorySize = orderEventHistorySize
+
+	orderPrepTime, err := time.ParseDuration(getEnv("ORDER_PREP_TIME", "20m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_PREP_TIME value: %v", err)
+	}
+	cfg.OrderPrepTime = orderPrepTime
+
+	if cfg.OrderNumberFormat, err = utils.ParseOrderNumberFormat(getEnv("ORDER_NUMBER_FORMAT", string(utils.DefaultOrderNumberFormat))); err != nil {
+		return nil, fmt.Errorf("invalid ORDER_NUMBER_FORMAT value: %v", err)
+	}
+
+	taxPricesIncludeTax, err := strconv.ParseBool(getEnv("TAX_PRICES_INCLUDE_TAX", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TAX_PRICES_INCLUDE_TAX value: %v", err)
+	}
+	cfg.TaxPricesIncludeTax = taxPricesIncludeTax
+
+	cfg.TaxRounding = utils.RoundingMode(getEnv("TAX_ROUNDING", string(utils.RoundHalfUp)))
+	if !cfg.TaxRounding.IsValid() {
+		return nil, fmt.Errorf("invalid TAX_ROUNDING value: %q (use half_up, half_even, up or down)", cfg.TaxRounding)
+	}
+
+	rawSlotInterval := getEnv("SLOT_INTERVAL", "15m")
+	slotInterval, err := time.ParseDuration(rawSlotInterval)
+	if err != nil || slotInterval <= 0 {
+		return nil, fmt.Errorf("invalid SLOT_INTERVAL value: %q", rawSlotInterval)
+	}
+	cfg.SlotInterval = slotInterval
+
+	if cfg.SlotOpensAt, err = parseClock(getEnv("SLOT_OPENS_AT", "09:00")); err != nil {
+		return nil, fmt.Errorf("invalid SLOT_OPENS_AT value: %v", err)
+	}
+	if cfg.SlotClosesAt, err = parseClock(getEnv("SLOT_CLOSES_AT", "21:00")); err != nil {
+		return nil, fmt.Errorf("invalid SLOT_CLOSES_AT value: %v", err)
+	}
+	if cfg.SlotClosesAt <= cfg.SlotOpensAt {
+		return nil, fmt.Errorf("SLOT_CLOSES_AT must be after SLOT_OPENS_AT")
+	}
+
+	rawSlotMaxOrders := getEnv("SLOT_MAX_ORDERS", "10")
+	slotMaxOrders, err := strconv.Atoi(rawSlotMaxOrders)
+	if err != nil || slotMaxOrders < 0 {
+		return nil, fmt.Errorf("invalid SLOT_MAX_ORDERS value: %q", rawSlotMaxOrders)
+	}
+	cfg.SlotMaxOrders = slotMaxOrders
+
+	rawSlotMaxItems := getEnv("SLOT_MAX_ITEMS", "0")
+	slotMaxItems, err := strconv.Atoi(rawSlotMaxItems)
+	if err != nil || slotMaxItems < 0 {
+		return nil, fmt.Errorf("invalid SLOT_MAX_ITEMS value: %q", rawSlotMaxItems)
+	}
+	cfg.SlotMaxItems = slotMaxItems
+
+	rawSlotBookingDays := getEnv("SLOT_BOOKING_DAYS", "7")
+	slotBookingDays, err := strconv.Atoi(rawSlotBookingDays)
+	if err != nil || slotBookingDays < 0 {
+		return nil, fmt.Errorf("invalid SLOT_BOOKING_DAYS value: %q", rawSlotBookingDays)
+	}
+	cfg.SlotBookingDays = slotBookingDays
+
+	rawLeadTime := getEnv("SCHEDULED_ORDER_LEAD_TIME", "30m")
+	scheduledOrderLeadTime, err := time.ParseDuration(rawLeadTime)
+	if err != nil || scheduledOrderLeadTime < 0 {
+		return nil, fmt.Errorf("invalid SCHEDULED_ORDER_LEAD_TIME value: %q", rawLeadTime)
+	}
+	cfg.ScheduledOrderLeadTime = scheduledOrderLeadTime
+
+	storeLatitude, err := strconv.ParseFloat(getEnv("STORE_LATITUDE", "0"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STORE_LATITUDE value: %v", err)
+	}
+	storeLongitude, err := strconv.ParseFloat(getEnv("STORE_LONGITUDE", "0"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STORE_LONGITUDE value: %v", err)
+	}
+	cfg.StoreLocation = utils.GeoPoint{Lat: storeLatitude, Lng: storeLongitude}
+	if !cfg.StoreLocation.IsValid() {
+		return nil, fmt.Errorf("STORE_LATITUDE and STORE_LONGITUDE are out of range")
+	}
+
+	rawPendingOrderTimeout := getEnv("PENDING_ORDER_TIMEOUT", "30m")
+	pendingOrderTimeout, err := time.ParseDuration(rawPendingOrderTimeout)
+	if err != nil || pendingOrderTimeout < 0 {
+		return nil, fmt.Errorf("invalid PENDING_ORDER_TIMEOUT value: %q", rawPendingOrderTimeout)
+	}
+	cfg.PendingOrderTimeout = pendingOrderTimeout
+
+	rawCartRetention := getEnv("CART_RETENTION", "720h")
+	cartRetention, err := time.ParseDuration(rawCartRetention)
+	if err != nil || cartRetention < 0 {
+		return nil, fmt.Errorf("invalid CART_RETENTION value: %q", rawCartRetention)
+	}
+	cfg.CartRetention = cartRetention
+
+	rawStockHoldTTL := getEnv("STOCK_HOLD_TTL", "0")
+	stockHoldTTL, err := time.ParseDuration(rawStockHoldTTL)
+	if err != nil || stockHoldTTL < 0 {
+		return nil, fmt.Errorf("invalid STOCK_HOLD_TTL value: %q", rawStockHoldTTL)
+	}
+	cfg.StockHoldTTL = stockHoldTTL
+
+	rawCartAbandonAfter := getEnv("CART_ABANDON_AFTER", "1h")
+	cartAbandonAfter, err := time.ParseDuration(rawCartAbandonAfter)
+	if err != nil || cartAbandonAfter < 0 {
+		return nil, fmt.Errorf("invalid CART_ABANDON_AFTER value: %q", rawCartAbandonAfter)
+	}
+	cfg.CartAbandonAfter = cartAbandonAfter
+
+	cartReminders, err := strconv.ParseBool(getEnv("CART_REMINDERS", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CART_REMINDERS value: %v", err)
+	}
+	cfg.CartReminders = cartReminders
+
+	if cfg.CartPurgeSchedule, err = utils.ParseCron(getEnv("CART_PURGE_SCHEDULE", "0 3 * * *")); err != nil {
+		return nil, fmt.Errorf("invalid CART_PURGE_SCHEDULE value: %v", err)
+	}
+
+	rawShutdownTimeout := getEnv("SHUTDOWN_TIMEOUT", "30s")
+	shutdownTimeout, err := time.ParseDuration(rawShutdownTimeout)
+	if err != nil || shutdownTimeout <= 0 {
+		return nil, fmt.Errorf("invalid SHUTDOWN_TIMEOUT value: %q", rawShutdownTimeout)
+	}
+	cfg.ShutdownTimeout = shutdownTimeout
+
 	return cfg, nil
 }
 
+// parseClock parses a time of day in HH:MM format into the time since midnight.
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
 // getEnv retrieves an environment variable or returns a fallback value.
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
@@ -0,0 +1,24 @@
+// internal/contract/abandoned_cart_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"time"
+)
+
+// CartReminderNotifier defines the contract for sending customers a reminder of the cart they left
+// behind, by email, push message or any other channel
+type CartReminderNotifier interface {
+	// RemindAbandonedCart reminds a user of their abandoned cart
+	RemindAbandonedCart(ctx context.Context, user *entities.User, cart *entities.AbandonedCart) error
+}
+
+// AbandonedCartService defines the contract for detecting abandoned carts
+type AbandonedCartService interface {
+	// DetectAbandonedCarts records the carts with items that nobody has touched for idleFor as
+	// abandoned and reminds their owners, when reminders are on. Guests can't be reminded. It
+	// returns how many carts were found abandoned.
+	DetectAbandonedCarts(ctx context.Context, idleFor time.Duration) (int, *exception.AppError)
+}
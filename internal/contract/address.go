@@ -0,0 +1,59 @@
+// internal/contract/address_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// AddressRepository defines the contract for address book data access operations
+type AddressRepository interface {
+	// CreateAddress creates a new address
+	CreateAddress(ctx context.Context, address *entities.Address) *exception.AppError
+	
+	// GetAddressByID retrieves an address of a user by its ID
+	GetAddressByID(ctx context.Context, userID, addressID utils.BinaryUUID) (*entities.Address, *exception.AppError)
+	
+	// GetAddressesByUserID retrieves the address book of a user, default address first
+	GetAddressesByUserID(ctx context.Context, userID utils.BinaryUUID) ([]entities.Address, *exception.AppError)
+	
+	// GetDefaultAddress retrieves the default address of a user, or nil when there is none
+	GetDefaultAddress(ctx context.Context, userID utils.BinaryUUID) (*entities.Address, *exception.AppError)
+	
+	// UpdateAddress updates an existing address
+	UpdateAddress(ctx context.Context, address *entities.Address) *exception.AppError
+	
+	// DeleteAddress deletes an address of a user
+	DeleteAddress(ctx context.Context, userID, addressID utils.BinaryUUID) *exception.AppError
+	
+	// SetDefaultAddress makes an address the only default address of its user
+	SetDefaultAddress(ctx context.Context, userID, addressID utils.BinaryUUID) *exception.AppError
+}
+
+// AddressService defines the contract for address book business logic operations
+type AddressService interface {
+	// CreateAddress adds an address to a user's address book. The first address becomes the default.
+	CreateAddress(ctx context.Context, userID utils.BinaryUUID, address *entities.Address) (*entities.Address, *exception.AppError)
+	
+	// GetAddresses retrieves a user's address book
+	GetAddresses(ctx context.Context, userID utils.BinaryUUID) ([]entities.Address, *exception.AppError)
+	
+	// GetAddress retrieves one address of a user's address book
+	GetAddress(ctx context.Context, userID, addressID utils.BinaryUUID) (*entities.Address, *exception.AppError)
+	
+	// UpdateAddress updates an address of a user's address book
+	UpdateAddress(ctx context.Context, userID utils.BinaryUUID, address *entities.Address) (*entities.Address, *exception.AppError)
+	
+	// DeleteAddress removes an address; when it was the default, the most recent remaining address
+	// becomes the default
+	DeleteAddress(ctx context.Context, userID, addressID utils.BinaryUUID) *exception.AppError
+	
+	// SetDefaultAddress makes an address the user's default address
+	SetDefaultAddress(ctx context.Context, userID, addressID utils.BinaryUUID) (*entities.Address, *exception.AppError)
+	
+	// ResolveDeliveryAddress returns the address to deliver an order to: the given address, or the
+	// user's default address when addressID is nil
+	ResolveDeliveryAddress(ctx context.Context, userID utils.BinaryUUID, addressID *utils.BinaryUUID) (*entities.Address, *exception.AppError)
+}
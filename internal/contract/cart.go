@@ -6,27 +6,101 @@ import (
 	"shopify-app/internal/entities"
 	"shopify-app/internal/exception"
 	"shopify-app/internal/utils"
+	"time"
 )
 
+// CartPricing is the price of a cart after promotions and tax
+type CartPricing struct {
+	Subtotal       *utils.GormDecimal   `json:"subtotal"` // Before discounts
+	Discounts      []DiscountLine       `json:"discounts"`
+	DiscountAmount *utils.GormDecimal   `json:"discount_amount"`
+	LineDiscounts  []*utils.GormDecimal `json:"-"` // Discount per cart item, in cart order
+	CouponCode     string               `json:"coupon_code,omitempty"`
+	CouponError    string               `json:"coupon_error,omitempty"`
+	Tax            *TaxBreakdown        `json:"tax"` // Calculated on the discounted lines; LineTaxes is in cart order too
+	Total          *utils.GormDecimal   `json:"total"`
+}
+
+// CartOwner identifies a cart: either a signed-in user's or an anonymous guest's. Exactly one of
+// the IDs is set; use UserCartOwner or GuestCartOwner to build one.
+type CartOwner struct {
+	UserID  *utils.BinaryUUID
+	GuestID *utils.BinaryUUID // From a signed guest token
+}
+
+// UserCartOwner is the owner of a signed-in user's cart
+func UserCartOwner(userID utils.BinaryUUID) CartOwner {
+	return CartOwner{UserID: &userID}
+}
+
+// GuestCartOwner is the owner of an anonymous visitor's cart
+func GuestCartOwner(guestID utils.BinaryUUID) CartOwner {
+	return CartOwner{GuestID: &guestID}
+}
+
+// IsGuest checks if the cart belongs to an anonymous visitor
+func (o CartOwner) IsGuest() bool {
+	return o.UserID == nil
+}
+
+// CustomerID is the user promotions are checked against. Guests have not redeemed anything yet,
+// so they get the zero ID; per-customer limits are enforced again at checkout.
+func (o CartOwner) CustomerID() utils.BinaryUUID {
+	if o.UserID == nil {
+		return utils.BinaryUUID{}
+	}
+	return *o.UserID
+}
+
+// CartMergeResult lists what was moved from a guest cart into a user's cart, and what could not be
+type CartMergeResult struct {
+	Merged  []ReorderItem `json:"merged"`
+	Skipped []ReorderItem `json:"skipped"`
+	Error   string        `json:"error,omitempty"` // Set when the merge failed; the guest cart is left as it was
+}
+
+// CartLineChange is what revalidating a cart changed about one of its lines
+type CartLineChange struct {
+	CartItemID  utils.BinaryUUID         `json:"cart_item_id"`
+	MenuID      utils.BinaryUUID         `json:"menu_id"`
+	MenuName    string                   `json:"menu_name"`
+	Issues      []entities.CartItemIssue `json:"issues"`
+	Removed     bool                     `json:"removed"` // The line can't be ordered anymore
+	OldPrice    *utils.GormDecimal       `json:"old_price,omitempty"`
+	NewPrice    *utils.GormDecimal       `json:"new_price,omitempty"`
+	OldQuantity int                      `json:"old_quantity,omitempty"`
+	NewQuantity int                      `json:"new_quantity,omitempty"`
+}
+
 // CartRepository defines the contract for cart data access operations
 type CartRepository interface {
-	// GetOrCreateCart retrieves or creates a cart for a user
-	GetOrCreateCart(ctx context.Context, userID utils.BinaryUUID) (*entities.Cart, *exception.AppError)
+	// GetOrCreateCart retrieves or creates the cart of a user or guest
+	GetOrCreateCart(ctx context.Context, owner CartOwner) (*entities.Cart, *exception.AppError)
+	
+	// GetCartWithItems retrieves the cart of a user or guest with all its items
+	GetCartWithItems(ctx context.Context, owner CartOwner) (*entities.Cart, *exception.AppError)
 	
-	// GetCartWithItems retrieves a cart with all its items for a user
-	GetCartWithItems(ctx context.Context, userID utils.BinaryUUID) (*entities.Cart, *exception.AppError)
+	// FindCartWithItems retrieves the cart of a user or guest with all its items, returning nil
+	// instead of creating one if there is none
+	FindCartWithItems(ctx context.Context, owner CartOwner) (*entities.Cart, *exception.AppError)
 	
-	// AddItemToCart adds an item to the cart or updates quantity if exists
-	AddItemToCart(ctx context.Context, cartID, menuID utils.BinaryUUID, quantity int, price *utils.GormDecimal) *exception.AppError
+	// DeleteCart removes a cart together with its items
+	DeleteCart(ctx context.Context, cartID utils.BinaryUUID) *exception.AppError
+	
+	// AddItemToCart adds an item with its chosen options to the cart or updates quantity if the same line exists
+	AddItemToCart(ctx context.Context, cartID, menuID utils.BinaryUUID, quantity int, price *utils.GormDecimal, options []entities.CartItemOption) *exception.AppError
 	
 	// UpdateCartItemQuantity updates the quantity of a specific cart item
 	UpdateCartItemQuantity(ctx context.Context, cartItemID utils.BinaryUUID, quantity int) *exception.AppError
 	
+	// UpdateCartItemPrice updates the unit price snapshot of a specific cart item
+	UpdateCartItemPrice(ctx context.Context, cartItemID utils.BinaryUUID, price *utils.GormDecimal) *exception.AppError
+	
 	// RemoveCartItem removes a specific item from the cart
 	RemoveCartItem(ctx context.Context, cartItemID utils.BinaryUUID) *exception.AppError
 	
-	// ClearCart removes all items from a user's cart
-	ClearCart(ctx context.Context, userID utils.BinaryUUID) *exception.AppError
+	// ClearCart removes all items from the cart of a user or guest
+	ClearCart(ctx context.Context, owner CartOwner) *exception.AppError
 	
 	// GetCartItem retrieves a specific cart item
 	GetCartItem(ctx context.Context, cartItemID utils.BinaryUUID) (*entities.CartItem, *exception.AppError)
@@ -37,33 +111,86 @@ type CartRepository interface {
 	// GetCartTotal calculates the total amount for a cart
 	GetCartTotal(ctx context.Context, cartID utils.BinaryUUID) (*utils.GormDecimal, *exception.AppError)
 	
-	// ValidateCartOwnership validates that a cart item belongs to a specific user
-	ValidateCartOwnership(ctx context.Context, cartItemID, userID utils.BinaryUUID) (bool, *exception.AppError)
+	// ValidateCartOwnership validates that a cart item belongs to the cart of a user or guest
+	ValidateCartOwnership(ctx context.Context, cartItemID utils.BinaryUUID, owner CartOwner) (bool, *exception.AppError)
+	
+	// SetCartCouponCode stores the coupon code entered for a cart; an empty code removes it
+	SetCartCouponCode(ctx context.Context, cartID utils.BinaryUUID, code string) *exception.AppError
+	
+	// DeleteStaleCarts removes the carts that have not been changed since the given time, together
+	// with their items, and returns how many were removed
+	DeleteStaleCarts(ctx context.Context, inactiveSince time.Time) (int64, *exception.AppError)
+	
+	// TouchCart records activity by the customer on the cart of a user or guest
+	TouchCart(ctx context.Context, owner CartOwner, at time.Time) *exception.AppError
+	
+	// FindIdleCarts retrieves up to limit carts with items, their menus and their user, that have
+	// seen no activity since the given time and were not found abandoned since their last activity
+	FindIdleCarts(ctx context.Context, idleSince time.Time, limit int) ([]entities.Cart, *exception.AppError)
+	
+	// MarkCartAbandoned flags a cart as abandoned and stores the snapshot of it with its items. It
+	// reports false, storing nothing, when the cart has seen activity since the snapshot was taken.
+	MarkCartAbandoned(ctx context.Context, abandoned *entities.AbandonedCart) (bool, *exception.AppError)
+	
+	// SetAbandonedCartReminded records when the customer was reminded of an abandoned cart
+	SetAbandonedCartReminded(ctx context.Context, abandonedCartID utils.BinaryUUID, at time.Time) *exception.AppError
+	
+	// RecoverAbandonedCart records that the latest abandonment of a cart was recovered by an order
+	RecoverAbandonedCart(ctx context.Context, cartID, orderID utils.BinaryUUID, at time.Time) *exception.AppError
 }
 
 // CartService defines the contract for cart business logic operations
 type CartService interface {
-	// AddItemToCart handles adding an item to cart with validation
-	AddItemToCart(ctx context.Context, userID, menuID utils.BinaryUUID, quantity int) *exception.AppError
+	// AddItemToCart handles adding an item with its chosen options to cart with validation
+	AddItemToCart(ctx context.Context, owner CartOwner, menuID utils.BinaryUUID, quantity int, optionIDs []utils.BinaryUUID) *exception.AppError
 	
-	// GetUserCart retrieves user's cart with all items and calculations
-	GetUserCart(ctx context.Context, userID utils.BinaryUUID) (*entities.Cart, *utils.GormDecimal, *exception.AppError)
+	// GetUserCart retrieves the cart of a user or guest with all items and calculations. Each item
+	// is checked against the current menu and flagged with its issues, if any.
+	GetUserCart(ctx context.Context, owner CartOwner) (*entities.Cart, *utils.GormDecimal, *exception.AppError)
 	
 	// UpdateCartItem handles updating cart item quantity with validation
-	UpdateCartItem(ctx context.Context, userID, cartItemID utils.BinaryUUID, quantity int) *exception.AppError
+	UpdateCartItem(ctx context.Context, owner CartOwner, cartItemID utils.BinaryUUID, quantity int) *exception.AppError
 	
 	// RemoveCartItem handles removing an item from cart with validation
-	RemoveCartItem(ctx context.Context, userID, cartItemID utils.BinaryUUID) *exception.AppError
+	RemoveCartItem(ctx context.Context, owner CartOwner, cartItemID utils.BinaryUUID) *exception.AppError
+	
+	// ClearCart clears all items from the cart of a user or guest
+	ClearCart(ctx context.Context, owner CartOwner) *exception.AppError
+	
+	// CompleteCheckout clears a cart that was checked out into an order. If the cart had been
+	// abandoned, the abandonment counts as recovered by the order.
+	CompleteCheckout(ctx context.Context, owner CartOwner, cart *entities.Cart, orderID utils.BinaryUUID) *exception.AppError
+	
+	// ValidateCartForCheckout validates cart items before checkout or a quote. A cart whose prices
+	// changed is refused with CodePriceChanged until it is revalidated.
+	ValidateCartForCheckout(ctx context.Context, owner CartOwner) (*entities.Cart, *utils.GormDecimal, *exception.AppError)
 	
-	// ClearCart clears all items from user's cart
-	ClearCart(ctx context.Context, userID utils.BinaryUUID) *exception.AppError
+	// PriceCart applies the promotions and tax to a cart loaded with its items and menus
+	PriceCart(ctx context.Context, owner CartOwner, cart *entities.Cart) (*CartPricing, *exception.AppError)
 	
-	// ValidateCartForCheckout validates cart items before checkout
-	ValidateCartForCheckout(ctx context.Context, userID utils.BinaryUUID) (*entities.Cart, *utils.GormDecimal, *exception.AppError)
+	// ApplyCoupon validates a coupon code and attaches it to the user's cart
+	ApplyCoupon(ctx context.Context, owner CartOwner, code string) *exception.AppError
 	
-	// GetCartItemCount returns the total number of items in user's cart
-	GetCartItemCount(ctx context.Context, userID utils.BinaryUUID) (int, *exception.AppError)
+	// RemoveCoupon detaches the coupon code from the cart
+	RemoveCoupon(ctx context.Context, owner CartOwner) *exception.AppError
+	
+	// GetCartItemCount returns the total number of items in the cart of a user or guest
+	GetCartItemCount(ctx context.Context, owner CartOwner) (int, *exception.AppError)
 	
 	// SyncCartItemPrices updates cart item prices with current menu prices
-	SyncCartItemPrices(ctx context.Context, userID utils.BinaryUUID) *exception.AppError
+	SyncCartItemPrices(ctx context.Context, owner CartOwner) *exception.AppError
+	
+	// RevalidateCart brings a cart in line with the current menu: prices are updated, quantities
+	// are lowered to what is in stock and lines that can't be ordered anymore are removed. It
+	// returns what was changed, line by line.
+	RevalidateCart(ctx context.Context, owner CartOwner) ([]CartLineChange, *exception.AppError)
+	
+	// MergeGuestCart moves a guest's cart into a user's cart when the guest signs in. A line the
+	// user already has keeps the larger of the two quantities rather than their sum, since it was
+	// most likely added on both devices. Lines that can no longer be ordered are skipped, and the
+	// guest's coupon is kept only if the user has none. The guest cart is removed afterwards.
+	MergeGuestCart(ctx context.Context, guestID, userID utils.BinaryUUID) (*CartMergeResult, *exception.AppError)
+	
+	// PurgeStaleCarts removes the carts nobody has touched for the given retention period
+	PurgeStaleCarts(ctx context.Context, retention time.Duration) (int64, *exception.AppError)
 }
\ No newline at end of file
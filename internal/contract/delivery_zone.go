@@ -0,0 +1,62 @@
+// internal/contract/delivery_zone_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// DeliveryQuote is what delivering to a location costs and how long it takes
+type DeliveryQuote struct {
+	ZoneID           utils.BinaryUUID   `json:"zone_id"`
+	ZoneName         string             `json:"zone_name"`
+	DistanceMeters   int                `json:"distance_meters"` // From the store
+	Fee              *utils.GormDecimal `json:"fee"`
+	MinOrderAmount   *utils.GormDecimal `json:"min_order_amount"`
+	EstimatedMinutes int                `json:"estimated_minutes"`
+}
+
+// DeliveryZoneRepository defines the contract for delivery zone data access operations
+type DeliveryZoneRepository interface {
+	// CreateDeliveryZone creates a new delivery zone with its fee tiers
+	CreateDeliveryZone(ctx context.Context, zone *entities.DeliveryZone) *exception.AppError
+	
+	// GetDeliveryZoneByID retrieves a delivery zone with its fee tiers, including deleted zones
+	// since past orders refer to them
+	GetDeliveryZoneByID(ctx context.Context, id utils.BinaryUUID) (*entities.DeliveryZone, *exception.AppError)
+	
+	// GetAllDeliveryZones retrieves all delivery zones with their fee tiers
+	GetAllDeliveryZones(ctx context.Context) ([]entities.DeliveryZone, *exception.AppError)
+	
+	// GetActiveDeliveryZones retrieves the active delivery zones with their fee tiers, by priority
+	GetActiveDeliveryZones(ctx context.Context) ([]entities.DeliveryZone, *exception.AppError)
+	
+	// UpdateDeliveryZone updates a delivery zone and replaces its fee tiers
+	UpdateDeliveryZone(ctx context.Context, zone *entities.DeliveryZone) *exception.AppError
+	
+	// DeleteDeliveryZone soft deletes a delivery zone
+	DeleteDeliveryZone(ctx context.Context, id utils.BinaryUUID) *exception.AppError
+}
+
+// DeliveryZoneService defines the contract for delivery zone business logic operations
+type DeliveryZoneService interface {
+	// CreateDeliveryZone validates and creates a new delivery zone (admin operation)
+	CreateDeliveryZone(ctx context.Context, zone *entities.DeliveryZone) (*entities.DeliveryZone, *exception.AppError)
+	
+	// UpdateDeliveryZone validates and updates a delivery zone (admin operation)
+	UpdateDeliveryZone(ctx context.Context, zone *entities.DeliveryZone) (*entities.DeliveryZone, *exception.AppError)
+	
+	// DeleteDeliveryZone deletes a delivery zone (admin operation)
+	DeleteDeliveryZone(ctx context.Context, id utils.BinaryUUID) *exception.AppError
+	
+	// GetDeliveryZones retrieves all delivery zones (admin operation)
+	GetDeliveryZones(ctx context.Context) ([]entities.DeliveryZone, *exception.AppError)
+	
+	// GetDeliveryZone retrieves a delivery zone, including deleted zones
+	GetDeliveryZone(ctx context.Context, id utils.BinaryUUID) (*entities.DeliveryZone, *exception.AppError)
+	
+	// QuoteDelivery finds the zone covering an address and its fee. The address must have coordinates.
+	QuoteDelivery(ctx context.Context, address *entities.PostalAddress) (*DeliveryQuote, *exception.AppError)
+}
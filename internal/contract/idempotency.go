@@ -0,0 +1,44 @@
+// internal/contract/idempotency_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// IdempotencyRepository defines the contract for idempotency key data access operations
+type IdempotencyRepository interface {
+	// CreateKeyIfAbsent stores a new key and reports false if the user already has a key with that value
+	CreateKeyIfAbsent(ctx context.Context, key *entities.IdempotencyKey) (bool, *exception.AppError)
+	
+	// GetKey retrieves a user's key, returning nil if it does not exist
+	GetKey(ctx context.Context, userID utils.BinaryUUID, key string) (*entities.IdempotencyKey, *exception.AppError)
+	
+	// CompleteKey stores the response that was sent for a key
+	CompleteKey(ctx context.Context, id utils.BinaryUUID, responseStatus int, responseBody []byte) *exception.AppError
+	
+	// DeleteKey removes a key so the request can be retried
+	DeleteKey(ctx context.Context, id utils.BinaryUUID) *exception.AppError
+	
+	// DeleteExpiredKeys removes all keys that expired before the given time
+	DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, *exception.AppError)
+}
+
+// IdempotencyService defines the contract for idempotent request handling
+type IdempotencyService interface {
+	// BeginRequest claims a key for a new request. It returns the stored key when a completed
+	// response should be replayed, or nil when the request should be processed.
+	BeginRequest(ctx context.Context, userID utils.BinaryUUID, key, fingerprint string) (*entities.IdempotencyKey, *exception.AppError)
+	
+	// CompleteRequest stores the response sent for a claimed key
+	CompleteRequest(ctx context.Context, userID utils.BinaryUUID, key string, responseStatus int, responseBody []byte) *exception.AppError
+	
+	// ReleaseRequest drops a claimed key so that a failed request can be retried with it
+	ReleaseRequest(ctx context.Context, userID utils.BinaryUUID, key string) *exception.AppError
+	
+	// PurgeExpiredKeys removes keys that are past their retention period
+	PurgeExpiredKeys(ctx context.Context) (int64, *exception.AppError)
+}
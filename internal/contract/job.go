@@ -0,0 +1,61 @@
+// internal/contract/job_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// JobFunc is the work done by a background job on each run
+type JobFunc func(ctx context.Context) *exception.AppError
+
+// JobStatus describes a registered background job and the outcome of its last run
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	Running        bool       `json:"running"`
+	RunningOn      string     `json:"running_on,omitempty"` // Instance running the job
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastError      string     `json:"last_error,omitempty"` // Empty when the last run succeeded
+	RunCount       int64      `json:"run_count"`
+	FailureCount   int64      `json:"failure_count"`
+}
+
+// JobRepository defines the contract for the shared state and locks of background jobs
+type JobRepository interface {
+	// RegisterJob stores a job if it is not known yet and updates its schedule otherwise
+	RegisterJob(ctx context.Context, name, schedule string) *exception.AppError
+	
+	// ClaimJob locks a job for owner until lockedUntil and moves its next run to nextRunAt. It reports
+	// false when another instance holds the lock or has already run the job for this turn.
+	ClaimJob(ctx context.Context, name, owner string, now, lockedUntil, nextRunAt time.Time) (bool, *exception.AppError)
+	
+	// FinishJob records the outcome of a run and releases the lock held by owner
+	FinishJob(ctx context.Context, name, owner string, finishedAt time.Time, duration time.Duration, runErr string) *exception.AppError
+	
+	// GetJobs retrieves the state of all jobs
+	GetJobs(ctx context.Context) ([]entities.ScheduledJob, *exception.AppError)
+}
+
+// JobScheduler defines the contract for the in-process scheduler of background jobs. Every server
+// instance runs a scheduler; the lock kept by JobRepository makes each run happen on one instance only.
+type JobScheduler interface {
+	// Register adds a job; it must be called before Start
+	Register(name string, schedule utils.Schedule, job JobFunc) *exception.AppError
+	
+	// Start registers the jobs in the database and starts running them on their schedule
+	Start(ctx context.Context) *exception.AppError
+	
+	// Stop stops starting new runs and waits for running jobs to finish. When ctx ends first the
+	// running jobs are cancelled and an error is returned.
+	Stop(ctx context.Context) *exception.AppError
+	
+	// GetJobs retrieves the status of the registered jobs
+	GetJobs(ctx context.Context) ([]JobStatus, *exception.AppError)
+}
@@ -31,9 +31,15 @@ type MenuRepository interface {
 	// ReduceMenuStock reduces the stock quantity of a menu item (for orders)
 	ReduceMenuStock(ctx context.Context, id utils.BinaryUUID, quantity int) *exception.AppError
 	
+	// IncreaseMenuStock gives quantity back to the stock of a menu item (for cancellations)
+	IncreaseMenuStock(ctx context.Context, id utils.BinaryUUID, quantity int) *exception.AppError
+	
 	// GetMenusByIDs retrieves multiple menu items by their IDs
 	GetMenusByIDs(ctx context.Context, ids []utils.BinaryUUID) ([]entities.Menu, *exception.AppError)
 	
+	// LockMenusByIDs retrieves multiple menu items and locks their rows (must run inside a transaction)
+	LockMenusByIDs(ctx context.Context, ids []utils.BinaryUUID) ([]entities.Menu, *exception.AppError)
+	
 	// GetMenusByCategory retrieves menu items by category
 	GetMenusByCategory(ctx context.Context, category string, offset, limit int) ([]entities.Menu, int64, *exception.AppError)
 	
@@ -42,6 +48,27 @@ type MenuRepository interface {
 	
 	// GetCategories retrieves all distinct categories
 	GetCategories(ctx context.Context) ([]string, *exception.AppError)
+	
+	// CreateOptionGroup creates an option group together with its options
+	CreateOptionGroup(ctx context.Context, group *entities.MenuOptionGroup) *exception.AppError
+	
+	// DeleteOptionGroup deletes an option group of a menu item and its options
+	DeleteOptionGroup(ctx context.Context, menuID, groupID utils.BinaryUUID) *exception.AppError
+	
+	// GetMenuOption retrieves an option that belongs to a menu item
+	GetMenuOption(ctx context.Context, menuID, optionID utils.BinaryUUID) (*entities.MenuOption, *exception.AppError)
+	
+	// UpdateMenuOptionStock sets the stock of an option (nil means not tracked)
+	UpdateMenuOptionStock(ctx context.Context, optionID utils.BinaryUUID, stock *int) *exception.AppError
+	
+	// LockMenuOptionsByIDs retrieves options and locks their rows (must run inside a transaction)
+	LockMenuOptionsByIDs(ctx context.Context, ids []utils.BinaryUUID) ([]entities.MenuOption, *exception.AppError)
+	
+	// ReduceMenuOptionStock reduces the stock of a tracked option (for orders)
+	ReduceMenuOptionStock(ctx context.Context, optionID utils.BinaryUUID, quantity int) *exception.AppError
+	
+	// IncreaseMenuOptionStock gives quantity back to a tracked option (for cancellations)
+	IncreaseMenuOptionStock(ctx context.Context, optionID utils.BinaryUUID, quantity int) *exception.AppError
 }
 
 // MenuService defines the contract for menu business logic operations
@@ -81,4 +108,13 @@ type MenuService interface {
 	
 	// ToggleMenuStatus toggles menu active/inactive status
 	ToggleMenuStatus(ctx context.Context, id utils.BinaryUUID) (*entities.Menu, *exception.AppError)
+	
+	// AddOptionGroup adds an option group with its options to a menu item
+	AddOptionGroup(ctx context.Context, menuID utils.BinaryUUID, group *entities.MenuOptionGroup) (*entities.MenuOptionGroup, *exception.AppError)
+	
+	// DeleteOptionGroup removes an option group from a menu item
+	DeleteOptionGroup(ctx context.Context, menuID, groupID utils.BinaryUUID) *exception.AppError
+	
+	// UpdateMenuOptionStock updates the stock of an option (nil means not tracked)
+	UpdateMenuOptionStock(ctx context.Context, menuID, optionID utils.BinaryUUID, stock *int) (*entities.MenuOption, *exception.AppError)
 }
\ No newline at end of file
@@ -9,6 +9,107 @@ import (
 	"time"
 )
 
+// OrderFilter holds the optional criteria for searching orders (admin operation).
+// Zero values and nil pointers mean "no filter".
+type OrderFilter struct {
+	Status        entities.OrderStatus
+	Fulfilment    entities.FulfilmentType
+	StartDate     *time.Time
+	EndDate       *time.Time // Exclusive
+	CustomerEmail string     // Partial match
+	OrderNumber   string     // Exact order number, or a bare number like "0427" for that position within any day
+	MinTotal      *utils.GormDecimal
+	MaxTotal      *utils.GormDecimal
+	MenuID        *utils.BinaryUUID // Orders containing this menu item
+	SortBy        string            // One of OrderSortFields
+	SortDesc      bool
+	Offset        int
+	Limit         int
+}
+
+// OrderSortFields lists the fields orders can be sorted by
+var OrderSortFields = []string{"created_at", "total_amount", "status"}
+
+// OrderItemChange is one edit to the items of a pending order. Set ItemID to change the quantity
+// of an existing item (0 removes it), or MenuID and OptionIDs to add a new item.
+type OrderItemChange struct {
+	ItemID    *utils.BinaryUUID
+	MenuID    utils.BinaryUUID
+	OptionIDs []utils.BinaryUUID
+	Quantity  int
+}
+
+// CheckoutOptions holds the choices a customer makes at checkout
+type CheckoutOptions struct {
+	FulfilmentType entities.FulfilmentType // Defaults to pickup
+	AddressID      *utils.BinaryUUID       // Delivery address; nil for the default address
+	ScheduledFor   *time.Time              // Start of the chosen time slot; nil for "as soon as possible"
+	Tip            *utils.GormDecimal      // Optional
+	QuoteID        string                  // Optional; the order is only placed when it comes to the quoted price
+}
+
+// QuoteOptions holds the checkout choices that change the price of a cart
+type QuoteOptions struct {
+	FulfilmentType entities.FulfilmentType // Defaults to pickup
+	AddressID      *utils.BinaryUUID       // Delivery address; nil for the default address
+	Tip            *utils.GormDecimal      // Optional
+}
+
+// QuoteLine is the price of one cart line
+type QuoteLine struct {
+	CartItemID utils.BinaryUUID   `json:"cart_item_id"`
+	MenuID     utils.BinaryUUID   `json:"menu_id"`
+	MenuName   string             `json:"menu_name"`
+	Options    []string           `json:"options,omitempty"`
+	UnitPrice  *utils.GormDecimal `json:"unit_price"` // Including the options
+	Quantity   int                `json:"quantity"`
+	Subtotal   *utils.GormDecimal `json:"subtotal"` // Unit price times quantity
+	Discount   *utils.GormDecimal `json:"discount"`
+	Tax        *utils.GormDecimal `json:"tax"`
+	Total      *utils.GormDecimal `json:"total"` // Subtotal less discount, plus tax unless prices include it
+}
+
+// QuoteFee is a charge on top of the items, such as delivery or a tip
+type QuoteFee struct {
+	Kind   entities.OrderFeeKind `json:"kind"`
+	Name   string                `json:"name"`
+	Amount *utils.GormDecimal    `json:"amount"`
+}
+
+// Quote is the full price of a cart as checkout would charge it. The ID is signed and pins the
+// price: checkout with it is refused when anything in the quote has changed since.
+type Quote struct {
+	ID               string             `json:"quote_id"`
+	ExpiresAt        time.Time          `json:"expires_at"`
+	Lines            []QuoteLine        `json:"lines"`
+	Subtotal         *utils.GormDecimal `json:"subtotal"` // Before discounts
+	Discounts        []DiscountLine     `json:"discounts"`
+	DiscountAmount   *utils.GormDecimal `json:"discount_amount"`
+	CouponCode       string             `json:"coupon_code,omitempty"`
+	CouponError      string             `json:"coupon_error,omitempty"`
+	TaxLines         []TaxLine          `json:"tax_lines"`
+	TaxAmount        *utils.GormDecimal `json:"tax_amount"`
+	PricesIncludeTax bool               `json:"prices_include_tax"`
+	Fees             []QuoteFee         `json:"fees"`
+	FeeAmount        *utils.GormDecimal `json:"fee_amount"` // Including the tip
+	Tip              *utils.GormDecimal `json:"tip"`
+	Total            *utils.GormDecimal `json:"total"`
+}
+
+// ReorderItem is an item of a past order that was copied into the cart, or could not be
+type ReorderItem struct {
+	MenuID   utils.BinaryUUID `json:"menu_id"`
+	MenuName string           `json:"menu_name"`
+	Quantity int              `json:"quantity"`
+	Reason   string           `json:"reason,omitempty"` // Why the item was skipped
+}
+
+// ReorderResult lists what a reorder added to the cart and what it had to skip
+type ReorderResult struct {
+	Added   []ReorderItem `json:"added"`
+	Skipped []ReorderItem `json:"skipped"`
+}
+
 // OrderRepository defines the contract for order data access operations
 type OrderRepository interface {
 	// CreateOrder creates a new order in the database
@@ -17,14 +118,48 @@ type OrderRepository interface {
 	// CreateOrderItems creates order items in batch
 	CreateOrderItems(ctx context.Context, orderItems []entities.OrderItem) *exception.AppError
 	
+	// CreateOrderTaxLines stores the tax calculated for an order
+	CreateOrderTaxLines(ctx context.Context, taxLines []entities.OrderTaxLine) *exception.AppError
+	
+	// CreateOrderDiscounts stores the discounts applied to an order
+	CreateOrderDiscounts(ctx context.Context, discounts []entities.OrderDiscount) *exception.AppError
+	
+	// UpdateOrderItemQuantity changes the quantity of an order item
+	UpdateOrderItemQuantity(ctx context.Context, itemID utils.BinaryUUID, quantity int) *exception.AppError
+	
+	// DeleteOrderItem removes an item and its options from an order
+	DeleteOrderItem(ctx context.Context, itemID utils.BinaryUUID) *exception.AppError
+	
+	// UpdateOrderPricing stores the recalculated amounts of an order and replaces its tax and
+	// discount lines
+	UpdateOrderPricing(ctx context.Context, order *entities.Order, taxLines []entities.OrderTaxLine, discounts []entities.OrderDiscount) *exception.AppError
+	
+	// CreateOrderRevision records an edit of an order, numbering it after the previous revisions
+	CreateOrderRevision(ctx context.Context, revision *entities.OrderRevision) *exception.AppError
+	
+	// NextOrderSequence increments the order counter of a day (YYYY-MM-DD) and returns the new value.
+	// Must run inside a transaction; concurrent callers for the same day wait until it ends.
+	NextOrderSequence(ctx context.Context, day string) (int, *exception.AppError)
+	
+	// GetDueScheduledOrderIDs retrieves the IDs of scheduled orders whose slot starts at or before cutoff
+	GetDueScheduledOrderIDs(ctx context.Context, cutoff time.Time) ([]utils.BinaryUUID, *exception.AppError)
+	
+	// GetStalePendingOrderIDs retrieves the IDs of pending orders placed before the given time,
+	// leaving out those with a pending payment opened after it
+	GetStalePendingOrderIDs(ctx context.Context, placedBefore time.Time) ([]utils.BinaryUUID, *exception.AppError)
+	
 	// GetOrderByID retrieves an order by its ID
 	GetOrderByID(ctx context.Context, id utils.BinaryUUID) (*entities.Order, *exception.AppError)
 	
+	// GetOrderByIDForUpdate retrieves an order and locks its row (must run inside a transaction)
+	GetOrderByIDForUpdate(ctx context.Context, id utils.BinaryUUID) (*entities.Order, *exception.AppError)
+	
 	// GetOrderWithItems retrieves an order with all its items
 	GetOrderWithItems(ctx context.Context, id utils.BinaryUUID) (*entities.Order, *exception.AppError)
 	
-	// GetOrdersByUserID retrieves orders for a specific user with pagination
-	GetOrdersByUserID(ctx context.Context, userID utils.BinaryUUID, offset, limit int) ([]entities.Order, int64, *exception.AppError)
+	// GetOrdersByUserID retrieves orders for a specific user with pagination, optionally only
+	// those matching an order number (see OrderFilter.OrderNumber)
+	GetOrdersByUserID(ctx context.Context, userID utils.BinaryUUID, orderNumber string, offset, limit int) ([]entities.Order, int64, *exception.AppError)
 	
 	// GetAllOrders retrieves all orders with pagination (admin operation)
 	GetAllOrders(ctx context.Context, offset, limit int, status entities.OrderStatus) ([]entities.Order, int64, *exception.AppError)
@@ -32,6 +167,15 @@ type OrderRepository interface {
 	// UpdateOrderStatus updates the status of an order
 	UpdateOrderStatus(ctx context.Context, id utils.BinaryUUID, status entities.OrderStatus) *exception.AppError
 	
+	// MarkOrderRestocked flags an order whose items have been put back into stock
+	MarkOrderRestocked(ctx context.Context, id utils.BinaryUUID) *exception.AppError
+	
+	// CreateStatusHistory records a status transition of an order
+	CreateStatusHistory(ctx context.Context, history *entities.OrderStatusHistory) *exception.AppError
+	
+	// GetStatusHistory retrieves the status transitions of an order
+	GetStatusHistory(ctx context.Context, orderID utils.BinaryUUID) ([]entities.OrderStatusHistory, *exception.AppError)
+	
 	// GetOrdersByDateRange retrieves orders within a date range
 	GetOrdersByDateRange(ctx context.Context, startDate, endDate time.Time) ([]entities.Order, *exception.AppError)
 	
@@ -40,21 +184,54 @@ type OrderRepository interface {
 	
 	// ValidateOrderOwnership validates that an order belongs to a specific user
 	ValidateOrderOwnership(ctx context.Context, orderID, userID utils.BinaryUUID) (bool, *exception.AppError)
+	
+	// SearchOrders retrieves orders matching a filter with sorting and pagination (admin operation)
+	SearchOrders(ctx context.Context, filter OrderFilter) ([]entities.Order, int64, *exception.AppError)
 }
 
 // OrderService defines the contract for order business logic operations
 type OrderService interface {
-	// CheckoutCart processes cart checkout and creates an order
-	CheckoutCart(ctx context.Context, userID utils.BinaryUUID) (*entities.Order, *exception.AppError)
+	// CheckoutCart processes cart checkout and creates an order. The order is priced by the same
+	// pipeline as QuoteCart; with a quote ID it must come to exactly the quoted price.
+	CheckoutCart(ctx context.Context, userID utils.BinaryUUID, options CheckoutOptions) (*entities.Order, *exception.AppError)
 	
-	// GetOrderHistory retrieves user's order history with pagination
-	GetOrderHistory(ctx context.Context, userID utils.BinaryUUID, offset, limit int) ([]entities.Order, int64, *exception.AppError)
+	// QuoteCart prices the cart of a user or guest line by line, with discounts, tax, fees and
+	// tip, as checkout would charge it. Only signed-in users can get a delivery quote.
+	QuoteCart(ctx context.Context, owner CartOwner, options QuoteOptions) (*Quote, *exception.AppError)
+	
+	// GetOrderHistory retrieves user's order history with pagination, optionally only the orders
+	// matching an order number
+	GetOrderHistory(ctx context.Context, userID utils.BinaryUUID, orderNumber string, offset, limit int) ([]entities.Order, int64, *exception.AppError)
 	
 	// GetOrderDetails retrieves detailed information about a specific order
 	GetOrderDetails(ctx context.Context, userID, orderID utils.BinaryUUID) (*entities.Order, *exception.AppError)
 	
-	// UpdateOrderStatus updates order status with validation (admin operation)
-	UpdateOrderStatus(ctx context.Context, orderID utils.BinaryUUID, status entities.OrderStatus) (*entities.Order, *exception.AppError)
+	// UpdateOrderStatus moves an order to a new status if the transition is allowed and records it.
+	// changedBy is nil when the change is made by the system.
+	UpdateOrderStatus(ctx context.Context, orderID utils.BinaryUUID, status entities.OrderStatus, changedBy *utils.BinaryUUID, reason string) (*entities.Order, *exception.AppError)
+	
+	// ConfirmOrder confirms a pending order, or schedules it when its time slot is not yet due
+	ConfirmOrder(ctx context.Context, orderID utils.BinaryUUID, changedBy *utils.BinaryUUID, reason string) (*entities.Order, *exception.AppError)
+	
+	// ReleaseDueScheduledOrders confirms the scheduled orders whose slot is within the lead time,
+	// returning how many were released to the kitchen
+	ReleaseDueScheduledOrders(ctx context.Context) (int, *exception.AppError)
+	
+	// ExpireStalePendingOrders cancels the orders that are still pending after the given timeout,
+	// putting their items back into stock, and returns how many were cancelled. An order whose
+	// payment was opened within the timeout gets until the timeout has passed since then.
+	ExpireStalePendingOrders(ctx context.Context, timeout time.Duration) (int, *exception.AppError)
+	
+	// AdvanceOrderStatus moves an order to the next status of the regular fulfilment flow
+	AdvanceOrderStatus(ctx context.Context, orderID utils.BinaryUUID, changedBy *utils.BinaryUUID) (*entities.Order, *exception.AppError)
+	
+	// ReorderOrder copies the items of a past order into the user's cart at current prices.
+	// Items that can no longer be ordered are skipped and reported instead of failing the request.
+	ReorderOrder(ctx context.Context, userID, orderID utils.BinaryUUID) (*ReorderResult, *exception.AppError)
+	
+	// EditOrderItems applies changes to the items of a pending order for its owner or an admin. The
+	// totals are recalculated, stock is taken or given back and the edit is recorded as a revision.
+	EditOrderItems(ctx context.Context, userID, orderID utils.BinaryUUID, userRole entities.UserRole, changes []OrderItemChange) (*entities.Order, *exception.AppError)
 	
 	// CancelOrder cancels an order if possible
 	CancelOrder(ctx context.Context, userID, orderID utils.BinaryUUID) (*entities.Order, *exception.AppError)
@@ -67,4 +244,16 @@ type OrderService interface {
 	
 	// ValidateOrderAccess validates user access to order
 	ValidateOrderAccess(ctx context.Context, userID, orderID utils.BinaryUUID, userRole entities.UserRole) (bool, *exception.AppError)
+	
+	// GetOrderTracking retrieves the progress of an order for the customer who placed it
+	GetOrderTracking(ctx context.Context, userID, orderID utils.BinaryUUID) (*OrderTracking, *exception.AppError)
+	
+	// OrderTrackingFor builds the progress of an order loaded with its status history
+	OrderTrackingFor(order *entities.Order) OrderTracking
+	
+	// GetOrderDetailsWithAccess retrieves an order for the owner or for an admin, checked by ValidateOrderAccess
+	GetOrderDetailsWithAccess(ctx context.Context, userID, orderID utils.BinaryUUID, userRole entities.UserRole) (*entities.Order, *exception.AppError)
+	
+	// SearchOrders retrieves orders matching a filter (admin operation)
+	SearchOrders(ctx context.Context, filter OrderFilter) ([]entities.Order, int64, *exception.AppError)
 }
\ No newline at end of file
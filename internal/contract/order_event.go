@@ -0,0 +1,54 @@
+// internal/contract/order_event_contract.go
+package contract
+
+import (
+	"shopify-app/internal/entities"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// OrderEventType identifies what happened to an order
+type OrderEventType string
+
+const (
+	OrderEventCreated       OrderEventType = "order.created"
+	OrderEventStatusChanged OrderEventType = "order.status_changed"
+	OrderEventItemsChanged  OrderEventType = "order.items_changed"
+)
+
+// OrderEvent is published whenever an order is placed, changes status or has its items edited
+type OrderEvent struct {
+	ID         uint64                  `json:"id"`
+	Type       OrderEventType          `json:"type"`
+	OrderID    utils.BinaryUUID        `json:"order_id"`
+	UserID     utils.BinaryUUID        `json:"user_id"`
+	FromStatus entities.OrderStatus    `json:"from_status,omitempty"`
+	Status     entities.OrderStatus    `json:"status"`
+	Order      *entities.Order         `json:"order,omitempty"`
+	Revision   *entities.OrderRevision `json:"revision,omitempty"` // What changed, for items_changed events
+	OccurredAt time.Time               `json:"occurred_at"`
+}
+
+// OrderTracking is the progress of an order as shown to the customer who placed it
+type OrderTracking struct {
+	OrderID          utils.BinaryUUID     `json:"order_id"`
+	Status           entities.OrderStatus `json:"status"`
+	EstimatedReadyAt *time.Time           `json:"estimated_ready_at"` // Nil for cancelled orders
+	UpdatedAt        time.Time            `json:"updated_at"`
+}
+
+// OrderEventHub defines the contract for the in-process publish/subscribe hub of order events
+type OrderEventHub interface {
+	// Publish assigns the event the next ID and delivers it to every matching subscriber
+	Publish(event OrderEvent)
+
+	// Subscribe registers a subscriber for the events accepted by match (nil accepts all).
+	// Buffered events with an ID greater than lastEventID are replayed first. The channel is
+	// closed when the subscriber falls too far behind or the hub is closed; call the returned
+	// func to unsubscribe.
+	Subscribe(lastEventID uint64, match func(OrderEvent) bool) (<-chan OrderEvent, func())
+
+	// Close closes every subscriber channel so open streams end, e.g. on server shutdown.
+	// Later subscribers get an already closed channel.
+	Close()
+}
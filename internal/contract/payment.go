@@ -0,0 +1,77 @@
+// internal/contract/payment_contract.go
+package contract
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// ErrInvalidWebhookSignature is returned by providers when a webhook signature does not match its payload
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// ErrRefundDeclined is returned by providers when they refuse a refund, so nothing was paid back
+var ErrRefundDeclined = errors.New("refund declined by the payment provider")
+
+// PaymentIntent is returned by a payment provider when a payment is started
+type PaymentIntent struct {
+	Reference    string
+	ClientSecret string
+}
+
+// PaymentWebhookEvent is a notification from a payment provider whose signature has been verified
+type PaymentWebhookEvent struct {
+	Reference     string
+	Status        entities.PaymentStatus
+	FailureReason string
+}
+
+// PaymentProvider defines the contract for an external payment provider
+type PaymentProvider interface {
+	// Name identifies the provider in stored payments and webhook URLs
+	Name() string
+	
+	// CreateIntent starts a payment with the provider
+	CreateIntent(ctx context.Context, payment *entities.Payment) (*PaymentIntent, error)
+	
+	// ParseWebhook verifies the signature of an inbound webhook and decodes it
+	ParseWebhook(payload []byte, header http.Header) (*PaymentWebhookEvent, error)
+	
+	// Refund pays back refund.Amount of a succeeded payment and returns the provider's reference.
+	// refund.ID is the idempotency key: asking again for the same refund never pays back twice.
+	// A refusal wraps ErrRefundDeclined; after any other error the outcome is unknown.
+	Refund(ctx context.Context, payment *entities.Payment, refund *entities.Refund) (string, error)
+}
+
+// PaymentRepository defines the contract for payment data access operations
+type PaymentRepository interface {
+	// CreatePayment creates a new payment
+	CreatePayment(ctx context.Context, payment *entities.Payment) *exception.AppError
+	
+	// GetPaymentByReferenceForUpdate retrieves a payment by its provider reference and locks it
+	GetPaymentByReferenceForUpdate(ctx context.Context, provider, reference string) (*entities.Payment, *exception.AppError)
+	
+	// GetPendingPaymentByOrderID retrieves the open payment of an order, returning nil if there is none
+	GetPendingPaymentByOrderID(ctx context.Context, orderID utils.BinaryUUID) (*entities.Payment, *exception.AppError)
+	
+	// GetPaymentsByOrderID retrieves all payments of an order
+	GetPaymentsByOrderID(ctx context.Context, orderID utils.BinaryUUID) ([]entities.Payment, *exception.AppError)
+	
+	// UpdatePaymentStatus updates the status of a payment
+	UpdatePaymentStatus(ctx context.Context, id utils.BinaryUUID, status entities.PaymentStatus, failureReason string) *exception.AppError
+}
+
+// PaymentService defines the contract for payment business logic
+type PaymentService interface {
+	// CreatePaymentIntent starts the payment of a pending order, or returns the one already in progress
+	CreatePaymentIntent(ctx context.Context, userID, orderID utils.BinaryUUID) (*entities.Payment, *exception.AppError)
+	
+	// GetOrderPayments retrieves the payments of one of the user's orders
+	GetOrderPayments(ctx context.Context, userID, orderID utils.BinaryUUID) ([]entities.Payment, *exception.AppError)
+	
+	// HandleWebhook applies a provider notification; a successful payment confirms the order
+	HandleWebhook(ctx context.Context, provider string, payload []byte, header http.Header) *exception.AppError
+}
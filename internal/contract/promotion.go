@@ -0,0 +1,127 @@
+// internal/contract/promotion_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// PricedLine is a cart or order line as seen by the promotion engine
+type PricedLine struct {
+	MenuID    utils.BinaryUUID
+	Category  string
+	UnitPrice *utils.GormDecimal
+	Quantity  int
+}
+
+// DiscountLine is the discount granted by one promotion
+type DiscountLine struct {
+	PromotionID utils.BinaryUUID   `json:"promotion_id"`
+	Code        string             `json:"code,omitempty"`
+	Name        string             `json:"name"`
+	Amount      *utils.GormDecimal `json:"amount"`
+}
+
+// DiscountResult is the outcome of applying the promotions to a set of lines
+type DiscountResult struct {
+	Discounts     []DiscountLine       `json:"discounts"`
+	Amount        *utils.GormDecimal   `json:"amount"`
+	LineDiscounts []*utils.GormDecimal `json:"-"`                      // Discount per input line, in input order
+	CouponError   string               `json:"coupon_error,omitempty"` // Why the entered coupon was not applied
+}
+
+// PromotionRepository defines the contract for promotion data access operations
+type PromotionRepository interface {
+	// CreatePromotion creates a new promotion
+	CreatePromotion(ctx context.Context, promotion *entities.Promotion) *exception.AppError
+	
+	// GetPromotionByID retrieves a promotion by its ID
+	GetPromotionByID(ctx context.Context, id utils.BinaryUUID) (*entities.Promotion, *exception.AppError)
+	
+	// GetPromotionByCode retrieves a promotion by its coupon code, deleted or not, returning nil if there is none
+	GetPromotionByCode(ctx context.Context, code string) (*entities.Promotion, *exception.AppError)
+	
+	// GetAllPromotions retrieves promotions with pagination
+	GetAllPromotions(ctx context.Context, offset, limit int) ([]entities.Promotion, int64, *exception.AppError)
+	
+	// GetAutomaticPromotions retrieves the active promotions that need no coupon code
+	GetAutomaticPromotions(ctx context.Context) ([]entities.Promotion, *exception.AppError)
+	
+	// GetPromotionsByIDs retrieves promotions by their IDs, including deleted ones
+	GetPromotionsByIDs(ctx context.Context, ids []utils.BinaryUUID) ([]entities.Promotion, *exception.AppError)
+	
+	// LockPromotionsByIDs retrieves promotions and locks their rows (must run inside a transaction)
+	LockPromotionsByIDs(ctx context.Context, ids []utils.BinaryUUID) ([]entities.Promotion, *exception.AppError)
+	
+	// UpdatePromotion updates an existing promotion
+	UpdatePromotion(ctx context.Context, promotion *entities.Promotion) *exception.AppError
+	
+	// DeletePromotion soft deletes a promotion
+	DeletePromotion(ctx context.Context, id utils.BinaryUUID) *exception.AppError
+	
+	// AdjustUsageCount adds delta to the usage count of a promotion
+	AdjustUsageCount(ctx context.Context, id utils.BinaryUUID, delta int) *exception.AppError
+	
+	// ClaimPromotionUse adds one to the usage count of a promotion while it is below the usage
+	// limit, and reports whether it did
+	ClaimPromotionUse(ctx context.Context, id utils.BinaryUUID) (bool, *exception.AppError)
+	
+	// CountUserRedemptions counts how often a user has used a promotion
+	CountUserRedemptions(ctx context.Context, promotionID, userID utils.BinaryUUID) (int64, *exception.AppError)
+	
+	// CountUserRedemptionsForUpdate counts how often a user has used a promotion with a locking
+	// read (must run inside a transaction)
+	CountUserRedemptionsForUpdate(ctx context.Context, promotionID, userID utils.BinaryUUID) (int64, *exception.AppError)
+	
+	// CreateRedemptions records promotion uses in batch
+	CreateRedemptions(ctx context.Context, redemptions []entities.PromotionRedemption) *exception.AppError
+	
+	// GetRedemptionsByOrderID retrieves the promotion uses of an order
+	GetRedemptionsByOrderID(ctx context.Context, orderID utils.BinaryUUID) ([]entities.PromotionRedemption, *exception.AppError)
+	
+	// DeleteRedemption removes the use of a promotion by an order
+	DeleteRedemption(ctx context.Context, orderID, promotionID utils.BinaryUUID) *exception.AppError
+	
+	// DeleteRedemptionsByOrderID removes the promotion uses of an order
+	DeleteRedemptionsByOrderID(ctx context.Context, orderID utils.BinaryUUID) *exception.AppError
+}
+
+// PromotionService defines the contract for promotion business logic operations
+type PromotionService interface {
+	// CreatePromotion validates and creates a new promotion (admin operation)
+	CreatePromotion(ctx context.Context, promotion *entities.Promotion) (*entities.Promotion, *exception.AppError)
+	
+	// UpdatePromotion validates and updates a promotion (admin operation)
+	UpdatePromotion(ctx context.Context, promotion *entities.Promotion) (*entities.Promotion, *exception.AppError)
+	
+	// DeletePromotion deletes a promotion (admin operation)
+	DeletePromotion(ctx context.Context, id utils.BinaryUUID) *exception.AppError
+	
+	// GetPromotions retrieves promotions with pagination (admin operation)
+	GetPromotions(ctx context.Context, offset, limit int) ([]entities.Promotion, int64, *exception.AppError)
+	
+	// ValidateCoupon checks that a coupon code can currently be used by a user
+	ValidateCoupon(ctx context.Context, userID utils.BinaryUUID, code string) (*entities.Promotion, *exception.AppError)
+	
+	// CalculateDiscounts applies the automatic promotions and the coupon (if any) to the given lines
+	CalculateDiscounts(ctx context.Context, userID utils.BinaryUUID, couponCode string, lines []PricedLine) (*DiscountResult, *exception.AppError)
+	
+	// RecalculateDiscounts applies the given promotions to changed lines, without checking their
+	// validity or usage limits again, together with the automatic promotions the user can use now;
+	// used when an order that already redeemed the given promotions is edited
+	RecalculateDiscounts(ctx context.Context, userID utils.BinaryUUID, promotionIDs []utils.BinaryUUID, lines []PricedLine) (*DiscountResult, *exception.AppError)
+	
+	// RedeemPromotions records the use of the discounts on an order, re-checking the usage limits
+	// under lock (must run inside a transaction)
+	RedeemPromotions(ctx context.Context, userID, orderID utils.BinaryUUID, discounts []DiscountLine) *exception.AppError
+	
+	// SyncRedemptions makes the promotion uses of an edited order match its new discounts: uses of
+	// promotions that no longer apply are given back and new ones are redeemed like at checkout
+	// (must run inside a transaction)
+	SyncRedemptions(ctx context.Context, userID, orderID utils.BinaryUUID, discounts []DiscountLine) *exception.AppError
+	
+	// ReleasePromotions gives back the promotion uses of a cancelled order
+	ReleasePromotions(ctx context.Context, orderID utils.BinaryUUID) *exception.AppError
+}
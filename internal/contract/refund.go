@@ -0,0 +1,69 @@
+// internal/contract/refund_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// RefundItemRequest is the quantity of an order item to refund
+type RefundItemRequest struct {
+	OrderItemID utils.BinaryUUID
+	Quantity    int
+}
+
+// RefundRequest describes a refund to issue. Without items everything not yet refunded is refunded.
+type RefundRequest struct {
+	Items   []RefundItemRequest
+	Reason  string
+	Restock bool // Put the refunded items back into stock
+}
+
+// RefundRepository defines the contract for refund data access operations
+type RefundRepository interface {
+	// CreateRefund creates a refund together with its items
+	CreateRefund(ctx context.Context, refund *entities.Refund) *exception.AppError
+	
+	// GetRefundByID retrieves a refund together with its items
+	GetRefundByID(ctx context.Context, id utils.BinaryUUID) (*entities.Refund, *exception.AppError)
+	
+	// GetRefundByIDForUpdate retrieves a refund and locks it (must run inside a transaction)
+	GetRefundByIDForUpdate(ctx context.Context, id utils.BinaryUUID) (*entities.Refund, *exception.AppError)
+	
+	// GetPendingRefundIDs retrieves the IDs of up to limit refunds created before the given time that are still pending
+	GetPendingRefundIDs(ctx context.Context, createdBefore time.Time, limit int) ([]utils.BinaryUUID, *exception.AppError)
+	
+	// GetRefundsByOrderID retrieves the refunds of an order, oldest first
+	GetRefundsByOrderID(ctx context.Context, orderID utils.BinaryUUID) ([]entities.Refund, *exception.AppError)
+	
+	// AddAmountRefunded adds a refunded amount to the running total of an order
+	AddAmountRefunded(ctx context.Context, orderID utils.BinaryUUID, amount *utils.GormDecimal) *exception.AppError
+	
+	// AddRefundedQuantity adds to the refunded quantity of an order item
+	AddRefundedQuantity(ctx context.Context, orderItemID utils.BinaryUUID, quantity int) *exception.AppError
+	
+	// AddRestockedQuantity adds to the quantity of an order item put back into stock by refunds
+	AddRestockedQuantity(ctx context.Context, orderItemID utils.BinaryUUID, quantity int) *exception.AppError
+	
+	// UpdateRefundOutcome stores the status, failure reason, provider reference and restock flag of a refund
+	UpdateRefundOutcome(ctx context.Context, refund *entities.Refund) *exception.AppError
+}
+
+// RefundService defines the contract for refund business logic operations
+type RefundService interface {
+	// RefundOrder refunds a paid order in full or for some of its items (admin operation; issuedBy
+	// is nil for a refund the system issues itself). The refund is recorded as pending before the
+	// provider is asked to pay it back, and settled after. When the provider's answer is unclear,
+	// the refund stays pending for RetryPendingRefunds.
+	RefundOrder(ctx context.Context, orderID utils.BinaryUUID, issuedBy *utils.BinaryUUID, request RefundRequest) (*entities.Refund, *exception.AppError)
+	
+	// RetryPendingRefunds asks the provider again, under the same refund IDs, for the refunds that
+	// have been pending for longer than the given time, and returns how many were settled
+	RetryPendingRefunds(ctx context.Context, pendingFor time.Duration) (int, *exception.AppError)
+	
+	// GetOrderRefunds retrieves the refunds of an order (admin operation)
+	GetOrderRefunds(ctx context.Context, orderID utils.BinaryUUID) ([]entities.Refund, *exception.AppError)
+}
@@ -11,7 +11,11 @@ import (
 // SalesReportData represents aggregated sales data
 type SalesReportData struct {
 	Date        time.Time          `json:"date"`
-	TotalSales  *utils.GormDecimal `json:"total_sales"`
+	TotalSales  *utils.GormDecimal `json:"total_sales"` // Gross, including tax
+	NetSales    *utils.GormDecimal `json:"net_sales"`   // Excluding tax
+	TaxAmount   *utils.GormDecimal `json:"tax_amount"`
+	Discounts   *utils.GormDecimal `json:"discounts"`   // Promotions granted, already deducted from the sales
+	Refunds     *utils.GormDecimal `json:"refunds"`     // Paid back to customers, already deducted from the sales
 	OrderCount  int64              `json:"order_count"`
 	ItemsSold   int64              `json:"items_sold"`
 }
@@ -27,7 +31,11 @@ type BestSellingItem struct {
 
 // SalesAnalytics represents comprehensive sales analytics
 type SalesAnalytics struct {
-	TotalRevenue    *utils.GormDecimal `json:"total_revenue"`
+	TotalRevenue    *utils.GormDecimal `json:"total_revenue"` // Gross, including tax
+	NetRevenue      *utils.GormDecimal `json:"net_revenue"`   // Excluding tax
+	TotalTax        *utils.GormDecimal `json:"total_tax"`
+	TotalDiscounts  *utils.GormDecimal `json:"total_discounts"`
+	TotalRefunds    *utils.GormDecimal `json:"total_refunds"`
 	TotalOrders     int64              `json:"total_orders"`
 	TotalItems      int64              `json:"total_items"`
 	AverageOrderValue *utils.GormDecimal `json:"average_order_value"`
@@ -36,6 +44,40 @@ type SalesAnalytics struct {
 	PeriodEnd       time.Time          `json:"period_end"`
 }
 
+// SlotLoad represents the scheduled orders booked into an upcoming time slot
+type SlotLoad struct {
+	SlotStart  time.Time `json:"slot_start"`
+	OrderCount int64     `json:"order_count"`
+	ItemCount  int64     `json:"item_count"`
+	MaxOrders  int       `json:"max_orders"` // 0 when orders per slot are not limited
+	MaxItems   int       `json:"max_items"`  // 0 when items per slot are not limited
+}
+
+// AbandonedItem is a menu item that was left in abandoned carts
+type AbandonedItem struct {
+	MenuID         utils.BinaryUUID   `json:"menu_id"`
+	MenuName       string             `json:"menu_name"`
+	TimesAbandoned int64              `json:"times_abandoned"` // Number of abandoned carts it was in
+	Quantity       int64              `json:"quantity"`
+	Value          *utils.GormDecimal `json:"value"`
+}
+
+// AbandonedCartReport shows how many carts were abandoned in a period, what was left in them and
+// how many were recovered by an order later
+type AbandonedCartReport struct {
+	AbandonedCarts  int64              `json:"abandoned_carts"`
+	CheckedOutCarts int64              `json:"checked_out_carts"` // Orders placed without the cart being abandoned first
+	AbandonmentRate float64            `json:"abandonment_rate"`  // Percentage of the carts that were abandoned
+	AbandonedValue  *utils.GormDecimal `json:"abandoned_value"`   // At the cart prices, before promotions and tax
+	RemindersSent   int64              `json:"reminders_sent"`
+	RecoveredCarts  int64              `json:"recovered_carts"`
+	RecoveredValue  *utils.GormDecimal `json:"recovered_value"`
+	RecoveryRate    float64            `json:"recovery_rate"` // Percentage of the abandoned carts that were recovered
+	TopItems        []AbandonedItem    `json:"top_items"`     // Most often abandoned first
+	PeriodStart     time.Time          `json:"period_start"`
+	PeriodEnd       time.Time          `json:"period_end"`
+}
+
 // ReportRepository defines the contract for report data access operations
 type ReportRepository interface {
 	// GetDailySales retrieves daily sales data for a specific date
@@ -67,6 +109,15 @@ type ReportRepository interface {
 	
 	// GetRevenueGrowth calculates revenue growth between periods
 	GetRevenueGrowth(ctx context.Context, currentStart, currentEnd, previousStart, previousEnd time.Time) (float64, *exception.AppError)
+	
+	// GetSlotLoad retrieves the orders and items scheduled into each slot starting in [from, to)
+	GetSlotLoad(ctx context.Context, from, to time.Time) ([]SlotLoad, *exception.AppError)
+	
+	// GetAbandonedCartStats retrieves the abandoned, checked out and recovered carts of a period
+	GetAbandonedCartStats(ctx context.Context, startDate, endDate time.Time) (*AbandonedCartReport, *exception.AppError)
+	
+	// GetMostAbandonedItems retrieves the menu items left most often in the carts abandoned in a period
+	GetMostAbandonedItems(ctx context.Context, startDate, endDate time.Time, limit int) ([]AbandonedItem, *exception.AppError)
 }
 
 // ReportService defines the contract for report business logic operations
@@ -104,6 +155,13 @@ type ReportService interface {
 	// ExportSalesReportCSV exports sales report data to CSV format
 	ExportSalesReportCSV(ctx context.Context, startDate, endDate time.Time) ([]byte, *exception.AppError)
 	
+	// GetSlotLoadReport retrieves how full the time slots in a period are booked
+	GetSlotLoadReport(ctx context.Context, from, to time.Time) ([]SlotLoad, *exception.AppError)
+	
+	// GetAbandonedCartReport retrieves the abandonment and recovery rates of a period and the items
+	// abandoned most often
+	GetAbandonedCartReport(ctx context.Context, startDate, endDate time.Time, limit int) (*AbandonedCartReport, *exception.AppError)
+	
 	// ValidateReportDateRange validates date range parameters
 	ValidateReportDateRange(startDate, endDate time.Time) *exception.AppError
 }
\ No newline at end of file
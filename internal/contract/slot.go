@@ -0,0 +1,52 @@
+// internal/contract/slot_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"time"
+)
+
+// TimeSlot is a pickup or delivery window customers can schedule an order for
+type TimeSlot struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	OrderCount      int       `json:"order_count"`
+	ItemCount       int       `json:"item_count"`
+	RemainingOrders *int      `json:"remaining_orders,omitempty"` // Nil when orders per slot are not limited
+	RemainingItems  *int      `json:"remaining_items,omitempty"`  // Nil when items per slot are not limited
+	Available       bool      `json:"available"`
+}
+
+// SlotRepository defines the contract for slot booking data access operations
+type SlotRepository interface {
+	// LockSlotBooking retrieves the booking of a slot, creating it when needed, and locks its row
+	// (must run inside a transaction)
+	LockSlotBooking(ctx context.Context, slotStart time.Time) (*entities.SlotBooking, *exception.AppError)
+	
+	// UpdateSlotBookingCounts stores the order and item counts of a booking
+	UpdateSlotBookingCounts(ctx context.Context, booking *entities.SlotBooking) *exception.AppError
+	
+	// GetSlotBookings retrieves the bookings of the slots starting in [from, to)
+	GetSlotBookings(ctx context.Context, from, to time.Time) ([]entities.SlotBooking, *exception.AppError)
+}
+
+// SlotService defines the contract for time slot business logic operations
+type SlotService interface {
+	// GetSlots lists the slots of a day that can still be chosen, with their remaining capacity
+	GetSlots(ctx context.Context, day time.Time) ([]TimeSlot, *exception.AppError)
+	
+	// ValidateSlot checks that a time is the start of a slot that can be booked now
+	ValidateSlot(slotStart time.Time) *exception.AppError
+	
+	// BookSlot changes the orders and items booked into a slot. Increases are checked against the
+	// slot capacity; decreases release capacity (must run inside a transaction).
+	BookSlot(ctx context.Context, slotStart time.Time, orders, items int) *exception.AppError
+	
+	// ReleaseCutoff returns the slot start up to which scheduled orders are due for the kitchen
+	ReleaseCutoff(now time.Time) time.Time
+	
+	// Capacity returns the maximum orders and items per slot; 0 means no limit
+	Capacity() (maxOrders, maxItems int)
+}
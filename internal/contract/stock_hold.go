@@ -0,0 +1,63 @@
+// internal/contract/stock_hold_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// HeldStock is the quantity kept aside by active stock holds, per menu item and per option
+type HeldStock struct {
+	Menus   map[utils.BinaryUUID]int
+	Options map[utils.BinaryUUID]int
+}
+
+// StockHoldRepository defines the contract for stock hold data access operations
+type StockHoldRepository interface {
+	// GetHeldStock sums the holds active at now on the given menu items and options. The holds of
+	// exceptCartID are left out when it is set.
+	GetHeldStock(ctx context.Context, menuIDs, optionIDs []utils.BinaryUUID, now time.Time, exceptCartID *utils.BinaryUUID) (*HeldStock, *exception.AppError)
+	
+	// GetCartHeldStock sums the holds of one cart that are active at now
+	GetCartHeldStock(ctx context.Context, cartID utils.BinaryUUID, now time.Time) (*HeldStock, *exception.AppError)
+	
+	// ReplaceCartHolds replaces all holds of a cart with the given ones
+	ReplaceCartHolds(ctx context.Context, cartID utils.BinaryUUID, holds []entities.StockHold) *exception.AppError
+	
+	// DeleteCartHolds removes all holds of a cart
+	DeleteCartHolds(ctx context.Context, cartID utils.BinaryUUID) *exception.AppError
+	
+	// DeleteExpiredHolds removes the holds that have expired by now
+	DeleteExpiredHolds(ctx context.Context, now time.Time) (int64, *exception.AppError)
+}
+
+// StockHoldService defines the contract for the soft holds that keep cart contents in stock for a while
+type StockHoldService interface {
+	// Enabled reports whether carts hold stock at all
+	Enabled() bool
+	
+	// HoldCart holds the stock for every line of a cart, with a fresh expiry. Quantities above what
+	// the cart held before must be available; an error is returned otherwise. The cart items, their
+	// options must be loaded. Must run inside a transaction.
+	HoldCart(ctx context.Context, cart *entities.Cart) *exception.AppError
+	
+	// ReleaseCart removes the holds of a cart
+	ReleaseCart(ctx context.Context, cartID utils.BinaryUUID) *exception.AppError
+	
+	// LockHeldByOthers locks the given menu and option rows and returns what active holds of other
+	// carts keep aside on them. All holds count when cartID is nil. Must run inside a transaction.
+	LockHeldByOthers(ctx context.Context, cartID *utils.BinaryUUID, menuIDs, optionIDs []utils.BinaryUUID) (*HeldStock, *exception.AppError)
+	
+	// HeldByOthers is LockHeldByOthers without the locks, for showing rather than taking stock
+	HeldByOthers(ctx context.Context, cartID utils.BinaryUUID, menuIDs, optionIDs []utils.BinaryUUID) (*HeldStock, *exception.AppError)
+	
+	// ApplyAvailableStock sets the available stock of menu items and their options to their stock
+	// minus the active holds
+	ApplyAvailableStock(ctx context.Context, menus []entities.Menu) *exception.AppError
+	
+	// ReleaseExpiredHolds removes the holds that have expired
+	ReleaseExpiredHolds(ctx context.Context) (int64, *exception.AppError)
+}
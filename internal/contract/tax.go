@@ -0,0 +1,74 @@
+// internal/contract/tax_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// TaxableLine is a priced line (price times quantity) to calculate tax for
+type TaxableLine struct {
+	MenuID   utils.BinaryUUID
+	Category string
+	Amount   *utils.GormDecimal
+}
+
+// TaxLine is the tax charged under one tax rule
+type TaxLine struct {
+	TaxRuleID     utils.BinaryUUID   `json:"tax_rule_id"`
+	Name          string             `json:"name"`
+	Rate          *utils.GormDecimal `json:"rate"`
+	TaxableAmount *utils.GormDecimal `json:"taxable_amount"`
+	Amount        *utils.GormDecimal `json:"amount"`
+}
+
+// TaxBreakdown is the result of calculating tax for a set of lines
+type TaxBreakdown struct {
+	Subtotal         *utils.GormDecimal   `json:"subtotal"` // Sum of the line amounts as listed
+	TaxAmount        *utils.GormDecimal   `json:"tax_amount"`
+	Total            *utils.GormDecimal   `json:"total"`
+	PricesIncludeTax bool                 `json:"prices_include_tax"`
+	Lines            []TaxLine            `json:"lines"`
+	LineTaxes        []*utils.GormDecimal `json:"-"` // Tax per input line, in input order
+}
+
+// TaxRuleRepository defines the contract for tax rule data access operations
+type TaxRuleRepository interface {
+	// CreateTaxRule creates a new tax rule
+	CreateTaxRule(ctx context.Context, rule *entities.TaxRule) *exception.AppError
+	
+	// GetTaxRuleByID retrieves a tax rule by its ID
+	GetTaxRuleByID(ctx context.Context, id utils.BinaryUUID) (*entities.TaxRule, *exception.AppError)
+	
+	// GetAllTaxRules retrieves all tax rules
+	GetAllTaxRules(ctx context.Context) ([]entities.TaxRule, *exception.AppError)
+	
+	// GetActiveTaxRules retrieves the tax rules currently in force
+	GetActiveTaxRules(ctx context.Context) ([]entities.TaxRule, *exception.AppError)
+	
+	// UpdateTaxRule updates an existing tax rule
+	UpdateTaxRule(ctx context.Context, rule *entities.TaxRule) *exception.AppError
+	
+	// DeleteTaxRule deletes a tax rule
+	DeleteTaxRule(ctx context.Context, id utils.BinaryUUID) *exception.AppError
+}
+
+// TaxService defines the contract for tax configuration and calculation
+type TaxService interface {
+	// CreateTaxRule validates and creates a new tax rule (admin operation)
+	CreateTaxRule(ctx context.Context, rule *entities.TaxRule) (*entities.TaxRule, *exception.AppError)
+	
+	// UpdateTaxRule validates and updates a tax rule (admin operation)
+	UpdateTaxRule(ctx context.Context, rule *entities.TaxRule) (*entities.TaxRule, *exception.AppError)
+	
+	// DeleteTaxRule deletes a tax rule (admin operation)
+	DeleteTaxRule(ctx context.Context, id utils.BinaryUUID) *exception.AppError
+	
+	// GetTaxRules retrieves all tax rules (admin operation)
+	GetTaxRules(ctx context.Context) ([]entities.TaxRule, *exception.AppError)
+	
+	// CalculateTax applies the active tax rules to the given lines
+	CalculateTax(ctx context.Context, lines []TaxableLine) (*TaxBreakdown, *exception.AppError)
+}
@@ -0,0 +1,24 @@
+// internal/contract/transaction_contract.go
+package contract
+
+import (
+	"context"
+	"shopify-app/internal/exception"
+)
+
+// TransactionManager defines the contract for running repository calls as a single unit of work
+type TransactionManager interface {
+	// WithinTransaction runs fn inside a database transaction. Repositories called with the
+	// context passed to fn share the transaction; it is committed when fn returns nil and
+	// rolled back otherwise. Nested calls join the outer transaction.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) *exception.AppError) *exception.AppError
+
+	// WithinSavepoint runs fn inside a savepoint of the transaction carried by ctx: when fn fails,
+	// only its own changes are rolled back and the outer transaction carries on. Without a
+	// transaction in ctx it behaves like WithinTransaction.
+	WithinSavepoint(ctx context.Context, fn func(ctx context.Context) *exception.AppError) *exception.AppError
+
+	// AfterCommit runs fn once the transaction carried by ctx has committed; it is dropped if
+	// the transaction rolls back. Without a transaction in ctx, fn runs immediately.
+	AfterCommit(ctx context.Context, fn func())
+}
@@ -6,6 +6,7 @@ import (
 	"shopify-app/internal/entities"
 	"shopify-app/internal/exception"
 	"shopify-app/internal/utils"
+	"time"
 )
 
 // UserRepository defines the contract for user data access operations
@@ -40,6 +41,12 @@ type UserService interface {
 	// Login handles user authentication and JWT token generation
 	Login(ctx context.Context, email, password string) (*entities.User, string, *exception.AppError)
 	
+	// CreateGuestToken issues a signed token that identifies an anonymous visitor's cart
+	CreateGuestToken(ctx context.Context) (string, time.Time, *exception.AppError)
+	
+	// ParseGuestToken returns the guest ID of a valid guest token
+	ParseGuestToken(token string) (utils.BinaryUUID, *exception.AppError)
+	
 	// GetUserProfile retrieves user profile information
 	GetUserProfile(ctx context.Context, userID utils.BinaryUUID) (*entities.User, *exception.AppError)
 	
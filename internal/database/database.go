@@ -11,10 +11,37 @@ func Migrate(db *gorm.DB) error {
 	log.Println("Running database migrations...")
 	return db.AutoMigrate(
 		&entities.User{},
+		&entities.Address{},
 		&entities.Menu{},
+		&entities.MenuOptionGroup{},
+		&entities.MenuOption{},
 		&entities.Cart{},
 		&entities.CartItem{},
+		&entities.CartItemOption{},
+		&entities.AbandonedCart{},
+		&entities.AbandonedCartItem{},
 		&entities.Order{},
+		&entities.OrderNumberSequence{},
 		&entities.OrderItem{},
+		&entities.OrderItemOption{},
+		&entities.OrderStatusHistory{},
+		&entities.OrderRevision{},
+		&entities.OrderRevisionChange{},
+		&entities.Payment{},
+		&entities.Refund{},
+		&entities.RefundItem{},
+		&entities.TaxRule{},
+		&entities.OrderTaxLine{},
+		&entities.Promotion{},
+		&entities.PromotionRedemption{},
+		&entities.OrderDiscount{},
+		&entities.OrderAddress{},
+		&entities.OrderFee{},
+		&entities.DeliveryZone{},
+		&entities.DeliveryFeeTier{},
+		&entities.SlotBooking{},
+		&entities.IdempotencyKey{},
+		&entities.ScheduledJob{},
+		&entities.StockHold{},
 	)
 }
@@ -0,0 +1,63 @@
+// internal/entities/abandoned_cart.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// AbandonedCart records a cart that was left with items in it for too long, with a snapshot of
+// what was in it at the time. The cart itself stays; checking it out later recovers it. Carts
+// are purged after a while, so the record doesn't refer to them with a foreign key.
+type AbandonedCart struct {
+	ID             utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	CartID         utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"cart_id"`
+	UserID         *utils.BinaryUUID  `gorm:"type:binary(16);index" json:"user_id,omitempty"` // Nil for a guest cart
+	ItemCount      int                `gorm:"type:int;not null" json:"item_count"`
+	Value          *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"value"` // At the cart's prices, before promotions and tax
+	LastActivityAt time.Time          `gorm:"not null" json:"last_activity_at"`
+	AbandonedAt    time.Time          `gorm:"not null;index" json:"abandoned_at"`
+	ReminderSentAt *time.Time         `json:"reminder_sent_at,omitempty"`
+	RecoveredAt    *time.Time         `gorm:"index" json:"recovered_at,omitempty"`
+	OrderID        *utils.BinaryUUID  `gorm:"type:binary(16);index" json:"order_id,omitempty"` // The order that recovered the cart
+
+	// Relationships
+	Items []AbandonedCartItem `gorm:"foreignKey:AbandonedCartID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+}
+
+// TableName returns the table name for the AbandonedCart entity
+func (AbandonedCart) TableName() string {
+	return "abandoned_carts"
+}
+
+// BeforeCreate hook to generate UUID before creating abandoned cart
+func (ac *AbandonedCart) BeforeCreate(tx *gorm.DB) error {
+	if ac.ID == (utils.BinaryUUID{}) {
+		ac.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// AbandonedCartItem is a line of an abandoned cart, as it was when the cart was abandoned
+type AbandonedCartItem struct {
+	ID              utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	AbandonedCartID utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"abandoned_cart_id"`
+	MenuID          utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"menu_id"`
+	MenuName        string             `gorm:"type:varchar(255);not null" json:"menu_name"`
+	Quantity        int                `gorm:"type:int;not null" json:"quantity"`
+	Price           *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"price"` // Unit price in the cart
+}
+
+// TableName returns the table name for the AbandonedCartItem entity
+func (AbandonedCartItem) TableName() string {
+	return "abandoned_cart_items"
+}
+
+// BeforeCreate hook to generate UUID before creating abandoned cart item
+func (aci *AbandonedCartItem) BeforeCreate(tx *gorm.DB) error {
+	if aci.ID == (utils.BinaryUUID{}) {
+		aci.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// internal/entities/address.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// FulfilmentType defines how an order reaches the customer
+type FulfilmentType string
+
+const (
+	FulfilmentPickup   FulfilmentType = "pickup"   // Collected at the counter
+	FulfilmentDelivery FulfilmentType = "delivery" // Brought to the customer's address
+	FulfilmentDineIn   FulfilmentType = "dine_in"  // Served at a table
+)
+
+// IsValid checks if the type is one of the known fulfilment types
+func (t FulfilmentType) IsValid() bool {
+	switch t {
+	case FulfilmentPickup, FulfilmentDelivery, FulfilmentDineIn:
+		return true
+	}
+	return false
+}
+
+// PostalAddress holds the fields shared by address book entries and the address snapshot of an order
+type PostalAddress struct {
+	Line1        string   `gorm:"type:varchar(255);not null" json:"line1"`
+	Line2        string   `gorm:"type:varchar(255)" json:"line2,omitempty"`
+	City         string   `gorm:"type:varchar(100);not null" json:"city"`
+	PostalCode   string   `gorm:"type:varchar(20);not null" json:"postal_code"`
+	Country      string   `gorm:"type:char(2);not null" json:"country"` // ISO 3166-1 alpha-2
+	Latitude     *float64 `gorm:"type:decimal(9,6)" json:"latitude,omitempty"`
+	Longitude    *float64 `gorm:"type:decimal(9,6)" json:"longitude,omitempty"`
+	Instructions string   `gorm:"type:varchar(255)" json:"instructions,omitempty"` // E.g. "ring twice"
+}
+
+// Address is an entry in a customer's address book
+type Address struct {
+	ID     utils.BinaryUUID `gorm:"type:binary(16);primaryKey" json:"id"`
+	UserID utils.BinaryUUID `gorm:"type:binary(16);not null;index" json:"user_id"`
+	Label  string           `gorm:"type:varchar(50)" json:"label,omitempty"` // E.g. "Home" or "Work"
+	PostalAddress
+	IsDefault bool           `gorm:"type:boolean;not null;default:false" json:"is_default"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName returns the table name for the Address entity
+func (Address) TableName() string {
+	return "addresses"
+}
+
+// BeforeCreate hook to generate UUID before creating address
+func (a *Address) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == (utils.BinaryUUID{}) {
+		a.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// OrderAddress is the delivery address of an order as it was at checkout, so that later changes
+// to the address book don't rewrite past orders
+type OrderAddress struct {
+	ID        utils.BinaryUUID  `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderID   utils.BinaryUUID  `gorm:"type:binary(16);not null;uniqueIndex" json:"order_id"`
+	AddressID *utils.BinaryUUID `gorm:"type:binary(16)" json:"address_id,omitempty"` // Address book entry it was copied from
+	Label     string            `gorm:"type:varchar(50)" json:"label,omitempty"`
+	PostalAddress
+	DeliveryZoneID   *utils.BinaryUUID `gorm:"type:binary(16)" json:"delivery_zone_id,omitempty"`
+	DistanceMeters   int               `gorm:"type:int;not null;default:0" json:"distance_meters"`   // From the store
+	EstimatedMinutes int               `gorm:"type:int;not null;default:0" json:"estimated_minutes"` // Delivery time quoted at checkout
+	CreatedAt        time.Time         `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the OrderAddress entity
+func (OrderAddress) TableName() string {
+	return "order_addresses"
+}
+
+// BeforeCreate hook to generate UUID before creating order address
+func (a *OrderAddress) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == (utils.BinaryUUID{}) {
+		a.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// Snapshot copies the address into a new delivery address for an order
+func (a *Address) Snapshot() *OrderAddress {
+	addressID := a.ID
+	return &OrderAddress{
+		AddressID:     &addressID,
+		Label:         a.Label,
+		PostalAddress: a.PostalAddress,
+	}
+}
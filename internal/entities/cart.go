@@ -4,17 +4,19 @@ package entities
 import (
 	"shopify-app/internal/utils"
 	"time"
-	"strconv"
 	"gorm.io/gorm"
-	pbdecimal "google.golang.org/genproto/googleapis/type/decimal"
 )
 
 // Cart represents the shopping cart entity in the database
 type Cart struct {
-	ID        utils.BinaryUUID `gorm:"type:binary(16);primaryKey" json:"id"`
-	UserID    utils.BinaryUUID `gorm:"type:binary(16);not null;uniqueIndex" json:"user_id"`
-	CreatedAt time.Time        `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+	ID             utils.BinaryUUID  `gorm:"type:binary(16);primaryKey" json:"id"`
+	UserID         *utils.BinaryUUID `gorm:"type:binary(16);uniqueIndex" json:"user_id,omitempty"`  // Nil for a guest cart
+	GuestID        *utils.BinaryUUID `gorm:"type:binary(16);uniqueIndex" json:"guest_id,omitempty"` // Set for a guest cart, from the guest token
+	CouponCode     string            `gorm:"type:varchar(50)" json:"coupon_code,omitempty"`
+	LastActivityAt time.Time         `gorm:"autoCreateTime;index" json:"last_activity_at"` // Last change to the contents by the customer
+	AbandonedAt    *time.Time        `gorm:"index" json:"abandoned_at,omitempty"`          // When the cart was last found abandoned; cleared when it is checked out or emptied
+	CreatedAt      time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
 	
 	// Relationships
 	User      User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
@@ -36,17 +38,24 @@ func (c *Cart) BeforeCreate(tx *gorm.DB) error {
 
 // CartItem represents individual items in a shopping cart
 type CartItem struct {
-	ID        utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
-	CartID    utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"cart_id"`
-	MenuID    utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"menu_id"`
-	Quantity  int                `gorm:"type:int;not null;default:1" json:"quantity" validate:"required,gt=0"`
-	Price     *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"price"` // Price snapshot at time of adding to cart
-	CreatedAt time.Time          `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+	ID         utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	CartID     utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"cart_id"`
+	MenuID     utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"menu_id"`
+	Quantity   int                `gorm:"type:int;not null;default:1" json:"quantity" validate:"required,gt=0"`
+	Price      *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"price"`         // Unit price snapshot (menu price plus option deltas) at time of adding to cart
+	OptionsKey string             `gorm:"type:char(64);not null;default:'';index" json:"-"` // Identifies the chosen options, see OptionsKey
+	CreatedAt  time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
 	
 	// Relationships
-	Cart Cart `gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE" json:"cart,omitempty"`
-	Menu Menu `gorm:"foreignKey:MenuID;constraint:OnDelete:CASCADE" json:"menu,omitempty"`
+	Cart    Cart             `gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE" json:"cart,omitempty"`
+	Menu    Menu             `gorm:"foreignKey:MenuID;constraint:OnDelete:CASCADE" json:"menu,omitempty"`
+	Options []CartItemOption `gorm:"foreignKey:CartItemID;constraint:OnDelete:CASCADE" json:"options,omitempty"`
+	
+	// Not stored; set when the cart is checked against the current menu
+	Issues            []CartItemIssue    `gorm:"-" json:"issues,omitempty"`
+	CurrentPrice      *utils.GormDecimal `gorm:"-" json:"current_price,omitempty"`      // Set when the price changed
+	AvailableQuantity *int               `gorm:"-" json:"available_quantity,omitempty"` // Set when there is not enough stock
 }
 
 // TableName returns the table name for the CartItem entity
@@ -62,13 +71,40 @@ func (ci *CartItem) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// OptionIDs returns the IDs of the options chosen for this cart item
+func (ci *CartItem) OptionIDs() []utils.BinaryUUID {
+	ids := make([]utils.BinaryUUID, len(ci.Options))
+	for i, option := range ci.Options {
+		ids[i] = option.OptionID
+	}
+	return ids
+}
+
+// CartItemOption is an option chosen for a cart item, with a snapshot of its name and price delta
+type CartItemOption struct {
+	ID         utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	CartItemID utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"cart_item_id"`
+	OptionID   utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"option_id"`
+	GroupName  string             `gorm:"type:varchar(100);not null" json:"group_name"`
+	OptionName string             `gorm:"type:varchar(100);not null" json:"option_name"`
+	PriceDelta *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"price_delta"`
+	CreatedAt  time.Time          `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the CartItemOption entity
+func (CartItemOption) TableName() string {
+	return "cart_item_options"
+}
+
+// BeforeCreate hook to generate UUID before creating cart item option
+func (cio *CartItemOption) BeforeCreate(tx *gorm.DB) error {
+	if cio.ID == (utils.BinaryUUID{}) {
+		cio.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
 // GetSubtotal calculates the subtotal for this cart item (price * quantity)
 func (ci *CartItem) GetSubtotal() *utils.GormDecimal {
-	if ci.Price == nil {
-		return &utils.GormDecimal{Internal: pbdecimal.Decimal{Value: "0"}}
-	}
-	// Simple string-based calculation for decimal
-	priceFloat, _ := strconv.ParseFloat(ci.Price.Internal.Value, 64)
-	subtotal := priceFloat * float64(ci.Quantity)
-	return &utils.GormDecimal{Internal: pbdecimal.Decimal{Value: strconv.FormatFloat(subtotal, 'f', 2, 64)}}
+	return ci.Price.MulInt(ci.Quantity)
 }
\ No newline at end of file
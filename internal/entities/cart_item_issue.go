@@ -0,0 +1,31 @@
+// internal/entities/cart_item_issue.go
+package entities
+
+// CartItemIssue is something that changed about a cart line since it was added to the cart
+type CartItemIssue string
+
+const (
+	// CartItemPriceChanged means the menu item or its chosen options cost something else now
+	CartItemPriceChanged CartItemIssue = "price_changed"
+	// CartItemUnavailable means the menu item or one of its chosen options was deactivated
+	CartItemUnavailable CartItemIssue = "unavailable"
+	// CartItemDeleted means the menu item or one of its chosen options was removed from the menu
+	CartItemDeleted CartItemIssue = "deleted"
+	// CartItemInsufficientStock means there is less in stock than the cart line asks for
+	CartItemInsufficientStock CartItemIssue = "insufficient_stock"
+)
+
+// HasIssue checks if the cart item was flagged with the given issue
+func (ci *CartItem) HasIssue(issue CartItemIssue) bool {
+	for _, flagged := range ci.Issues {
+		if flagged == issue {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOrderable checks if the cart item can still be ordered at all, whatever its price or quantity
+func (ci *CartItem) IsOrderable() bool {
+	return !ci.HasIssue(CartItemUnavailable) && !ci.HasIssue(CartItemDeleted)
+}
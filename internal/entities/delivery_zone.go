@@ -0,0 +1,117 @@
+// internal/entities/delivery_zone.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// DeliveryZoneType defines how the area of a delivery zone is described
+type DeliveryZoneType string
+
+const (
+	DeliveryZonePolygon DeliveryZoneType = "polygon" // A GeoJSON Polygon or MultiPolygon
+	DeliveryZoneRadius  DeliveryZoneType = "radius"  // A circle around the store
+)
+
+// IsValid checks if the type is one of the known delivery zone types
+func (t DeliveryZoneType) IsValid() bool {
+	return t == DeliveryZonePolygon || t == DeliveryZoneRadius
+}
+
+// DeliveryZone is an area the store delivers to, with its own minimum order, fees and ETA
+type DeliveryZone struct {
+	ID               utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	Name             string             `gorm:"type:varchar(100);not null" json:"name"`
+	Type             DeliveryZoneType   `gorm:"type:varchar(20);not null" json:"type"`
+	Area             string             `gorm:"type:text" json:"area,omitempty"`         // GeoJSON geometry, polygon zones only
+	RadiusMeters     *int               `gorm:"type:int" json:"radius_meters,omitempty"` // Radius zones only
+	MinOrderAmount   *utils.GormDecimal `gorm:"type:decimal(10,2);not null;default:0" json:"min_order_amount"`
+	EstimatedMinutes int                `gorm:"type:int;not null" json:"estimated_minutes"`  // Delivery time on top of preparation
+	Priority         int                `gorm:"type:int;not null;default:0" json:"priority"` // Lower wins where zones overlap
+	IsActive         bool               `gorm:"type:boolean;not null;default:true" json:"is_active"`
+	CreatedAt        time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt     `gorm:"index" json:"-"`
+
+	// Relationships
+	FeeTiers []DeliveryFeeTier `gorm:"foreignKey:ZoneID;constraint:OnDelete:CASCADE" json:"fee_tiers"`
+}
+
+// TableName returns the table name for the DeliveryZone entity
+func (DeliveryZone) TableName() string {
+	return "delivery_zones"
+}
+
+// BeforeCreate hook to generate UUID before creating delivery zone
+func (z *DeliveryZone) BeforeCreate(tx *gorm.DB) error {
+	if z.ID == (utils.BinaryUUID{}) {
+		z.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// FeeFor returns the fee of the first tier covering the distance from the store. FeeTiers must be
+// loaded and sorted by distance; false means the zone doesn't deliver that far.
+func (z *DeliveryZone) FeeFor(distanceMeters int) (*utils.GormDecimal, bool) {
+	for _, tier := range z.FeeTiers {
+		if distanceMeters <= tier.UpToMeters {
+			return tier.Fee, true
+		}
+	}
+	return nil, false
+}
+
+// DeliveryFeeTier is one step of a zone's fee schedule: deliveries up to UpToMeters from the store
+// cost Fee
+type DeliveryFeeTier struct {
+	ID         utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	ZoneID     utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"zone_id"`
+	UpToMeters int                `gorm:"type:int;not null" json:"up_to_meters"`
+	Fee        *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"fee"`
+}
+
+// TableName returns the table name for the DeliveryFeeTier entity
+func (DeliveryFeeTier) TableName() string {
+	return "delivery_fee_tiers"
+}
+
+// BeforeCreate hook to generate UUID before creating fee tier
+func (t *DeliveryFeeTier) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == (utils.BinaryUUID{}) {
+		t.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// OrderFeeKind defines what an order fee is charged for
+type OrderFeeKind string
+
+const (
+	OrderFeeDelivery OrderFeeKind = "delivery"
+	OrderFeeTip      OrderFeeKind = "tip" // Given by the customer at checkout
+)
+
+// OrderFee is a charge on an order besides its items, e.g. the delivery fee or a tip. Fees are not taxed.
+type OrderFee struct {
+	ID        utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderID   utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"order_id"`
+	Kind      OrderFeeKind       `gorm:"type:varchar(20);not null" json:"kind"`
+	Name      string             `gorm:"type:varchar(100);not null" json:"name"` // Snapshot, e.g. "Delivery (Downtown)"
+	Amount    *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+	CreatedAt time.Time          `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the OrderFee entity
+func (OrderFee) TableName() string {
+	return "order_fees"
+}
+
+// BeforeCreate hook to generate UUID before creating order fee
+func (f *OrderFee) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == (utils.BinaryUUID{}) {
+		f.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// internal/entities/idempotency_key.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// IdempotencyStatus defines the processing state of an idempotent request
+type IdempotencyStatus string
+
+const (
+	IdempotencyProcessing IdempotencyStatus = "processing"
+	IdempotencyCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyKey stores a client supplied Idempotency-Key together with the response that was sent for it
+type IdempotencyKey struct {
+	ID             utils.BinaryUUID  `gorm:"type:binary(16);primaryKey" json:"id"`
+	UserID         utils.BinaryUUID  `gorm:"type:binary(16);not null;uniqueIndex:idx_idempotency_user_key" json:"user_id"`
+	Key            string            `gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key" json:"key"`
+	Fingerprint    string            `gorm:"type:char(64);not null" json:"-"` // SHA-256 of method, path and body
+	Status         IdempotencyStatus `gorm:"type:varchar(20);not null;default:'processing'" json:"status"`
+	ResponseStatus int               `gorm:"type:int" json:"-"`
+	ResponseBody   []byte            `gorm:"type:mediumblob" json:"-"`
+	ExpiresAt      time.Time         `gorm:"not null;index" json:"expires_at"`
+	CreatedAt      time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the IdempotencyKey entity
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// BeforeCreate hook to generate UUID before creating idempotency key
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == (utils.BinaryUUID{}) {
+		k.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// IsExpired checks if the key has outlived its retention period
+func (k *IdempotencyKey) IsExpired(now time.Time) bool {
+	return now.After(k.ExpiresAt)
+}
@@ -22,8 +22,12 @@ type Menu struct {
 	DeletedAt   gorm.DeletedAt    `gorm:"index" json:"deleted_at,omitempty"`
 	
 	// Relationships
-	CartItems  []CartItem  `gorm:"foreignKey:MenuID;constraint:OnDelete:CASCADE" json:"cart_items,omitempty"`
-	OrderItems []OrderItem `gorm:"foreignKey:MenuID;constraint:OnDelete:RESTRICT" json:"order_items,omitempty"`
+	CartItems    []CartItem        `gorm:"foreignKey:MenuID;constraint:OnDelete:CASCADE" json:"cart_items,omitempty"`
+	OrderItems   []OrderItem       `gorm:"foreignKey:MenuID;constraint:OnDelete:RESTRICT" json:"order_items,omitempty"`
+	OptionGroups []MenuOptionGroup `gorm:"foreignKey:MenuID;constraint:OnDelete:CASCADE" json:"option_groups,omitempty"`
+	
+	// Not stored; set by the menu endpoints
+	AvailableStock *int `gorm:"-" json:"available_stock,omitempty"` // Stock minus what active cart holds keep aside
 }
 
 // TableName returns the table name for the Menu entity
@@ -0,0 +1,150 @@
+// internal/entities/menu_option.go
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"shopify-app/internal/utils"
+	"sort"
+	"strings"
+	"time"
+	"gorm.io/gorm"
+)
+
+// MenuOptionGroup groups the options a customer can pick for a menu item, e.g. size or toppings
+type MenuOptionGroup struct {
+	ID        utils.BinaryUUID `gorm:"type:binary(16);primaryKey" json:"id"`
+	MenuID    utils.BinaryUUID `gorm:"type:binary(16);not null;index" json:"menu_id"`
+	Name      string           `gorm:"type:varchar(100);not null" json:"name" validate:"required,min=1,max=100"`
+	MinSelect int              `gorm:"type:int;not null;default:0" json:"min_select" validate:"gte=0"` // 1 with MaxSelect 1 means "choose exactly one"
+	MaxSelect int              `gorm:"type:int;not null;default:1" json:"max_select" validate:"gte=1"`
+	SortOrder int              `gorm:"type:int;not null;default:0" json:"sort_order"`
+	CreatedAt time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Options []MenuOption `gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE" json:"options,omitempty"`
+}
+
+// TableName returns the table name for the MenuOptionGroup entity
+func (MenuOptionGroup) TableName() string {
+	return "menu_option_groups"
+}
+
+// BeforeCreate hook to generate UUID before creating option group
+func (g *MenuOptionGroup) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == (utils.BinaryUUID{}) {
+		g.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// MenuOption is a single choice within an option group, e.g. "Large" or "Extra cheese"
+type MenuOption struct {
+	ID         utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	GroupID    utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"group_id"`
+	Name       string             `gorm:"type:varchar(100);not null" json:"name" validate:"required,min=1,max=100"`
+	PriceDelta *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"price_delta"` // Added to the menu price
+	Stock      *int               `gorm:"type:int" json:"stock"`                          // Nil when stock is not tracked
+	IsActive   bool               `gorm:"type:boolean;not null;default:true" json:"is_active"`
+	CreatedAt  time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Not stored; set by the menu endpoints for options that track stock
+	AvailableStock *int `gorm:"-" json:"available_stock,omitempty"` // Stock minus what active cart holds keep aside
+}
+
+// TableName returns the table name for the MenuOption entity
+func (MenuOption) TableName() string {
+	return "menu_options"
+}
+
+// BeforeCreate hook to generate UUID before creating option
+func (o *MenuOption) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == (utils.BinaryUUID{}) {
+		o.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// TracksStock checks if the option has its own stock count
+func (o *MenuOption) TracksStock() bool {
+	return o.Stock != nil
+}
+
+// IsInStock checks if the option is available for the requested quantity
+func (o *MenuOption) IsInStock(requestedQuantity int) bool {
+	return o.IsActive && (o.Stock == nil || *o.Stock >= requestedQuantity)
+}
+
+// SelectedOption is an option picked by a customer together with the group it belongs to
+type SelectedOption struct {
+	Group  MenuOptionGroup
+	Option MenuOption
+}
+
+// FindOption looks up an option of this menu item by its ID
+func (m *Menu) FindOption(optionID utils.BinaryUUID) (*MenuOptionGroup, *MenuOption, bool) {
+	for gi := range m.OptionGroups {
+		group := &m.OptionGroups[gi]
+		for oi := range group.Options {
+			if group.Options[oi].ID == optionID {
+				return group, &group.Options[oi], true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// SelectOptions validates a customer's choice of options against the option groups of this
+// menu item. OptionGroups and their Options must be loaded.
+func (m *Menu) SelectOptions(optionIDs []utils.BinaryUUID) ([]SelectedOption, error) {
+	selected := make([]SelectedOption, 0, len(optionIDs))
+	perGroup := make(map[utils.BinaryUUID]int)
+	seen := make(map[utils.BinaryUUID]bool)
+
+	for _, id := range optionIDs {
+		if seen[id] {
+			return nil, fmt.Errorf("option %s selected more than once", id)
+		}
+		seen[id] = true
+
+		group, option, ok := m.FindOption(id)
+		if !ok {
+			return nil, fmt.Errorf("option %s does not belong to %s", id, m.Name)
+		}
+		if !option.IsActive {
+			return nil, fmt.Errorf("option %s is not available", option.Name)
+		}
+		perGroup[group.ID]++
+		selected = append(selected, SelectedOption{Group: *group, Option: *option})
+	}
+
+	for _, group := range m.OptionGroups {
+		count := perGroup[group.ID]
+		if count < group.MinSelect {
+			return nil, fmt.Errorf("choose at least %d option(s) for %s", group.MinSelect, group.Name)
+		}
+		if count > group.MaxSelect {
+			return nil, fmt.Errorf("choose at most %d option(s) for %s", group.MaxSelect, group.Name)
+		}
+	}
+
+	return selected, nil
+}
+
+// OptionsKey builds a stable key for a set of option IDs, so that cart lines with the same menu
+// item and the same options can be merged regardless of the order the options were picked in
+func OptionsKey(optionIDs []utils.BinaryUUID) string {
+	if len(optionIDs) == 0 {
+		return ""
+	}
+	ids := make([]string, len(optionIDs))
+	for i, id := range optionIDs {
+		ids[i] = id.String()
+	}
+	sort.Strings(ids)
+	sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	return hex.EncodeToString(sum[:])
+}
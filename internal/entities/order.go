@@ -4,9 +4,7 @@ package entities
 import (
 	"shopify-app/internal/utils"
 	"time"
-	"strconv"
 	"gorm.io/gorm"
-	pbdecimal "google.golang.org/genproto/googleapis/type/decimal"
 )
 
 // OrderStatus defines the status types for orders
@@ -14,6 +12,7 @@ type OrderStatus string
 
 const (
 	StatusPending   OrderStatus = "pending"
+	StatusScheduled OrderStatus = "scheduled" // Paid for a future slot, waiting to be released to the kitchen
 	StatusConfirmed OrderStatus = "confirmed"
 	StatusPreparing OrderStatus = "preparing"
 	StatusReady     OrderStatus = "ready"
@@ -21,18 +20,61 @@ const (
 	StatusCancelled OrderStatus = "cancelled"
 )
 
+// orderStatusTransitions lists the statuses an order may move to from each status.
+// Cancellation is only allowed while the kitchen has not started preparing.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	StatusPending:   {StatusConfirmed, StatusScheduled, StatusCancelled},
+	StatusScheduled: {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusPreparing, StatusCancelled},
+	StatusPreparing: {StatusReady},
+	StatusReady:     {StatusDelivered},
+	StatusDelivered: {},
+	StatusCancelled: {},
+}
+
+// IsValid checks if the status is one of the known order statuses
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderStatusTransitions[s]
+	return ok
+}
+
+// IsFinal checks if no further status changes are possible from this status
+func (s OrderStatus) IsFinal() bool {
+	return s.IsValid() && len(orderStatusTransitions[s]) == 0
+}
+
 // Order represents the order entity in the database
 type Order struct {
-	ID          utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
-	UserID      utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"user_id"`
-	TotalAmount *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"total_amount"`
-	Status      OrderStatus        `gorm:"type:enum('pending','confirmed','preparing','ready','delivered','cancelled');not null;default:'pending'" json:"status"`
-	CreatedAt   time.Time          `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+	ID               utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderNumber      string             `gorm:"type:varchar(32);not null;default:'';index" json:"order_number"`          // Short number for staff and receipts, see utils.OrderNumberFormat
+	OrderDay         string             `gorm:"type:char(10);not null;default:'';index:idx_order_day_sequence" json:"-"` // Day the order number is counted in, YYYY-MM-DD
+	DailySequence    int                `gorm:"type:int;not null;default:0;index:idx_order_day_sequence" json:"-"`       // Position of the order within its day
+	UserID           utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"user_id"`
+	Subtotal         *utils.GormDecimal `gorm:"type:decimal(10,2);not null;default:0" json:"subtotal"` // Sum of the item prices as listed
+	DiscountAmount   *utils.GormDecimal `gorm:"type:decimal(10,2);not null;default:0" json:"discount_amount"`
+	TaxAmount        *utils.GormDecimal `gorm:"type:decimal(10,2);not null;default:0" json:"tax_amount"`
+	FeeAmount        *utils.GormDecimal `gorm:"type:decimal(10,2);not null;default:0" json:"fee_amount"` // Sum of the fee lines, e.g. delivery
+	AmountRefunded   *utils.GormDecimal `gorm:"type:decimal(10,2);not null;default:0" json:"amount_refunded"`
+	TotalAmount      *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"total_amount"`
+	PricesIncludeTax bool               `gorm:"type:boolean;not null;default:false" json:"prices_include_tax"`
+	Status           OrderStatus        `gorm:"type:enum('pending','scheduled','confirmed','preparing','ready','delivered','cancelled');not null;default:'pending'" json:"status"`
+	FulfilmentType   FulfilmentType     `gorm:"type:enum('pickup','delivery','dine_in');not null;default:'pickup'" json:"fulfilment_type"`
+	ScheduledFor     *time.Time         `gorm:"index" json:"scheduled_for,omitempty"` // Start of the chosen pickup slot; nil for "as soon as possible"
+	RestockedAt      *time.Time         `json:"restocked_at,omitempty"` // Set once the items of a cancelled order are back in stock
+	CreatedAt        time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
 	
 	// Relationships
-	User       User        `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
-	OrderItems []OrderItem `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"order_items,omitempty"`
+	User            User                 `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	OrderItems      []OrderItem          `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"order_items,omitempty"`
+	StatusHistory   []OrderStatusHistory `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"timeline,omitempty"`
+	Payments        []Payment            `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"payments,omitempty"`
+	TaxLines        []OrderTaxLine       `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"tax_lines,omitempty"`
+	Discounts       []OrderDiscount      `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"discounts,omitempty"`
+	Fees            []OrderFee           `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"fees,omitempty"`
+	Refunds         []Refund             `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"refunds,omitempty"`
+	Revisions       []OrderRevision      `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"revisions,omitempty"`
+	DeliveryAddress *OrderAddress        `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"delivery_address,omitempty"` // Delivery orders only
 }
 
 // TableName returns the table name for the Order entity
@@ -50,18 +92,21 @@ func (o *Order) BeforeCreate(tx *gorm.DB) error {
 
 // OrderItem represents individual items in an order
 type OrderItem struct {
-	ID        utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
-	OrderID   utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"order_id"`
-	MenuID    utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"menu_id"`
-	Quantity  int                `gorm:"type:int;not null" json:"quantity"`
-	Price     *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"price"` // Price snapshot at time of order
-	MenuName  string             `gorm:"type:varchar(255);not null" json:"menu_name"` // Menu name snapshot
-	CreatedAt time.Time          `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+	ID                utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderID           utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"order_id"`
+	MenuID            utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"menu_id"`
+	Quantity          int                `gorm:"type:int;not null" json:"quantity"`
+	Price             *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"price"`    // Price snapshot at time of order
+	RefundedQuantity  int                `gorm:"type:int;not null;default:0" json:"refunded_quantity"`
+	RestockedQuantity int                `gorm:"type:int;not null;default:0" json:"-"`        // Put back into stock by refunds
+	MenuName          string             `gorm:"type:varchar(255);not null" json:"menu_name"` // Menu name snapshot
+	CreatedAt         time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
 	
 	// Relationships
-	Order Order `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"order,omitempty"`
-	Menu  Menu  `gorm:"foreignKey:MenuID;constraint:OnDelete:RESTRICT" json:"menu,omitempty"`
+	Order   Order             `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"order,omitempty"`
+	Menu    Menu              `gorm:"foreignKey:MenuID;constraint:OnDelete:RESTRICT" json:"menu,omitempty"`
+	Options []OrderItemOption `gorm:"foreignKey:OrderItemID;constraint:OnDelete:CASCADE" json:"options,omitempty"`
 }
 
 // TableName returns the table name for the OrderItem entity
@@ -77,20 +122,101 @@ func (oi *OrderItem) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// OrderItemOption is a snapshot of an option chosen for an order item at time of order
+type OrderItemOption struct {
+	ID          utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderItemID utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"order_item_id"`
+	OptionID    utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"option_id"`
+	GroupName   string             `gorm:"type:varchar(100);not null" json:"group_name"`
+	OptionName  string             `gorm:"type:varchar(100);not null" json:"option_name"`
+	PriceDelta  *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"price_delta"`
+	CreatedAt   time.Time          `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the OrderItemOption entity
+func (OrderItemOption) TableName() string {
+	return "order_item_options"
+}
+
+// BeforeCreate hook to generate UUID before creating order item option
+func (oio *OrderItemOption) BeforeCreate(tx *gorm.DB) error {
+	if oio.ID == (utils.BinaryUUID{}) {
+		oio.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
 // GetSubtotal calculates the subtotal for this order item (price * quantity)
 func (oi *OrderItem) GetSubtotal() *utils.GormDecimal {
-	if oi.Price == nil {
-		return &utils.GormDecimal{Internal: pbdecimal.Decimal{Value: "0"}}
-	}
-	// Simple string-based calculation for decimal
-	priceFloat, _ := strconv.ParseFloat(oi.Price.Internal.Value, 64)
-	subtotal := priceFloat * float64(oi.Quantity)
-	return &utils.GormDecimal{Internal: pbdecimal.Decimal{Value: strconv.FormatFloat(subtotal, 'f', 2, 64)}}
+	return oi.Price.MulInt(oi.Quantity)
+}
+
+// CanBeEdited checks if the items of the order may still be changed, which is only the case
+// before the store has confirmed it
+func (o *Order) CanBeEdited() bool {
+	return o.Status == StatusPending
 }
 
 // CanBeCancelled checks if the order can be cancelled based on its current status
 func (o *Order) CanBeCancelled() bool {
-	return o.Status == StatusPending || o.Status == StatusConfirmed
+	return o.CanTransitionTo(StatusCancelled)
+}
+
+// CanTransitionTo checks if the order may move from its current status to the given one
+func (o *Order) CanTransitionTo(status OrderStatus) bool {
+	for _, next := range orderStatusTransitions[o.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStatus returns the next status in the regular fulfilment flow, if there is one. A pending
+// order for a later slot moves to scheduled instead; the service decides that.
+func (o *Order) NextStatus() (OrderStatus, bool) {
+	for _, next := range orderStatusTransitions[o.Status] {
+		if next != StatusCancelled && next != StatusScheduled {
+			return next, true
+		}
+	}
+	return "", false
+}
+
+// StatusReachedAt returns when the order last moved to the given status. StatusHistory must be loaded.
+func (o *Order) StatusReachedAt(status OrderStatus) (time.Time, bool) {
+	for i := len(o.StatusHistory) - 1; i >= 0; i-- {
+		if o.StatusHistory[i].ToStatus == status {
+			return o.StatusHistory[i].CreatedAt, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// EstimatedReadyAt estimates when the order will be ready, given how long the kitchen needs to
+// prepare an order. Returns false for cancelled orders. StatusHistory must be loaded.
+func (o *Order) EstimatedReadyAt(prepTime time.Duration, now time.Time) (time.Time, bool) {
+	switch o.Status {
+	case StatusCancelled:
+		return time.Time{}, false
+	case StatusReady, StatusDelivered:
+		if readyAt, ok := o.StatusReachedAt(StatusReady); ok {
+			return readyAt, true
+		}
+		return o.UpdatedAt, true
+	case StatusPreparing:
+		if startedAt, ok := o.StatusReachedAt(StatusPreparing); ok {
+			if readyAt := startedAt.Add(prepTime); readyAt.After(now) {
+				return readyAt, true
+			}
+			return now, true // Running late
+		}
+	}
+	// The kitchen has not started yet
+	if o.ScheduledFor != nil && o.ScheduledFor.After(now) {
+		return *o.ScheduledFor, true
+	}
+	return now.Add(prepTime), true
 }
 
 // CanBeUpdated checks if the order can be updated based on its current status
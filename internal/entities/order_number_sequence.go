@@ -0,0 +1,18 @@
+// internal/entities/order_number_sequence.go
+package entities
+
+import (
+	"time"
+)
+
+// OrderNumberSequence counts the orders placed on a day, so each order gets the next number of its day
+type OrderNumberSequence struct {
+	Day       string    `gorm:"type:char(10);primaryKey" json:"day"` // YYYY-MM-DD in the store's time zone
+	LastValue int       `gorm:"type:int;not null;default:0" json:"last_value"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the OrderNumberSequence entity
+func (OrderNumberSequence) TableName() string {
+	return "order_number_sequences"
+}
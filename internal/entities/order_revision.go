@@ -0,0 +1,60 @@
+// internal/entities/order_revision.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// OrderRevision records an edit made to the items of a pending order
+type OrderRevision struct {
+	ID            utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderID       utils.BinaryUUID   `gorm:"type:binary(16);not null;uniqueIndex:idx_order_revision_number" json:"order_id"`
+	Number        int                `gorm:"type:int;not null;uniqueIndex:idx_order_revision_number" json:"number"` // 1 for the first edit
+	ChangedBy     *utils.BinaryUUID  `gorm:"type:binary(16)" json:"changed_by,omitempty"`
+	PreviousTotal *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"previous_total"`
+	NewTotal      *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"new_total"`
+	CreatedAt     time.Time          `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Changes []OrderRevisionChange `gorm:"foreignKey:RevisionID;constraint:OnDelete:CASCADE" json:"changes,omitempty"`
+}
+
+// TableName returns the table name for the OrderRevision entity
+func (OrderRevision) TableName() string {
+	return "order_revisions"
+}
+
+// BeforeCreate hook to generate UUID before creating order revision
+func (r *OrderRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == (utils.BinaryUUID{}) {
+		r.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// OrderRevisionChange is the change to a single order item within a revision. An added item has
+// FromQuantity 0 and a removed item has ToQuantity 0.
+type OrderRevisionChange struct {
+	ID           utils.BinaryUUID `gorm:"type:binary(16);primaryKey" json:"id"`
+	RevisionID   utils.BinaryUUID `gorm:"type:binary(16);not null;index" json:"revision_id"`
+	OrderItemID  utils.BinaryUUID `gorm:"type:binary(16);not null" json:"order_item_id"`
+	MenuID       utils.BinaryUUID `gorm:"type:binary(16);not null" json:"menu_id"`
+	MenuName     string           `gorm:"type:varchar(255);not null" json:"menu_name"`
+	FromQuantity int              `gorm:"type:int;not null" json:"from_quantity"`
+	ToQuantity   int              `gorm:"type:int;not null" json:"to_quantity"`
+}
+
+// TableName returns the table name for the OrderRevisionChange entity
+func (OrderRevisionChange) TableName() string {
+	return "order_revision_changes"
+}
+
+// BeforeCreate hook to generate UUID before creating order revision change
+func (c *OrderRevisionChange) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == (utils.BinaryUUID{}) {
+		c.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
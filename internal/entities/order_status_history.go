@@ -0,0 +1,32 @@
+// internal/entities/order_status_history.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// OrderStatusHistory records a single status transition of an order
+type OrderStatusHistory struct {
+	ID         utils.BinaryUUID  `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderID    utils.BinaryUUID  `gorm:"type:binary(16);not null;index" json:"order_id"`
+	FromStatus OrderStatus       `gorm:"type:varchar(20);not null;default:''" json:"from_status"` // Empty for the initial status
+	ToStatus   OrderStatus       `gorm:"type:varchar(20);not null" json:"to_status"`
+	ChangedBy  *utils.BinaryUUID `gorm:"type:binary(16)" json:"changed_by,omitempty"` // Nil when changed by the system
+	Reason     string            `gorm:"type:varchar(255)" json:"reason,omitempty"`
+	CreatedAt  time.Time         `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TableName returns the table name for the OrderStatusHistory entity
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+// BeforeCreate hook to generate UUID before creating status history entry
+func (h *OrderStatusHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == (utils.BinaryUUID{}) {
+		h.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// internal/entities/payment.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// PaymentStatus defines the state of a payment with the payment provider
+type PaymentStatus string
+
+const (
+	PaymentPending   PaymentStatus = "pending"
+	PaymentSucceeded PaymentStatus = "succeeded"
+	PaymentFailed    PaymentStatus = "failed"
+	PaymentCancelled PaymentStatus = "cancelled"
+)
+
+// IsFinal checks if the provider can no longer change the payment
+func (s PaymentStatus) IsFinal() bool {
+	return s != PaymentPending
+}
+
+// Payment represents an attempt to pay for an order through a payment provider
+type Payment struct {
+	ID                utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderID           utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"order_id"`
+	Provider          string             `gorm:"type:varchar(50);not null;uniqueIndex:idx_payment_provider_reference" json:"provider"`
+	ProviderReference string             `gorm:"type:varchar(255);not null;uniqueIndex:idx_payment_provider_reference" json:"provider_reference"`
+	ClientSecret      string             `gorm:"type:varchar(255)" json:"client_secret,omitempty"` // Handed to the client to complete the payment
+	Amount            *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency          string             `gorm:"type:char(3);not null" json:"currency"`
+	Status            PaymentStatus      `gorm:"type:enum('pending','succeeded','failed','cancelled');not null;default:'pending'" json:"status"`
+	FailureReason     string             `gorm:"type:varchar(255)" json:"failure_reason,omitempty"`
+	CreatedAt         time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Payment entity
+func (Payment) TableName() string {
+	return "payments"
+}
+
+// BeforeCreate hook to generate UUID before creating payment
+func (p *Payment) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == (utils.BinaryUUID{}) {
+		p.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// internal/entities/promotion.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"strings"
+	"time"
+	"gorm.io/gorm"
+)
+
+// PromotionType defines how a promotion discounts a cart
+type PromotionType string
+
+const (
+	PromotionPercentage  PromotionType = "percentage"   // Value percent off the eligible items
+	PromotionFixedAmount PromotionType = "fixed_amount" // Value off the eligible items
+	PromotionBuyXGetY    PromotionType = "buy_x_get_y"  // For every BuyQuantity eligible items, GetQuantity of them are free (the cheapest)
+	PromotionFreeItem    PromotionType = "free_item"    // GetQuantity units of FreeMenuID are free once MinSubtotal is reached
+)
+
+// IsValid checks if the type is one of the known promotion types
+func (t PromotionType) IsValid() bool {
+	switch t {
+	case PromotionPercentage, PromotionFixedAmount, PromotionBuyXGetY, PromotionFreeItem:
+		return true
+	}
+	return false
+}
+
+// Promotion is a discount that applies automatically or when its coupon code is entered
+type Promotion struct {
+	ID           utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	Name         string             `gorm:"type:varchar(100);not null" json:"name" validate:"required,min=1,max=100"`
+	Code         *string            `gorm:"type:varchar(50);uniqueIndex" json:"code,omitempty"` // Nil for automatic promotions
+	Type         PromotionType      `gorm:"type:varchar(20);not null" json:"type"`
+	Value        *utils.GormDecimal `gorm:"type:decimal(10,2)" json:"value,omitempty"`       // Percentage or amount off
+	Category     string             `gorm:"type:varchar(100)" json:"category,omitempty"`     // Limits the promotion to a category
+	BuyQuantity  int                `gorm:"type:int;not null;default:0" json:"buy_quantity"` // Buy-X-get-Y only
+	GetQuantity  int                `gorm:"type:int;not null;default:0" json:"get_quantity"` // Buy-X-get-Y and free item
+	FreeMenuID   *utils.BinaryUUID  `gorm:"type:binary(16)" json:"free_menu_id,omitempty"`
+	MinSubtotal  *utils.GormDecimal `gorm:"type:decimal(10,2)" json:"min_subtotal,omitempty"`
+	StartsAt     *time.Time         `json:"starts_at,omitempty"`
+	EndsAt       *time.Time         `json:"ends_at,omitempty"`
+	UsageLimit   *int               `gorm:"type:int" json:"usage_limit,omitempty"` // Across all customers
+	PerUserLimit *int               `gorm:"type:int" json:"per_user_limit,omitempty"`
+	UsageCount   int                `gorm:"type:int;not null;default:0" json:"usage_count"`
+	Stackable    bool               `gorm:"type:boolean;not null;default:false" json:"stackable"` // May be combined with other stackable promotions
+	IsActive     bool               `gorm:"type:boolean;not null;default:true" json:"is_active"`
+	CreatedAt    time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt     `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// TableName returns the table name for the Promotion entity
+func (Promotion) TableName() string {
+	return "promotions"
+}
+
+// BeforeCreate hook to generate UUID before creating promotion
+func (p *Promotion) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == (utils.BinaryUUID{}) {
+		p.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// IsRunning checks if the promotion is active and within its validity window
+func (p *Promotion) IsRunning(now time.Time) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// HasUsesLeft checks the global usage limit
+func (p *Promotion) HasUsesLeft() bool {
+	return p.UsageLimit == nil || p.UsageCount < *p.UsageLimit
+}
+
+// AppliesToCategory checks if items of a category are eligible for the promotion
+func (p *Promotion) AppliesToCategory(category string) bool {
+	return p.Category == "" || strings.EqualFold(p.Category, category)
+}
+
+// PromotionRedemption records that a promotion was used on an order
+type PromotionRedemption struct {
+	ID          utils.BinaryUUID `gorm:"type:binary(16);primaryKey" json:"id"`
+	PromotionID utils.BinaryUUID `gorm:"type:binary(16);not null;index:idx_redemption_promotion_user" json:"promotion_id"`
+	UserID      utils.BinaryUUID `gorm:"type:binary(16);not null;index:idx_redemption_promotion_user" json:"user_id"`
+	OrderID     utils.BinaryUUID `gorm:"type:binary(16);not null;index" json:"order_id"`
+	CreatedAt   time.Time        `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the PromotionRedemption entity
+func (PromotionRedemption) TableName() string {
+	return "promotion_redemptions"
+}
+
+// BeforeCreate hook to generate UUID before creating promotion redemption
+func (r *PromotionRedemption) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == (utils.BinaryUUID{}) {
+		r.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// OrderDiscount is a discount applied to an order, as calculated at checkout
+type OrderDiscount struct {
+	ID          utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderID     utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"order_id"`
+	PromotionID utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"promotion_id"`
+	Code        string             `gorm:"type:varchar(50)" json:"code,omitempty"`
+	Name        string             `gorm:"type:varchar(100);not null" json:"name"` // Promotion name snapshot
+	Amount      *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+	CreatedAt   time.Time          `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the OrderDiscount entity
+func (OrderDiscount) TableName() string {
+	return "order_discounts"
+}
+
+// BeforeCreate hook to generate UUID before creating order discount
+func (d *OrderDiscount) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == (utils.BinaryUUID{}) {
+		d.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
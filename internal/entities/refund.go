@@ -0,0 +1,72 @@
+// internal/entities/refund.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// RefundStatus defines the state of a refund with the payment provider
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending" // Recorded, but not confirmed by the provider yet
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed" // Refused by the provider; nothing was paid back
+)
+
+// Refund is money paid back to the customer for an order, in full or for some of its items
+type Refund struct {
+	ID                utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderID           utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"order_id"`
+	PaymentID         utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"payment_id"`
+	Amount            *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Reason            string             `gorm:"type:varchar(255);not null" json:"reason"`
+	Status            RefundStatus       `gorm:"type:enum('pending','succeeded','failed');not null;default:'succeeded'" json:"status"`
+	FailureReason     string             `gorm:"type:varchar(255)" json:"failure_reason,omitempty"`
+	RestockRequested  bool               `gorm:"type:boolean;not null;default:false" json:"restock_requested"` // Put the items back into stock once the refund succeeds
+	Restocked         bool               `gorm:"type:boolean;not null;default:false" json:"restocked"`         // The refunded items were put back into stock
+	ProviderReference string             `gorm:"type:varchar(255)" json:"provider_reference,omitempty"`
+	CreatedBy         *utils.BinaryUUID  `gorm:"type:binary(16)" json:"created_by,omitempty"` // Admin who issued the refund
+	CreatedAt         time.Time          `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Items []RefundItem `gorm:"foreignKey:RefundID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+}
+
+// TableName returns the table name for the Refund entity
+func (Refund) TableName() string {
+	return "refunds"
+}
+
+// BeforeCreate hook to generate UUID before creating refund
+func (r *Refund) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == (utils.BinaryUUID{}) {
+		r.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// RefundItem is the quantity of an order item a refund covers
+type RefundItem struct {
+	ID          utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	RefundID    utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"refund_id"`
+	OrderItemID utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"order_item_id"`
+	MenuName    string             `gorm:"type:varchar(255);not null" json:"menu_name"`
+	Quantity    int                `gorm:"type:int;not null" json:"quantity"`
+	Amount      *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+}
+
+// TableName returns the table name for the RefundItem entity
+func (RefundItem) TableName() string {
+	return "refund_items"
+}
+
+// BeforeCreate hook to generate UUID before creating refund item
+func (ri *RefundItem) BeforeCreate(tx *gorm.DB) error {
+	if ri.ID == (utils.BinaryUUID{}) {
+		ri.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// internal/entities/scheduled_job.go
+package entities
+
+import (
+	"time"
+)
+
+// ScheduledJob is the shared state of a background job. The row doubles as the lock that makes sure
+// only one server instance runs the job at a time.
+type ScheduledJob struct {
+	Name           string     `gorm:"type:varchar(100);primaryKey" json:"name"`
+	Schedule       string     `gorm:"type:varchar(100);not null" json:"schedule"`
+	LockedBy       string     `gorm:"type:varchar(255);not null;default:''" json:"locked_by,omitempty"` // Instance running the job, empty when idle
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`                                           // A crashed instance's lock is taken over after this
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastDurationMs int64      `gorm:"type:bigint;not null;default:0" json:"last_duration_ms"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	RunCount       int64      `gorm:"type:bigint;not null;default:0" json:"run_count"`
+	FailureCount   int64      `gorm:"type:bigint;not null;default:0" json:"failure_count"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the ScheduledJob entity
+func (ScheduledJob) TableName() string {
+	return "scheduled_jobs"
+}
+
+// IsLocked checks if an instance holds the job's lock at the given time
+func (j *ScheduledJob) IsLocked(now time.Time) bool {
+	return j.LockedBy != "" && j.LockedUntil != nil && j.LockedUntil.After(now)
+}
@@ -0,0 +1,32 @@
+// internal/entities/slot.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// SlotBooking counts the orders and items booked into a pickup or delivery time slot. The row
+// is locked while booking so concurrent checkouts can't overbook the slot.
+type SlotBooking struct {
+	ID         utils.BinaryUUID `gorm:"type:binary(16);primaryKey" json:"id"`
+	SlotStart  time.Time        `gorm:"not null;uniqueIndex" json:"slot_start"`
+	OrderCount int              `gorm:"type:int;not null;default:0" json:"order_count"`
+	ItemCount  int              `gorm:"type:int;not null;default:0" json:"item_count"`
+	CreatedAt  time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the SlotBooking entity
+func (SlotBooking) TableName() string {
+	return "slot_bookings"
+}
+
+// BeforeCreate hook to generate UUID before creating slot booking
+func (b *SlotBooking) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == (utils.BinaryUUID{}) {
+		b.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// internal/entities/stock_hold.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// StockHold keeps the quantity of a cart line aside for a while, so the stock is still there at
+// checkout. Holds don't change the stock itself; they only lower what other carts can take.
+type StockHold struct {
+	ID         utils.BinaryUUID `gorm:"type:binary(16);primaryKey" json:"id"`
+	CartID     utils.BinaryUUID `gorm:"type:binary(16);not null;index" json:"cart_id"`
+	CartItemID utils.BinaryUUID `gorm:"type:binary(16);not null;uniqueIndex" json:"cart_item_id"`
+	MenuID     utils.BinaryUUID `gorm:"type:binary(16);not null;index:idx_stock_hold_menu_expiry" json:"menu_id"`
+	Quantity   int              `gorm:"type:int;not null" json:"quantity"`
+	ExpiresAt  time.Time        `gorm:"not null;index:idx_stock_hold_menu_expiry;index" json:"expires_at"` // The hold no longer counts after this
+	CreatedAt  time.Time        `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Cart     Cart     `gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE" json:"-"`
+	CartItem CartItem `gorm:"foreignKey:CartItemID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName returns the table name for the StockHold entity
+func (StockHold) TableName() string {
+	return "stock_holds"
+}
+
+// BeforeCreate hook to generate UUID before creating stock hold
+func (h *StockHold) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == (utils.BinaryUUID{}) {
+		h.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// internal/entities/tax.go
+package entities
+
+import (
+	"shopify-app/internal/utils"
+	"time"
+	"gorm.io/gorm"
+)
+
+// TaxRule is a tax rate that applies to a single menu item, to a category, or to everything when
+// neither is set. The most specific matching rules win.
+type TaxRule struct {
+	ID        utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	Name      string             `gorm:"type:varchar(100);not null" json:"name" validate:"required,min=1,max=100"`
+	Rate      *utils.GormDecimal `gorm:"type:decimal(7,4);not null" json:"rate"` // Percentage, e.g. 8.25
+	Category  string             `gorm:"type:varchar(100);index" json:"category,omitempty"`
+	MenuID    *utils.BinaryUUID  `gorm:"type:binary(16);index" json:"menu_id,omitempty"`
+	IsActive  bool               `gorm:"type:boolean;not null;default:true" json:"is_active"`
+	CreatedAt time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the TaxRule entity
+func (TaxRule) TableName() string {
+	return "tax_rules"
+}
+
+// BeforeCreate hook to generate UUID before creating tax rule
+func (t *TaxRule) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == (utils.BinaryUUID{}) {
+		t.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
+
+// OrderTaxLine is the tax charged on an order under one tax rule, as calculated at checkout
+type OrderTaxLine struct {
+	ID            utils.BinaryUUID   `gorm:"type:binary(16);primaryKey" json:"id"`
+	OrderID       utils.BinaryUUID   `gorm:"type:binary(16);not null;index" json:"order_id"`
+	TaxRuleID     *utils.BinaryUUID  `gorm:"type:binary(16)" json:"tax_rule_id,omitempty"`
+	Name          string             `gorm:"type:varchar(100);not null" json:"name"` // Rule name snapshot
+	Rate          *utils.GormDecimal `gorm:"type:decimal(7,4);not null" json:"rate"`
+	TaxableAmount *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"taxable_amount"`
+	Amount        *utils.GormDecimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+	CreatedAt     time.Time          `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the OrderTaxLine entity
+func (OrderTaxLine) TableName() string {
+	return "order_tax_lines"
+}
+
+// BeforeCreate hook to generate UUID before creating order tax line
+func (l *OrderTaxLine) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == (utils.BinaryUUID{}) {
+		l.ID = utils.NewBinaryUUID()
+	}
+	return nil
+}
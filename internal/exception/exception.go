@@ -17,6 +17,10 @@ const (
 	CodeUnauthorized ErrorCode = "UNAUTHORIZED"
 	// CodeForbidden indicates a failure in authorization (e.g., insufficient permissions).
 	CodeForbidden ErrorCode = "FORBIDDEN"
+	// CodeConflict indicates that the request conflicts with the current state of a resource.
+	CodeConflict ErrorCode = "CONFLICT"
+	// CodePriceChanged indicates that prices changed since the customer last saw them.
+	CodePriceChanged ErrorCode = "PRICE_CHANGED"
 	// CodeDatabaseError indicates a problem with the database.
 	CodeDatabaseError ErrorCode = "DATABASE_ERROR"
 	// CodeInternalServerError indicates an unexpected server-side error.
@@ -50,6 +54,8 @@ func (e *AppError) HTTPStatus() int {
 		return http.StatusUnauthorized
 	case CodeForbidden:
 		return http.StatusForbidden
+	case CodeConflict, CodePriceChanged:
+		return http.StatusConflict
 	case CodeDatabaseError:
 		return http.StatusInternalServerError
 	default:
@@ -84,3 +90,21 @@ func NewValidationError(message string, details ...interface{}) *AppError {
 	}
 	return err
 }
+
+// NewConflictError creates a new conflict error.
+func NewConflictError(message string, details ...interface{}) *AppError {
+	err := NewAppError(nil, message, CodeConflict)
+	if len(details) > 0 {
+		err.Details = details
+	}
+	return err
+}
+
+// NewPriceChangedError creates a new error for prices that changed behind the customer's back.
+func NewPriceChangedError(message string, details ...interface{}) *AppError {
+	err := NewAppError(nil, message, CodePriceChanged)
+	if len(details) > 0 {
+		err.Details = details
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"shopify-app/pkg/web_response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client supplied key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses that were replayed from a stored key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// responseRecorder keeps a copy of everything written to the response body
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware replays the stored response when a request is repeated with the same
+// Idempotency-Key. Requests without the header are passed through. Must run after AuthMiddleware
+// or CartOwnerMiddleware.
+func IdempotencyMiddleware(idempotencyService contract.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		// Guests have their own keys, stored under their guest ID
+		userIDValue, exists := c.Get("userID")
+		if !exists {
+			userIDValue, exists = c.Get("guestID")
+		}
+		if !exists {
+			web_response.HandleError(c, web_response.NewUnauthorizedError("user not found in context"))
+			c.Abort()
+			return
+		}
+		userID := userIDValue.(utils.BinaryUUID)
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			web_response.HandleError(c, exception.NewAppError(err, "failed to read request body", exception.CodeValidation))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, appErr := idempotencyService.BeginRequest(c.Request.Context(), userID, key, requestFingerprint(c.Request, body))
+		if appErr != nil {
+			web_response.HandleError(c, appErr)
+			c.Abort()
+			return
+		}
+		if stored != nil {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(stored.ResponseStatus, "application/json; charset=utf-8", stored.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		// The client may already be gone (that is why it retries), so don't tie the bookkeeping to its request
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		// A panicking handler is recovered further up the chain, after this middleware has been
+		// unwound; release the key on the way so a retry isn't refused as in progress until it expires
+		finished := false
+		defer func() {
+			if !finished {
+				releaseKey(ctx, idempotencyService, userID, key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		finished = true
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			// Server errors are not final; let the client retry with the same key
+			releaseKey(ctx, idempotencyService, userID, key)
+			return
+		}
+		if err := idempotencyService.CompleteRequest(ctx, userID, key, recorder.Status(), recorder.body.Bytes()); err != nil {
+			log.Printf("failed to store response for idempotency key %q: %v", key, err)
+			// Without the stored response a retry would be refused as in progress until the key expires
+			releaseKey(ctx, idempotencyService, userID, key)
+		}
+	}
+}
+
+// releaseKey drops a claimed idempotency key, logging when that fails; the key then stays
+// claimed until it expires
+func releaseKey(ctx context.Context, idempotencyService contract.IdempotencyService, userID utils.BinaryUUID, key string) {
+	if err := idempotencyService.ReleaseRequest(ctx, userID, key); err != nil {
+		log.Printf("failed to release idempotency key %q: %v", key, err)
+	}
+}
+
+// requestFingerprint identifies a request by its method, path and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
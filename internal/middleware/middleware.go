@@ -10,6 +10,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// GuestTokenHeader is the request header carrying the signed token of an anonymous visitor's cart
+const GuestTokenHeader = "X-Guest-Token"
+
 func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -19,26 +22,67 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			web_response.HandleError(c, web_response.NewUnauthorizedError("invalid authorization header format"))
+		if !authenticate(c, cfg, authHeader) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// CartOwnerMiddleware lets signed-in users and anonymous visitors in. A request with an
+// Authorization header is authenticated like AuthMiddleware; otherwise it must carry a guest
+// token, whose guest ID is stored as "guestID".
+func CartOwnerMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			if !authenticate(c, cfg, authHeader) {
+				return
+			}
+			c.Next()
+			return
+		}
+
+		guestToken := c.GetHeader(GuestTokenHeader)
+		if guestToken == "" {
+			web_response.HandleError(c, web_response.NewUnauthorizedError("missing authorization header or guest token"))
 			c.Abort()
 			return
 		}
 
-		claims, err := jwt.ValidateToken(parts[1], cfg.JWTSecret)
+		claims, err := jwt.ValidateGuestToken(guestToken, cfg.JWTSecret)
 		if err != nil {
 			web_response.HandleError(c, web_response.NewUnauthorizedError(err.Error()))
 			c.Abort()
 			return
 		}
 
-		c.Set("userID", claims.UserID)
-		c.Set("userRole", claims.Role)
+		c.Set("guestID", claims.GuestID)
 		c.Next()
 	}
 }
 
+// authenticate validates a bearer token and stores the user in the context, aborting the request
+// when the token is not valid
+func authenticate(c *gin.Context, cfg *config.Config, authHeader string) bool {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		web_response.HandleError(c, web_response.NewUnauthorizedError("invalid authorization header format"))
+		c.Abort()
+		return false
+	}
+
+	claims, err := jwt.ValidateToken(parts[1], cfg.JWTSecret)
+	if err != nil {
+		web_response.HandleError(c, web_response.NewUnauthorizedError(err.Error()))
+		c.Abort()
+		return false
+	}
+
+	c.Set("userID", claims.UserID)
+	c.Set("userRole", claims.Role)
+	return true
+}
+
 func RoleMiddleware(requiredRole entities.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("userRole")
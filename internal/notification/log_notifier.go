@@ -0,0 +1,23 @@
+package notification
+
+import (
+	"context"
+	"log"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+)
+
+// logNotifier is the built-in cart reminder notifier. It only writes the reminders to the log, so
+// the reminder flow can be followed without an email or push service; replace it with a notifier
+// for a real channel in production.
+type logNotifier struct{}
+
+// NewLogNotifier creates the built-in notifier that logs cart reminders
+func NewLogNotifier() contract.CartReminderNotifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) RemindAbandonedCart(ctx context.Context, user *entities.User, cart *entities.AbandonedCart) error {
+	log.Printf("cart reminder for %s: %d items worth %s left in cart %s", user.Email, cart.ItemCount, cart.Value, cart.CartID)
+	return nil
+}
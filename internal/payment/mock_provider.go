@@ -0,0 +1,81 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+)
+
+const (
+	// MockProviderName is the name of the built-in mock provider
+	MockProviderName = "mock"
+	// MockSignatureHeader carries the HMAC-SHA256 of the webhook body, hex encoded
+	MockSignatureHeader = "X-Mock-Signature"
+)
+
+// mockWebhookPayload is the body of a mock provider webhook
+type mockWebhookPayload struct {
+	Reference     string                 `json:"reference"`
+	Status        entities.PaymentStatus `json:"status"`
+	FailureReason string                 `json:"failure_reason"`
+}
+
+// mockProvider is a local payment provider that accepts every payment. Payments are completed by
+// posting a signed webhook, so the whole flow can be exercised without an external service.
+type mockProvider struct {
+	webhookSecret []byte
+}
+
+// NewMockProvider creates the built-in mock payment provider
+func NewMockProvider(webhookSecret string) contract.PaymentProvider {
+	return &mockProvider{webhookSecret: []byte(webhookSecret)}
+}
+
+func (p *mockProvider) Name() string {
+	return MockProviderName
+}
+
+func (p *mockProvider) CreateIntent(ctx context.Context, payment *entities.Payment) (*contract.PaymentIntent, error) {
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	reference := "mock_pi_" + payment.ID.String()
+	return &contract.PaymentIntent{
+		Reference:    reference,
+		ClientSecret: reference + "_secret_" + hex.EncodeToString(secret),
+	}, nil
+}
+
+func (p *mockProvider) Refund(ctx context.Context, payment *entities.Payment, refund *entities.Refund) (string, error) {
+	return "mock_re_" + refund.ID.String(), nil
+}
+
+func (p *mockProvider) ParseWebhook(payload []byte, header http.Header) (*contract.PaymentWebhookEvent, error) {
+	if !VerifySignature(p.webhookSecret, payload, header.Get(MockSignatureHeader)) {
+		return nil, contract.ErrInvalidWebhookSignature
+	}
+
+	var body mockWebhookPayload
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if body.Reference == "" {
+		return nil, errors.New("invalid webhook payload: reference is required")
+	}
+	if body.Status != entities.PaymentSucceeded && body.Status != entities.PaymentFailed {
+		return nil, fmt.Errorf("invalid webhook payload: unsupported status '%s'", body.Status)
+	}
+
+	return &contract.PaymentWebhookEvent{
+		Reference:     body.Reference,
+		Status:        body.Status,
+		FailureReason: body.FailureReason,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// addressRepository implements the contract.AddressRepository interface
+type addressRepository struct {
+	db *gorm.DB
+}
+
+// NewAddressRepository creates a new instance of the address repository
+func NewAddressRepository(db *gorm.DB) contract.AddressRepository {
+	return &addressRepository{db: db}
+}
+
+// CreateAddress creates a new address
+func (r *addressRepository) CreateAddress(ctx context.Context, address *entities.Address) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Create(address).Error; err != nil {
+		return exception.NewAppError(err, "failed to create address")
+	}
+	return nil
+}
+
+// GetAddressByID retrieves an address of a user by its ID
+func (r *addressRepository) GetAddressByID(ctx context.Context, userID, addressID utils.BinaryUUID) (*entities.Address, *exception.AppError) {
+	var address entities.Address
+	if err := dbFromContext(ctx, r.db).First(&address, "id = ? AND user_id = ?", addressID, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, exception.NewAppError(err, "address not found", exception.CodeNotFound)
+		}
+		return nil, exception.NewAppError(err, "failed to get address")
+	}
+	return &address, nil
+}
+
+// GetAddressesByUserID retrieves the address book of a user, default address first
+func (r *addressRepository) GetAddressesByUserID(ctx context.Context, userID utils.BinaryUUID) ([]entities.Address, *exception.AppError) {
+	var addresses []entities.Address
+	if err := dbFromContext(ctx, r.db).Where("user_id = ?", userID).
+		Order("is_default DESC, created_at DESC").Find(&addresses).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to get addresses")
+	}
+	return addresses, nil
+}
+
+// GetDefaultAddress retrieves the default address of a user, or nil when there is none
+func (r *addressRepository) GetDefaultAddress(ctx context.Context, userID utils.BinaryUUID) (*entities.Address, *exception.AppError) {
+	var address entities.Address
+	if err := dbFromContext(ctx, r.db).First(&address, "user_id = ? AND is_default = ?", userID, true).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, exception.NewAppError(err, "failed to get default address")
+	}
+	return &address, nil
+}
+
+// UpdateAddress updates an existing address
+func (r *addressRepository) UpdateAddress(ctx context.Context, address *entities.Address) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Save(address).Error; err != nil {
+		return exception.NewAppError(err, "failed to update address")
+	}
+	return nil
+}
+
+// DeleteAddress deletes an address of a user
+func (r *addressRepository) DeleteAddress(ctx context.Context, userID, addressID utils.BinaryUUID) *exception.AppError {
+	result := dbFromContext(ctx, r.db).Delete(&entities.Address{}, "id = ? AND user_id = ?", addressID, userID)
+	if result.Error != nil {
+		return exception.NewAppError(result.Error, "failed to delete address")
+	}
+	if result.RowsAffected == 0 {
+		return exception.NewAppError(nil, "address not found", exception.CodeNotFound)
+	}
+	return nil
+}
+
+// SetDefaultAddress makes an address the only default address of its user
+func (r *addressRepository) SetDefaultAddress(ctx context.Context, userID, addressID utils.BinaryUUID) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Model(&entities.Address{}).Where("user_id = ?", userID).
+		Update("is_default", gorm.Expr("id = ?", addressID)).Error; err != nil {
+		return exception.NewAppError(err, "failed to set default address")
+	}
+	return nil
+}
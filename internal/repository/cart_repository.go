@@ -8,6 +8,7 @@ import (
 	"shopify-app/internal/entities"
 	"shopify-app/internal/exception"
 	"shopify-app/internal/utils"
+	"time"
 )
 
 // cartRepository implements the contract.CartRepository interface
@@ -20,52 +21,88 @@ func NewCartRepository(db *gorm.DB) contract.CartRepository {
 	return &cartRepository{db: db}
 }
 
-// GetOrCreateCart retrieves or creates a cart for a user
-func (r *cartRepository) GetOrCreateCart(ctx context.Context, userID utils.BinaryUUID) (*entities.Cart, *exception.AppError) {
+// ownedBy scopes a cart query to the cart of a user or guest
+func ownedBy(owner contract.CartOwner) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if owner.IsGuest() {
+			return db.Where("carts.guest_id = ?", owner.GuestID)
+		}
+		return db.Where("carts.user_id = ?", owner.UserID)
+	}
+}
+
+// GetOrCreateCart retrieves or creates the cart of a user or guest
+func (r *cartRepository) GetOrCreateCart(ctx context.Context, owner contract.CartOwner) (*entities.Cart, *exception.AppError) {
 	var cart entities.Cart
-	if err := r.db.WithContext(ctx).Where(entities.Cart{UserID: userID}).FirstOrCreate(&cart).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Where(entities.Cart{UserID: owner.UserID, GuestID: owner.GuestID}).FirstOrCreate(&cart).Error; err != nil {
 		return nil, exception.NewAppError(err, "failed to get or create cart")
 	}
 	return &cart, nil
 }
 
-// GetCartWithItems retrieves a cart with all its items for a user
-func (r *cartRepository) GetCartWithItems(ctx context.Context, userID utils.BinaryUUID) (*entities.Cart, *exception.AppError) {
+// GetCartWithItems retrieves the cart of a user or guest with all its items
+func (r *cartRepository) GetCartWithItems(ctx context.Context, owner contract.CartOwner) (*entities.Cart, *exception.AppError) {
+	cart, err := r.FindCartWithItems(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		// If no cart, create one and return it (it will be empty)
+		return r.GetOrCreateCart(ctx, owner)
+	}
+	return cart, nil
+}
+
+// FindCartWithItems retrieves the cart of a user or guest with all its items, or nil if there is none
+func (r *cartRepository) FindCartWithItems(ctx context.Context, owner contract.CartOwner) (*entities.Cart, *exception.AppError) {
 	var cart entities.Cart
-	err := r.db.WithContext(ctx).
+	err := dbFromContext(ctx, r.db).
 		Preload("CartItems").
-		Preload("CartItems.Menu").
-		Where("user_id = ?", userID).
+		Preload("CartItems.Options").
+		// Deleted menu items too, so the cart can tell the customer what was taken off the menu
+		Preload("CartItems.Menu", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("CartItems.Menu.OptionGroups.Options").
+		Scopes(ownedBy(owner)).
 		First(&cart).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// If no cart, create one and return it (it will be empty)
-			return r.GetOrCreateCart(ctx, userID)
+			return nil, nil
 		}
 		return nil, exception.NewAppError(err, "failed to get cart with items")
 	}
 	return &cart, nil
 }
 
-// AddItemToCart adds an item to the cart or updates quantity if it exists
-func (r *cartRepository) AddItemToCart(ctx context.Context, cartID, menuID utils.BinaryUUID, quantity int, price *utils.GormDecimal) *exception.AppError {
-	// Check if the item already exists in the cart
+// DeleteCart removes a cart; its items and their options go with it through the foreign keys
+func (r *cartRepository) DeleteCart(ctx context.Context, cartID utils.BinaryUUID) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Delete(&entities.Cart{}, "id = ?", cartID).Error; err != nil {
+		return exception.NewAppError(err, "failed to delete cart")
+	}
+	return nil
+}
+
+// AddItemToCart adds an item to the cart or updates quantity if the same item with the same options exists
+func (r *cartRepository) AddItemToCart(ctx context.Context, cartID, menuID utils.BinaryUUID, quantity int, price *utils.GormDecimal, options []entities.CartItemOption) *exception.AppError {
+	newItem := entities.CartItem{
+		CartID:   cartID,
+		MenuID:   menuID,
+		Quantity: quantity,
+		Price:    price,
+		Options:  options,
+	}
+	newItem.OptionsKey = entities.OptionsKey(newItem.OptionIDs())
+
+	// Check if the item already exists in the cart with the same options
 	var existingItem entities.CartItem
-	err := r.db.WithContext(ctx).
-		Where("cart_id = ? AND menu_id = ?", cartID, menuID).
+	err := dbFromContext(ctx, r.db).
+		Where("cart_id = ? AND menu_id = ? AND options_key = ?", cartID, menuID, newItem.OptionsKey).
 		First(&existingItem).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// Item does not exist, create a new one
-			newItem := entities.CartItem{
-				CartID:   cartID,
-				MenuID:   menuID,
-				Quantity: quantity,
-				Price:    price,
-			}
-			if createErr := r.db.WithContext(ctx).Create(&newItem).Error; createErr != nil {
+			if createErr := dbFromContext(ctx, r.db).Create(&newItem).Error; createErr != nil {
 				return exception.NewAppError(createErr, "failed to add new item to cart")
 			}
 			return nil
@@ -76,7 +113,7 @@ func (r *cartRepository) AddItemToCart(ctx context.Context, cartID, menuID utils
 
 	// Item exists, update its quantity
 	newQuantity := existingItem.Quantity + quantity
-	if updateErr := r.db.WithContext(ctx).Model(&existingItem).Update("quantity", newQuantity).Error; updateErr != nil {
+	if updateErr := dbFromContext(ctx, r.db).Model(&existingItem).Update("quantity", newQuantity).Error; updateErr != nil {
 		return exception.NewAppError(updateErr, "failed to update item quantity in cart")
 	}
 
@@ -85,39 +122,144 @@ func (r *cartRepository) AddItemToCart(ctx context.Context, cartID, menuID utils
 
 // UpdateCartItemQuantity updates the quantity of a specific cart item
 func (r *cartRepository) UpdateCartItemQuantity(ctx context.Context, cartItemID utils.BinaryUUID, quantity int) *exception.AppError {
-	if err := r.db.WithContext(ctx).Model(&entities.CartItem{}).Where("id = ?", cartItemID).Update("quantity", quantity).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&entities.CartItem{}).Where("id = ?", cartItemID).Update("quantity", quantity).Error; err != nil {
 		return exception.NewAppError(err, "failed to update cart item quantity")
 	}
 	return nil
 }
 
+// UpdateCartItemPrice updates the unit price snapshot of a specific cart item
+func (r *cartRepository) UpdateCartItemPrice(ctx context.Context, cartItemID utils.BinaryUUID, price *utils.GormDecimal) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Model(&entities.CartItem{}).Where("id = ?", cartItemID).Update("price", price).Error; err != nil {
+		return exception.NewAppError(err, "failed to update cart item price")
+	}
+	return nil
+}
+
 // RemoveCartItem removes a specific item from the cart
 func (r *cartRepository) RemoveCartItem(ctx context.Context, cartItemID utils.BinaryUUID) *exception.AppError {
-	if err := r.db.WithContext(ctx).Delete(&entities.CartItem{}, "id = ?", cartItemID).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Delete(&entities.CartItem{}, "id = ?", cartItemID).Error; err != nil {
 		return exception.NewAppError(err, "failed to remove cart item")
 	}
 	return nil
 }
 
-// ClearCart removes all items from a user's cart
-func (r *cartRepository) ClearCart(ctx context.Context, userID utils.BinaryUUID) *exception.AppError {
+// ClearCart removes all items from the cart of a user or guest
+func (r *cartRepository) ClearCart(ctx context.Context, owner contract.CartOwner) *exception.AppError {
 	// First, get the cart ID for the user
-	cart, err := r.GetOrCreateCart(ctx, userID)
+	cart, err := r.GetOrCreateCart(ctx, owner)
 	if err != nil {
 		return err
 	}
 
 	// Delete all items associated with that cart ID
-	if deleteErr := r.db.WithContext(ctx).Where("cart_id = ?", cart.ID).Delete(&entities.CartItem{}).Error; deleteErr != nil {
+	if deleteErr := dbFromContext(ctx, r.db).Where("cart_id = ?", cart.ID).Delete(&entities.CartItem{}).Error; deleteErr != nil {
 		return exception.NewAppError(deleteErr, "failed to clear cart")
 	}
+	// A coupon is entered for one order only, and an empty cart is no longer abandoned
+	if updateErr := dbFromContext(ctx, r.db).Model(&entities.Cart{}).Where("id = ?", cart.ID).
+		Updates(map[string]interface{}{"coupon_code": "", "abandoned_at": nil}).Error; updateErr != nil {
+		return exception.NewAppError(updateErr, "failed to clear cart")
+	}
+	return nil
+}
+
+// SetCartCouponCode stores the coupon code entered for a cart
+func (r *cartRepository) SetCartCouponCode(ctx context.Context, cartID utils.BinaryUUID, code string) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Model(&entities.Cart{}).Where("id = ?", cartID).Update("coupon_code", code).Error; err != nil {
+		return exception.NewAppError(err, "failed to update cart coupon code")
+	}
+	return nil
+}
+
+// DeleteStaleCarts removes the carts where neither the cart nor any of its items changed since the
+// given time. Items and their options go with the cart through the foreign keys.
+func (r *cartRepository) DeleteStaleCarts(ctx context.Context, inactiveSince time.Time) (int64, *exception.AppError) {
+	result := dbFromContext(ctx, r.db).
+		Where("updated_at < ?", inactiveSince).
+		Where("NOT EXISTS (SELECT 1 FROM cart_items WHERE cart_items.cart_id = carts.id AND cart_items.updated_at >= ?)", inactiveSince).
+		Delete(&entities.Cart{})
+	if result.Error != nil {
+		return 0, exception.NewAppError(result.Error, "failed to delete stale carts")
+	}
+	return result.RowsAffected, nil
+}
+
+// TouchCart records activity by the customer on the cart of a user or guest
+func (r *cartRepository) TouchCart(ctx context.Context, owner contract.CartOwner, at time.Time) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Model(&entities.Cart{}).Scopes(ownedBy(owner)).Update("last_activity_at", at).Error; err != nil {
+		return exception.NewAppError(err, "failed to record cart activity")
+	}
+	return nil
+}
+
+// FindIdleCarts retrieves up to limit carts with items that have seen no activity since the given
+// time and were not found abandoned since their last activity, longest idle first. Carts from
+// before activity was recorded go by their last update.
+func (r *cartRepository) FindIdleCarts(ctx context.Context, idleSince time.Time, limit int) ([]entities.Cart, *exception.AppError) {
+	var carts []entities.Cart
+	err := dbFromContext(ctx, r.db).
+		Preload("CartItems").
+		Preload("CartItems.Menu", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("User").
+		Where("carts.last_activity_at < ? OR (carts.last_activity_at IS NULL AND carts.updated_at < ?)", idleSince, idleSince).
+		Where("carts.abandoned_at IS NULL OR carts.abandoned_at < carts.last_activity_at").
+		Where("EXISTS (SELECT 1 FROM cart_items WHERE cart_items.cart_id = carts.id)").
+		Order("carts.last_activity_at ASC").
+		Limit(limit).
+		Find(&carts).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get idle carts")
+	}
+	return carts, nil
+}
+
+// MarkCartAbandoned flags a cart as abandoned and stores the snapshot of it. The flag is set
+// without touching updated_at, so being found abandoned doesn't keep a cart from being purged.
+func (r *cartRepository) MarkCartAbandoned(ctx context.Context, abandoned *entities.AbandonedCart) (bool, *exception.AppError) {
+	result := dbFromContext(ctx, r.db).Model(&entities.Cart{}).
+		Where("id = ?", abandoned.CartID).
+		Where("last_activity_at IS NULL OR last_activity_at <= ?", abandoned.LastActivityAt).
+		Where("abandoned_at IS NULL OR abandoned_at < last_activity_at").
+		UpdateColumn("abandoned_at", abandoned.AbandonedAt)
+	if result.Error != nil {
+		return false, exception.NewAppError(result.Error, "failed to mark cart as abandoned")
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := dbFromContext(ctx, r.db).Create(abandoned).Error; err != nil {
+		return false, exception.NewAppError(err, "failed to record abandoned cart")
+	}
+	return true, nil
+}
+
+// SetAbandonedCartReminded records when the customer was reminded of an abandoned cart
+func (r *cartRepository) SetAbandonedCartReminded(ctx context.Context, abandonedCartID utils.BinaryUUID, at time.Time) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Model(&entities.AbandonedCart{}).Where("id = ?", abandonedCartID).Update("reminder_sent_at", at).Error; err != nil {
+		return exception.NewAppError(err, "failed to record abandoned cart reminder")
+	}
+	return nil
+}
+
+// RecoverAbandonedCart records that the latest abandonment of a cart was recovered by an order
+func (r *cartRepository) RecoverAbandonedCart(ctx context.Context, cartID, orderID utils.BinaryUUID, at time.Time) *exception.AppError {
+	err := dbFromContext(ctx, r.db).Model(&entities.AbandonedCart{}).
+		Where("cart_id = ? AND recovered_at IS NULL", cartID).
+		Order("abandoned_at DESC").
+		Limit(1).
+		Updates(map[string]interface{}{"recovered_at": at, "order_id": orderID}).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to record recovered cart")
+	}
 	return nil
 }
 
 // GetCartItem retrieves a specific cart item
 func (r *cartRepository) GetCartItem(ctx context.Context, cartItemID utils.BinaryUUID) (*entities.CartItem, *exception.AppError) {
 	var item entities.CartItem
-	if err := r.db.WithContext(ctx).First(&item, "id = ?", cartItemID).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Preload("Options").First(&item, "id = ?", cartItemID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, exception.NewAppError(err, "cart item not found")
 		}
@@ -129,7 +271,7 @@ func (r *cartRepository) GetCartItem(ctx context.Context, cartItemID utils.Binar
 // GetCartItemByMenuID retrieves a cart item by cart ID and menu ID
 func (r *cartRepository) GetCartItemByMenuID(ctx context.Context, cartID, menuID utils.BinaryUUID) (*entities.CartItem, *exception.AppError) {
 	var item entities.CartItem
-	err := r.db.WithContext(ctx).
+	err := dbFromContext(ctx, r.db).
 		Where("cart_id = ? AND menu_id = ?", cartID, menuID).
 		First(&item).Error
 
@@ -145,25 +287,25 @@ func (r *cartRepository) GetCartItemByMenuID(ctx context.Context, cartID, menuID
 // GetCartTotal calculates the total amount for a cart
 func (r *cartRepository) GetCartTotal(ctx context.Context, cartID utils.BinaryUUID) (*utils.GormDecimal, *exception.AppError) {
 	var items []entities.CartItem
-	if err := r.db.WithContext(ctx).Where("cart_id = ?", cartID).Find(&items).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Where("cart_id = ?", cartID).Find(&items).Error; err != nil {
 		return nil, exception.NewAppError(err, "failed to get cart items for total calculation")
 	}
 
-	total := utils.MustNewGormDecimal("0")
+	total := utils.NewMoney(0, 2)
 	for _, item := range items {
-		subtotal := item.GetSubtotal()
-		total = utils.MustGormDecimalAdd(*total, *subtotal)
+		total = total.Add(item.GetSubtotal())
 	}
 
 	return total, nil
 }
 
-// ValidateCartOwnership validates that a cart item belongs to a specific user
-func (r *cartRepository) ValidateCartOwnership(ctx context.Context, cartItemID, userID utils.BinaryUUID) (bool, *exception.AppError) {
+// ValidateCartOwnership validates that a cart item belongs to the cart of a user or guest
+func (r *cartRepository) ValidateCartOwnership(ctx context.Context, cartItemID utils.BinaryUUID, owner contract.CartOwner) (bool, *exception.AppError) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&entities.CartItem{}).
+	err := dbFromContext(ctx, r.db).Model(&entities.CartItem{}).
 		Joins("JOIN carts ON carts.id = cart_items.cart_id").
-		Where("cart_items.id = ? AND carts.user_id = ?", cartItemID, userID).
+		Where("cart_items.id = ?", cartItemID).
+		Scopes(ownedBy(owner)).
 		Count(&count).Error
 
 	if err != nil {
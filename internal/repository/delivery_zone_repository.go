@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// deliveryZoneRepository implements the contract.DeliveryZoneRepository interface
+type deliveryZoneRepository struct {
+	db *gorm.DB
+}
+
+// NewDeliveryZoneRepository creates a new instance of the delivery zone repository
+func NewDeliveryZoneRepository(db *gorm.DB) contract.DeliveryZoneRepository {
+	return &deliveryZoneRepository{db: db}
+}
+
+// preloadFeeTiers loads the fee tiers of the zones, nearest first
+func preloadFeeTiers(db *gorm.DB) *gorm.DB {
+	return db.Preload("FeeTiers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("up_to_meters ASC")
+	})
+}
+
+// CreateDeliveryZone creates a new delivery zone with its fee tiers
+func (r *deliveryZoneRepository) CreateDeliveryZone(ctx context.Context, zone *entities.DeliveryZone) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Create(zone).Error; err != nil {
+		return exception.NewAppError(err, "failed to create delivery zone")
+	}
+	return nil
+}
+
+// GetDeliveryZoneByID retrieves a delivery zone with its fee tiers, including deleted zones
+func (r *deliveryZoneRepository) GetDeliveryZoneByID(ctx context.Context, id utils.BinaryUUID) (*entities.DeliveryZone, *exception.AppError) {
+	var zone entities.DeliveryZone
+	if err := preloadFeeTiers(dbFromContext(ctx, r.db).Unscoped()).First(&zone, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, exception.NewAppError(err, "delivery zone not found", exception.CodeNotFound)
+		}
+		return nil, exception.NewAppError(err, "failed to get delivery zone")
+	}
+	return &zone, nil
+}
+
+// GetAllDeliveryZones retrieves all delivery zones with their fee tiers
+func (r *deliveryZoneRepository) GetAllDeliveryZones(ctx context.Context) ([]entities.DeliveryZone, *exception.AppError) {
+	var zones []entities.DeliveryZone
+	if err := preloadFeeTiers(dbFromContext(ctx, r.db)).Order("priority ASC, name ASC").Find(&zones).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to get delivery zones")
+	}
+	return zones, nil
+}
+
+// GetActiveDeliveryZones retrieves the active delivery zones with their fee tiers, by priority
+func (r *deliveryZoneRepository) GetActiveDeliveryZones(ctx context.Context) ([]entities.DeliveryZone, *exception.AppError) {
+	var zones []entities.DeliveryZone
+	if err := preloadFeeTiers(dbFromContext(ctx, r.db)).Where("is_active = ?", true).
+		Order("priority ASC, name ASC, id ASC").Find(&zones).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to get active delivery zones")
+	}
+	return zones, nil
+}
+
+// UpdateDeliveryZone updates a delivery zone and replaces its fee tiers
+func (r *deliveryZoneRepository) UpdateDeliveryZone(ctx context.Context, zone *entities.DeliveryZone) *exception.AppError {
+	db := dbFromContext(ctx, r.db)
+	if err := db.Where("zone_id = ?", zone.ID).Delete(&entities.DeliveryFeeTier{}).Error; err != nil {
+		return exception.NewAppError(err, "failed to replace delivery fee tiers")
+	}
+	for i := range zone.FeeTiers {
+		zone.FeeTiers[i].ID = utils.BinaryUUID{}
+		zone.FeeTiers[i].ZoneID = zone.ID
+	}
+	if err := db.Session(&gorm.Session{FullSaveAssociations: true}).Save(zone).Error; err != nil {
+		return exception.NewAppError(err, "failed to update delivery zone")
+	}
+	return nil
+}
+
+// DeleteDeliveryZone soft deletes a delivery zone
+func (r *deliveryZoneRepository) DeleteDeliveryZone(ctx context.Context, id utils.BinaryUUID) *exception.AppError {
+	result := dbFromContext(ctx, r.db).Delete(&entities.DeliveryZone{}, "id = ?", id)
+	if result.Error != nil {
+		return exception.NewAppError(result.Error, "failed to delete delivery zone")
+	}
+	if result.RowsAffected == 0 {
+		return exception.NewAppError(nil, "delivery zone not found", exception.CodeNotFound)
+	}
+	return nil
+}
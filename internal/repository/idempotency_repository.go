@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// idempotencyRepository implements the contract.IdempotencyRepository interface
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository creates a new instance of the idempotency repository
+func NewIdempotencyRepository(db *gorm.DB) contract.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// CreateKeyIfAbsent stores a new key unless the user already has one with the same value
+func (r *idempotencyRepository) CreateKeyIfAbsent(ctx context.Context, key *entities.IdempotencyKey) (bool, *exception.AppError) {
+	result := dbFromContext(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return false, exception.NewAppError(result.Error, "failed to store idempotency key")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetKey retrieves a user's key
+func (r *idempotencyRepository) GetKey(ctx context.Context, userID utils.BinaryUUID, key string) (*entities.IdempotencyKey, *exception.AppError) {
+	var record entities.IdempotencyKey
+	err := dbFromContext(ctx, r.db).
+		Where("user_id = ? AND idempotency_key = ?", userID, key).
+		First(&record).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil if not found, as it's a check, not an error
+		}
+		return nil, exception.NewAppError(err, "failed to get idempotency key")
+	}
+	return &record, nil
+}
+
+// CompleteKey stores the response that was sent for a key
+func (r *idempotencyRepository) CompleteKey(ctx context.Context, id utils.BinaryUUID, responseStatus int, responseBody []byte) *exception.AppError {
+	err := dbFromContext(ctx, r.db).Model(&entities.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          entities.IdempotencyCompleted,
+		"response_status": responseStatus,
+		"response_body":   responseBody,
+	}).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to complete idempotency key")
+	}
+	return nil
+}
+
+// DeleteKey removes a key
+func (r *idempotencyRepository) DeleteKey(ctx context.Context, id utils.BinaryUUID) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Delete(&entities.IdempotencyKey{}, "id = ?", id).Error; err != nil {
+		return exception.NewAppError(err, "failed to delete idempotency key")
+	}
+	return nil
+}
+
+// DeleteExpiredKeys removes all keys that expired before the given time
+func (r *idempotencyRepository) DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, *exception.AppError) {
+	result := dbFromContext(ctx, r.db).Where("expires_at < ?", before).Delete(&entities.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, exception.NewAppError(result.Error, "failed to delete expired idempotency keys")
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"time"
+)
+
+// jobRepository implements the contract.JobRepository interface
+type jobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository creates a new instance of the job repository
+func NewJobRepository(db *gorm.DB) contract.JobRepository {
+	return &jobRepository{db: db}
+}
+
+// RegisterJob stores a job or updates its schedule
+func (r *jobRepository) RegisterJob(ctx context.Context, name, schedule string) *exception.AppError {
+	job := &entities.ScheduledJob{Name: name, Schedule: schedule}
+	err := dbFromContext(ctx, r.db).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "name"}},
+		// MySQL assigns left to right, so the next run is compared against the old schedule first.
+		// A changed schedule drops the next run planned under the old one.
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "next_run_at"}, Value: gorm.Expr("IF(schedule = VALUES(schedule), next_run_at, NULL)")},
+			{Column: clause.Column{Name: "schedule"}, Value: gorm.Expr("VALUES(schedule)")},
+		},
+	}).Create(job).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to register job")
+	}
+	return nil
+}
+
+// ClaimJob locks a job unless it is locked by another instance or not due yet. The check and the
+// update are a single statement, so two instances can never both claim the same run.
+func (r *jobRepository) ClaimJob(ctx context.Context, name, owner string, now, lockedUntil, nextRunAt time.Time) (bool, *exception.AppError) {
+	result := dbFromContext(ctx, r.db).Model(&entities.ScheduledJob{}).
+		Where("name = ?", name).
+		Where("locked_by = '' OR locked_until IS NULL OR locked_until < ?", now).
+		Where("next_run_at IS NULL OR next_run_at <= ?", now).
+		Updates(map[string]interface{}{
+			"locked_by":       owner,
+			"locked_until":    lockedUntil,
+			"last_started_at": now,
+			"next_run_at":     nextRunAt,
+		})
+	if result.Error != nil {
+		return false, exception.NewAppError(result.Error, "failed to claim job")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FinishJob records the outcome of a run and releases the lock
+func (r *jobRepository) FinishJob(ctx context.Context, name, owner string, finishedAt time.Time, duration time.Duration, runErr string) *exception.AppError {
+	failed := 0
+	if runErr != "" {
+		failed = 1
+	}
+	err := dbFromContext(ctx, r.db).Model(&entities.ScheduledJob{}).
+		Where("name = ? AND locked_by = ?", name, owner).
+		Updates(map[string]interface{}{
+			"locked_by":        "",
+			"locked_until":     nil,
+			"last_finished_at": finishedAt,
+			"last_duration_ms": duration.Milliseconds(),
+			"last_error":       runErr,
+			"run_count":        gorm.Expr("run_count + 1"),
+			"failure_count":    gorm.Expr("failure_count + ?", failed),
+		}).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to record job run")
+	}
+	return nil
+}
+
+// GetJobs retrieves the state of all jobs ordered by name
+func (r *jobRepository) GetJobs(ctx context.Context) ([]entities.ScheduledJob, *exception.AppError) {
+	var jobs []entities.ScheduledJob
+	if err := dbFromContext(ctx, r.db).Order("name ASC").Find(&jobs).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to get jobs")
+	}
+	return jobs, nil
+}
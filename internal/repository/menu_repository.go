@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"shopify-app/internal/contract"
 	"shopify-app/internal/entities"
 	"shopify-app/internal/exception"
@@ -22,7 +23,7 @@ func NewMenuRepository(db *gorm.DB) contract.MenuRepository {
 
 // CreateMenu creates a new menu item in the database
 func (r *menuRepository) CreateMenu(ctx context.Context, menu *entities.Menu) *exception.AppError {
-	if err := r.db.WithContext(ctx).Create(menu).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Create(menu).Error; err != nil {
 		return exception.NewAppError(err, "failed to create menu")
 	}
 	return nil
@@ -31,9 +32,9 @@ func (r *menuRepository) CreateMenu(ctx context.Context, menu *entities.Menu) *e
 // GetMenuByID retrieves a menu item by its ID
 func (r *menuRepository) GetMenuByID(ctx context.Context, id utils.BinaryUUID) (*entities.Menu, *exception.AppError) {
 	var menu entities.Menu
-	if err := r.db.WithContext(ctx).First(&menu, "id = ?", id).Error; err != nil {
+	if err := withOptionGroups(dbFromContext(ctx, r.db)).First(&menu, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, exception.NewAppError(err, "menu not found")
+			return nil, exception.NewAppError(err, "menu not found", exception.CodeNotFound)
 		}
 		return nil, exception.NewAppError(err, "failed to get menu by id")
 	}
@@ -45,7 +46,7 @@ func (r *menuRepository) GetAllMenus(ctx context.Context, offset, limit int, sea
 	var menus []entities.Menu
 	var count int64
 
-	query := r.db.WithContext(ctx).Model(&entities.Menu{})
+	query := dbFromContext(ctx, r.db).Model(&entities.Menu{})
 
 	if search != "" {
 		query = query.Where("name LIKE ? OR description LIKE ?", "%"+search+"%", "%"+search+"%")
@@ -61,16 +62,16 @@ func (r *menuRepository) GetAllMenus(ctx context.Context, offset, limit int, sea
 		return nil, 0, exception.NewAppError(err, "failed to count menus")
 	}
 
-	if err := query.Offset(offset).Limit(limit).Find(&menus).Error; err != nil {
+	if err := withOptionGroups(query).Offset(offset).Limit(limit).Find(&menus).Error; err != nil {
 		return nil, 0, exception.NewAppError(err, "failed to get all menus")
 	}
 
 	return menus, count, nil
 }
 
-// UpdateMenu updates an existing menu item
+// UpdateMenu updates an existing menu item; loaded option groups are not written back
 func (r *menuRepository) UpdateMenu(ctx context.Context, menu *entities.Menu) *exception.AppError {
-	if err := r.db.WithContext(ctx).Save(menu).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Omit(clause.Associations).Save(menu).Error; err != nil {
 		return exception.NewAppError(err, "failed to update menu")
 	}
 	return nil
@@ -78,7 +79,7 @@ func (r *menuRepository) UpdateMenu(ctx context.Context, menu *entities.Menu) *e
 
 // DeleteMenu soft deletes a menu item
 func (r *menuRepository) DeleteMenu(ctx context.Context, id utils.BinaryUUID) *exception.AppError {
-	if err := r.db.WithContext(ctx).Delete(&entities.Menu{}, "id = ?", id).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Delete(&entities.Menu{}, "id = ?", id).Error; err != nil {
 		return exception.NewAppError(err, "failed to delete menu")
 	}
 	return nil
@@ -86,35 +87,153 @@ func (r *menuRepository) DeleteMenu(ctx context.Context, id utils.BinaryUUID) *e
 
 // UpdateMenuStock updates the stock quantity of a menu item
 func (r *menuRepository) UpdateMenuStock(ctx context.Context, id utils.BinaryUUID, newStock int) *exception.AppError {
-	if err := r.db.WithContext(ctx).Model(&entities.Menu{}).Where("id = ?", id).Update("stock", newStock).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&entities.Menu{}).Where("id = ?", id).Update("stock", newStock).Error; err != nil {
 		return exception.NewAppError(err, "failed to update menu stock")
 	}
 	return nil
 }
 
-// ReduceMenuStock reduces the stock quantity of a menu item
+// ReduceMenuStock reduces the stock quantity of a menu item, refusing to go below zero
 func (r *menuRepository) ReduceMenuStock(ctx context.Context, id utils.BinaryUUID, quantity int) *exception.AppError {
-	if err := r.db.WithContext(ctx).Model(&entities.Menu{}).Where("id = ?", id).Update("stock", gorm.Expr("stock - ?", quantity)).Error; err != nil {
-		return exception.NewAppError(err, "failed to reduce menu stock")
+	result := dbFromContext(ctx, r.db).Model(&entities.Menu{}).
+		Where("id = ? AND stock >= ?", id, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return exception.NewAppError(result.Error, "failed to reduce menu stock")
+	}
+	if result.RowsAffected == 0 {
+		return exception.NewConflictError("insufficient stock for menu item", id.String())
+	}
+	return nil
+}
+
+// IncreaseMenuStock adds quantity back to the stock of a menu item, including soft-deleted ones
+func (r *menuRepository) IncreaseMenuStock(ctx context.Context, id utils.BinaryUUID, quantity int) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Unscoped().Model(&entities.Menu{}).Where("id = ?", id).Update("stock", gorm.Expr("stock + ?", quantity)).Error; err != nil {
+		return exception.NewAppError(err, "failed to increase menu stock")
 	}
 	return nil
 }
 
+// LockMenusByIDs retrieves menu items by their IDs and locks their rows until the transaction ends
+func (r *menuRepository) LockMenusByIDs(ctx context.Context, ids []utils.BinaryUUID) ([]entities.Menu, *exception.AppError) {
+	var menus []entities.Menu
+	// Lock in primary key order so concurrent checkouts never deadlock on each other
+	err := dbFromContext(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id ASC").
+		Find(&menus).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to lock menus")
+	}
+	return menus, nil
+}
+
 // GetMenusByIDs retrieves multiple menu items by their IDs
 func (r *menuRepository) GetMenusByIDs(ctx context.Context, ids []utils.BinaryUUID) ([]entities.Menu, *exception.AppError) {
 	var menus []entities.Menu
-	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&menus).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Where("id IN ?", ids).Find(&menus).Error; err != nil {
 		return nil, exception.NewAppError(err, "failed to get menus by ids")
 	}
 	return menus, nil
 }
 
+// CreateOptionGroup creates an option group together with its options
+func (r *menuRepository) CreateOptionGroup(ctx context.Context, group *entities.MenuOptionGroup) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Create(group).Error; err != nil {
+		return exception.NewAppError(err, "failed to create option group")
+	}
+	return nil
+}
+
+// DeleteOptionGroup deletes an option group of a menu item and its options
+func (r *menuRepository) DeleteOptionGroup(ctx context.Context, menuID, groupID utils.BinaryUUID) *exception.AppError {
+	result := dbFromContext(ctx, r.db).Delete(&entities.MenuOptionGroup{}, "id = ? AND menu_id = ?", groupID, menuID)
+	if result.Error != nil {
+		return exception.NewAppError(result.Error, "failed to delete option group")
+	}
+	if result.RowsAffected == 0 {
+		return exception.NewAppError(nil, "option group not found", exception.CodeNotFound)
+	}
+	return nil
+}
+
+// GetMenuOption retrieves an option that belongs to a menu item
+func (r *menuRepository) GetMenuOption(ctx context.Context, menuID, optionID utils.BinaryUUID) (*entities.MenuOption, *exception.AppError) {
+	var option entities.MenuOption
+	err := dbFromContext(ctx, r.db).
+		Joins("JOIN menu_option_groups ON menu_option_groups.id = menu_options.group_id").
+		Where("menu_options.id = ? AND menu_option_groups.menu_id = ?", optionID, menuID).
+		First(&option).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, exception.NewAppError(err, "menu option not found", exception.CodeNotFound)
+		}
+		return nil, exception.NewAppError(err, "failed to get menu option")
+	}
+	return &option, nil
+}
+
+// UpdateMenuOptionStock sets the stock of an option; nil stops tracking its stock
+func (r *menuRepository) UpdateMenuOptionStock(ctx context.Context, optionID utils.BinaryUUID, stock *int) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Model(&entities.MenuOption{}).Where("id = ?", optionID).Update("stock", stock).Error; err != nil {
+		return exception.NewAppError(err, "failed to update menu option stock")
+	}
+	return nil
+}
+
+// LockMenuOptionsByIDs retrieves options by their IDs and locks their rows until the transaction ends
+func (r *menuRepository) LockMenuOptionsByIDs(ctx context.Context, ids []utils.BinaryUUID) ([]entities.MenuOption, *exception.AppError) {
+	var options []entities.MenuOption
+	err := dbFromContext(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id ASC").
+		Find(&options).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to lock menu options")
+	}
+	return options, nil
+}
+
+// ReduceMenuOptionStock reduces the stock of a tracked option, refusing to go below zero
+func (r *menuRepository) ReduceMenuOptionStock(ctx context.Context, optionID utils.BinaryUUID, quantity int) *exception.AppError {
+	result := dbFromContext(ctx, r.db).Model(&entities.MenuOption{}).
+		Where("id = ? AND stock >= ?", optionID, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return exception.NewAppError(result.Error, "failed to reduce menu option stock")
+	}
+	if result.RowsAffected == 0 {
+		return exception.NewConflictError("insufficient stock for menu option", optionID.String())
+	}
+	return nil
+}
+
+// IncreaseMenuOptionStock gives quantity back to a tracked option; untracked options are left alone
+func (r *menuRepository) IncreaseMenuOptionStock(ctx context.Context, optionID utils.BinaryUUID, quantity int) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Model(&entities.MenuOption{}).Where("id = ? AND stock IS NOT NULL", optionID).Update("stock", gorm.Expr("stock + ?", quantity)).Error; err != nil {
+		return exception.NewAppError(err, "failed to increase menu option stock")
+	}
+	return nil
+}
+
+// withOptionGroups preloads the option groups and options of the menus being queried
+func withOptionGroups(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("OptionGroups", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order ASC")
+		}).
+		Preload("OptionGroups.Options")
+}
+
 // GetMenusByCategory retrieves menu items by category
 func (r *menuRepository) GetMenusByCategory(ctx context.Context, category string, offset, limit int) ([]entities.Menu, int64, *exception.AppError) {
 	var menus []entities.Menu
 	var count int64
 
-	query := r.db.WithContext(ctx).Model(&entities.Menu{}).Where("category = ?", category)
+	query := dbFromContext(ctx, r.db).Model(&entities.Menu{}).Where("category = ?", category)
 
 	if err := query.Count(&count).Error; err != nil {
 		return nil, 0, exception.NewAppError(err, "failed to count menus by category")
@@ -132,7 +251,7 @@ func (r *menuRepository) SearchMenus(ctx context.Context, query string, offset,
 	var menus []entities.Menu
 	var count int64
 
-	dbQuery := r.db.WithContext(ctx).Model(&entities.Menu{}).Where("name LIKE ? OR description LIKE ?", "%"+query+"%", "%"+query+"%")
+	dbQuery := dbFromContext(ctx, r.db).Model(&entities.Menu{}).Where("name LIKE ? OR description LIKE ?", "%"+query+"%", "%"+query+"%")
 
 	if err := dbQuery.Count(&count).Error; err != nil {
 		return nil, 0, exception.NewAppError(err, "failed to count menus by search")
@@ -148,7 +267,7 @@ func (r *menuRepository) SearchMenus(ctx context.Context, query string, offset,
 // GetCategories retrieves all distinct categories
 func (r *menuRepository) GetCategories(ctx context.Context) ([]string, *exception.AppError) {
 	var categories []string
-	if err := r.db.WithContext(ctx).Model(&entities.Menu{}).Distinct().Pluck("category", &categories).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&entities.Menu{}).Distinct().Pluck("category", &categories).Error; err != nil {
 		return nil, exception.NewAppError(err, "failed to get categories")
 	}
 	return categories, nil
@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"shopify-app/internal/contract"
 	"shopify-app/internal/entities"
 	"shopify-app/internal/exception"
 	"shopify-app/internal/utils"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -23,7 +26,7 @@ func NewOrderRepository(db *gorm.DB) contract.OrderRepository {
 
 // CreateOrder creates a new order in the database
 func (r *orderRepository) CreateOrder(ctx context.Context, order *entities.Order) *exception.AppError {
-	if err := r.db.WithContext(ctx).Create(order).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Create(order).Error; err != nil {
 		return exception.NewAppError(err, "failed to create order")
 	}
 	return nil
@@ -31,16 +34,146 @@ func (r *orderRepository) CreateOrder(ctx context.Context, order *entities.Order
 
 // CreateOrderItems creates order items in batch
 func (r *orderRepository) CreateOrderItems(ctx context.Context, orderItems []entities.OrderItem) *exception.AppError {
-	if err := r.db.WithContext(ctx).Create(&orderItems).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Create(&orderItems).Error; err != nil {
 		return exception.NewAppError(err, "failed to create order items")
 	}
 	return nil
 }
 
+// CreateOrderTaxLines creates the tax lines of an order in batch
+func (r *orderRepository) CreateOrderTaxLines(ctx context.Context, taxLines []entities.OrderTaxLine) *exception.AppError {
+	if len(taxLines) == 0 {
+		return nil
+	}
+	if err := dbFromContext(ctx, r.db).Create(&taxLines).Error; err != nil {
+		return exception.NewAppError(err, "failed to create order tax lines")
+	}
+	return nil
+}
+
+// CreateOrderDiscounts creates the discount lines of an order in batch
+func (r *orderRepository) CreateOrderDiscounts(ctx context.Context, discounts []entities.OrderDiscount) *exception.AppError {
+	if len(discounts) == 0 {
+		return nil
+	}
+	if err := dbFromContext(ctx, r.db).Create(&discounts).Error; err != nil {
+		return exception.NewAppError(err, "failed to create order discounts")
+	}
+	return nil
+}
+
+// UpdateOrderItemQuantity changes the quantity of an order item
+func (r *orderRepository) UpdateOrderItemQuantity(ctx context.Context, itemID utils.BinaryUUID, quantity int) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).Where("id = ?", itemID).Update("quantity", quantity).Error; err != nil {
+		return exception.NewAppError(err, "failed to update order item quantity")
+	}
+	return nil
+}
+
+// DeleteOrderItem removes an item and its options from an order
+func (r *orderRepository) DeleteOrderItem(ctx context.Context, itemID utils.BinaryUUID) *exception.AppError {
+	db := dbFromContext(ctx, r.db)
+	if err := db.Where("order_item_id = ?", itemID).Delete(&entities.OrderItemOption{}).Error; err != nil {
+		return exception.NewAppError(err, "failed to delete order item options")
+	}
+	if err := db.Delete(&entities.OrderItem{}, "id = ?", itemID).Error; err != nil {
+		return exception.NewAppError(err, "failed to delete order item")
+	}
+	return nil
+}
+
+// UpdateOrderPricing stores the recalculated amounts of an order and replaces its tax and discount lines
+func (r *orderRepository) UpdateOrderPricing(ctx context.Context, order *entities.Order, taxLines []entities.OrderTaxLine, discounts []entities.OrderDiscount) *exception.AppError {
+	db := dbFromContext(ctx, r.db)
+	err := db.Model(&entities.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"subtotal":        order.Subtotal,
+		"discount_amount": order.DiscountAmount,
+		"tax_amount":      order.TaxAmount,
+		"total_amount":    order.TotalAmount,
+	}).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to update order amounts")
+	}
+
+	if err := db.Where("order_id = ?", order.ID).Delete(&entities.OrderTaxLine{}).Error; err != nil {
+		return exception.NewAppError(err, "failed to replace order tax lines")
+	}
+	if err := db.Where("order_id = ?", order.ID).Delete(&entities.OrderDiscount{}).Error; err != nil {
+		return exception.NewAppError(err, "failed to replace order discounts")
+	}
+	if err := r.CreateOrderTaxLines(ctx, taxLines); err != nil {
+		return err
+	}
+	return r.CreateOrderDiscounts(ctx, discounts)
+}
+
+// CreateOrderRevision records an edit of an order together with its changes
+func (r *orderRepository) CreateOrderRevision(ctx context.Context, revision *entities.OrderRevision) *exception.AppError {
+	db := dbFromContext(ctx, r.db)
+	var last int
+	if err := db.Model(&entities.OrderRevision{}).Where("order_id = ?", revision.OrderID).
+		Select("COALESCE(MAX(number), 0)").Scan(&last).Error; err != nil {
+		return exception.NewAppError(err, "failed to number order revision")
+	}
+	revision.Number = last + 1
+	if err := db.Create(revision).Error; err != nil {
+		return exception.NewAppError(err, "failed to create order revision")
+	}
+	return nil
+}
+
+// GetDueScheduledOrderIDs retrieves the IDs of scheduled orders whose slot starts at or before cutoff
+func (r *orderRepository) GetDueScheduledOrderIDs(ctx context.Context, cutoff time.Time) ([]utils.BinaryUUID, *exception.AppError) {
+	var ids []utils.BinaryUUID
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Where("status = ? AND scheduled_for <= ?", entities.StatusScheduled, cutoff).
+		Order("scheduled_for ASC").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get due scheduled orders")
+	}
+	return ids, nil
+}
+
+// GetStalePendingOrderIDs retrieves the IDs of pending orders placed before the given time. An
+// order with a pending payment opened after that time is left alone while the customer pays.
+func (r *orderRepository) GetStalePendingOrderIDs(ctx context.Context, placedBefore time.Time) ([]utils.BinaryUUID, *exception.AppError) {
+	var ids []utils.BinaryUUID
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Where("status = ? AND created_at < ?", entities.StatusPending, placedBefore).
+		Where("NOT EXISTS (SELECT 1 FROM payments WHERE payments.order_id = orders.id AND payments.status = ? AND payments.created_at >= ?)", entities.PaymentPending, placedBefore).
+		Order("created_at ASC").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get stale pending orders")
+	}
+	return ids, nil
+}
+
+// NextOrderSequence increments the order counter of a day and returns the new value. The upsert
+// keeps the counter row locked until the surrounding transaction ends, so concurrent checkouts
+// are numbered one after the other.
+func (r *orderRepository) NextOrderSequence(ctx context.Context, day string) (int, *exception.AppError) {
+	db := dbFromContext(ctx, r.db)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"last_value": gorm.Expr("last_value + 1")}),
+	}).Create(&entities.OrderNumberSequence{Day: day, LastValue: 1}).Error
+	if err != nil {
+		return 0, exception.NewAppError(err, "failed to increment order number")
+	}
+
+	var sequence entities.OrderNumberSequence
+	if err := db.Where("day = ?", day).First(&sequence).Error; err != nil {
+		return 0, exception.NewAppError(err, "failed to get order number")
+	}
+	return sequence.LastValue, nil
+}
+
 // GetOrderByID retrieves an order by its ID
 func (r *orderRepository) GetOrderByID(ctx context.Context, id utils.BinaryUUID) (*entities.Order, *exception.AppError) {
 	var order entities.Order
-	if err := r.db.WithContext(ctx).First(&order, "id = ?", id).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).First(&order, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, exception.NewAppError(err, "order not found")
 		}
@@ -49,12 +182,43 @@ func (r *orderRepository) GetOrderByID(ctx context.Context, id utils.BinaryUUID)
 	return &order, nil
 }
 
+// GetOrderByIDForUpdate retrieves an order by its ID and locks its row until the transaction ends
+func (r *orderRepository) GetOrderByIDForUpdate(ctx context.Context, id utils.BinaryUUID) (*entities.Order, *exception.AppError) {
+	var order entities.Order
+	if err := dbFromContext(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, exception.NewAppError(err, "order not found", exception.CodeNotFound)
+		}
+		return nil, exception.NewAppError(err, "failed to lock order")
+	}
+	return &order, nil
+}
+
 // GetOrderWithItems retrieves an order with all its items
 func (r *orderRepository) GetOrderWithItems(ctx context.Context, id utils.BinaryUUID) (*entities.Order, *exception.AppError) {
 	var order entities.Order
-	err := r.db.WithContext(ctx).
+	err := dbFromContext(ctx, r.db).
 		Preload("OrderItems").
+		Preload("OrderItems.Options").
 		Preload("OrderItems.Menu").
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Payments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("TaxLines").
+		Preload("Discounts").
+		Preload("Fees").
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Refunds.Items").
+		Preload("DeliveryAddress").
+		Preload("Revisions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("number ASC")
+		}).
+		Preload("Revisions.Changes").
 		First(&order, "id = ?", id).Error
 
 	if err != nil {
@@ -66,12 +230,16 @@ func (r *orderRepository) GetOrderWithItems(ctx context.Context, id utils.Binary
 	return &order, nil
 }
 
-// GetOrdersByUserID retrieves orders for a specific user with pagination
-func (r *orderRepository) GetOrdersByUserID(ctx context.Context, userID utils.BinaryUUID, offset, limit int) ([]entities.Order, int64, *exception.AppError) {
+// GetOrdersByUserID retrieves orders for a specific user with pagination, optionally only those
+// matching an order number
+func (r *orderRepository) GetOrdersByUserID(ctx context.Context, userID utils.BinaryUUID, orderNumber string, offset, limit int) ([]entities.Order, int64, *exception.AppError) {
 	var orders []entities.Order
 	var count int64
 
-	query := r.db.WithContext(ctx).Model(&entities.Order{}).Where("user_id = ?", userID)
+	query := dbFromContext(ctx, r.db).Model(&entities.Order{}).Where("user_id = ?", userID)
+	if orderNumber != "" {
+		query = whereOrderNumber(query, orderNumber)
+	}
 
 	if err := query.Count(&count).Error; err != nil {
 		return nil, 0, exception.NewAppError(err, "failed to count orders by user id")
@@ -89,7 +257,7 @@ func (r *orderRepository) GetAllOrders(ctx context.Context, offset, limit int, s
 	var orders []entities.Order
 	var count int64
 
-	query := r.db.WithContext(ctx).Model(&entities.Order{})
+	query := dbFromContext(ctx, r.db).Model(&entities.Order{})
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
@@ -107,16 +275,41 @@ func (r *orderRepository) GetAllOrders(ctx context.Context, offset, limit int, s
 
 // UpdateOrderStatus updates the status of an order
 func (r *orderRepository) UpdateOrderStatus(ctx context.Context, id utils.BinaryUUID, status entities.OrderStatus) *exception.AppError {
-	if err := r.db.WithContext(ctx).Model(&entities.Order{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&entities.Order{}).Where("id = ?", id).Update("status", status).Error; err != nil {
 		return exception.NewAppError(err, "failed to update order status")
 	}
 	return nil
 }
 
+// MarkOrderRestocked flags an order as restocked so its items are never given back twice
+func (r *orderRepository) MarkOrderRestocked(ctx context.Context, id utils.BinaryUUID) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Model(&entities.Order{}).Where("id = ?", id).Update("restocked_at", time.Now()).Error; err != nil {
+		return exception.NewAppError(err, "failed to mark order as restocked")
+	}
+	return nil
+}
+
+// CreateStatusHistory records a status transition of an order
+func (r *orderRepository) CreateStatusHistory(ctx context.Context, history *entities.OrderStatusHistory) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Create(history).Error; err != nil {
+		return exception.NewAppError(err, "failed to record order status history")
+	}
+	return nil
+}
+
+// GetStatusHistory retrieves the status transitions of an order in chronological order
+func (r *orderRepository) GetStatusHistory(ctx context.Context, orderID utils.BinaryUUID) ([]entities.OrderStatusHistory, *exception.AppError) {
+	var history []entities.OrderStatusHistory
+	if err := dbFromContext(ctx, r.db).Where("order_id = ?", orderID).Order("created_at ASC").Find(&history).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to get order status history")
+	}
+	return history, nil
+}
+
 // GetOrdersByDateRange retrieves orders within a date range
 func (r *orderRepository) GetOrdersByDateRange(ctx context.Context, startDate, endDate time.Time) ([]entities.Order, *exception.AppError) {
 	var orders []entities.Order
-	err := r.db.WithContext(ctx).
+	err := dbFromContext(ctx, r.db).
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Order("created_at DESC").
 		Find(&orders).Error
@@ -132,7 +325,7 @@ func (r *orderRepository) GetOrdersByStatus(ctx context.Context, status entities
 	var orders []entities.Order
 	var count int64
 
-	query := r.db.WithContext(ctx).Model(&entities.Order{}).Where("status = ?", status)
+	query := dbFromContext(ctx, r.db).Model(&entities.Order{}).Where("status = ?", status)
 
 	if err := query.Count(&count).Error; err != nil {
 		return nil, 0, exception.NewAppError(err, "failed to count orders by status")
@@ -148,7 +341,7 @@ func (r *orderRepository) GetOrdersByStatus(ctx context.Context, status entities
 // ValidateOrderOwnership validates that an order belongs to a specific user
 func (r *orderRepository) ValidateOrderOwnership(ctx context.Context, orderID, userID utils.BinaryUUID) (bool, *exception.AppError) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&entities.Order{}).
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
 		Where("id = ? AND user_id = ?", orderID, userID).
 		Count(&count).Error
 
@@ -158,3 +351,78 @@ func (r *orderRepository) ValidateOrderOwnership(ctx context.Context, orderID, u
 
 	return count > 0, nil
 }
+
+// orderSortColumns maps the sort fields of contract.OrderFilter to columns
+var orderSortColumns = map[string]string{
+	"created_at":   "orders.created_at",
+	"total_amount": "orders.total_amount",
+	"status":       "orders.status",
+}
+
+// whereOrderNumber narrows an orders query down to an order number. Numbers like "#0427" repeat
+// every day, so a bare number matches that position on any day; combine it with a date range to
+// find one order.
+func whereOrderNumber(query *gorm.DB, orderNumber string) *gorm.DB {
+	if seq, err := strconv.Atoi(strings.TrimLeft(orderNumber, "#")); err == nil && seq > 0 {
+		return query.Where("(orders.order_number = ? OR orders.daily_sequence = ?)", orderNumber, seq)
+	}
+	return query.Where("orders.order_number = ?", orderNumber)
+}
+
+// SearchOrders retrieves orders matching a filter with sorting and pagination
+func (r *orderRepository) SearchOrders(ctx context.Context, filter contract.OrderFilter) ([]entities.Order, int64, *exception.AppError) {
+	var orders []entities.Order
+	var count int64
+
+	query := dbFromContext(ctx, r.db).Model(&entities.Order{})
+
+	if filter.Status != "" {
+		query = query.Where("orders.status = ?", filter.Status)
+	}
+	if filter.Fulfilment != "" {
+		query = query.Where("orders.fulfilment_type = ?", filter.Fulfilment)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("orders.created_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("orders.created_at < ?", *filter.EndDate)
+	}
+	if filter.CustomerEmail != "" {
+		query = query.Joins("JOIN users ON users.id = orders.user_id").
+			Where("users.email LIKE ?", "%"+filter.CustomerEmail+"%")
+	}
+	if filter.MinTotal != nil {
+		query = query.Where("orders.total_amount >= ?", filter.MinTotal)
+	}
+	if filter.MaxTotal != nil {
+		query = query.Where("orders.total_amount <= ?", filter.MaxTotal)
+	}
+	if filter.OrderNumber != "" {
+		query = whereOrderNumber(query, filter.OrderNumber)
+	}
+	if filter.MenuID != nil {
+		query = query.Where("EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.menu_id = ?)", *filter.MenuID)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, exception.NewAppError(err, "failed to count orders")
+	}
+
+	sortColumn, ok := orderSortColumns[filter.SortBy]
+	if !ok {
+		sortColumn = orderSortColumns["created_at"]
+	}
+
+	err := query.Select("orders.*").
+		Preload("User").
+		Order(clause.OrderByColumn{Column: clause.Column{Name: sortColumn, Raw: true}, Desc: filter.SortDesc}).
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, 0, exception.NewAppError(err, "failed to search orders")
+	}
+
+	return orders, count, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// paymentRepository implements the contract.PaymentRepository interface
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+// NewPaymentRepository creates a new instance of the payment repository
+func NewPaymentRepository(db *gorm.DB) contract.PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+// CreatePayment creates a new payment
+func (r *paymentRepository) CreatePayment(ctx context.Context, payment *entities.Payment) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Create(payment).Error; err != nil {
+		return exception.NewAppError(err, "failed to create payment")
+	}
+	return nil
+}
+
+// GetPaymentByReferenceForUpdate retrieves a payment by its provider reference and locks the row
+func (r *paymentRepository) GetPaymentByReferenceForUpdate(ctx context.Context, provider, reference string) (*entities.Payment, *exception.AppError) {
+	var payment entities.Payment
+	err := dbFromContext(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("provider = ? AND provider_reference = ?", provider, reference).
+		First(&payment).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, exception.NewAppError(err, "payment not found", exception.CodeNotFound)
+		}
+		return nil, exception.NewAppError(err, "failed to get payment")
+	}
+	return &payment, nil
+}
+
+// GetPendingPaymentByOrderID retrieves the open payment of an order
+func (r *paymentRepository) GetPendingPaymentByOrderID(ctx context.Context, orderID utils.BinaryUUID) (*entities.Payment, *exception.AppError) {
+	var payment entities.Payment
+	err := dbFromContext(ctx, r.db).
+		Where("order_id = ? AND status = ?", orderID, entities.PaymentPending).
+		Order("created_at DESC").
+		First(&payment).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No payment in progress
+		}
+		return nil, exception.NewAppError(err, "failed to get pending payment")
+	}
+	return &payment, nil
+}
+
+// GetPaymentsByOrderID retrieves all payments of an order, oldest first
+func (r *paymentRepository) GetPaymentsByOrderID(ctx context.Context, orderID utils.BinaryUUID) ([]entities.Payment, *exception.AppError) {
+	var payments []entities.Payment
+	err := dbFromContext(ctx, r.db).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Find(&payments).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get payments")
+	}
+	return payments, nil
+}
+
+// UpdatePaymentStatus updates the status of a payment
+func (r *paymentRepository) UpdatePaymentStatus(ctx context.Context, id utils.BinaryUUID, status entities.PaymentStatus, failureReason string) *exception.AppError {
+	err := dbFromContext(ctx, r.db).Model(&entities.Payment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":         status,
+		"failure_reason": failureReason,
+	}).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to update payment status")
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// promotionRepository implements the contract.PromotionRepository interface
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+// NewPromotionRepository creates a new instance of the promotion repository
+func NewPromotionRepository(db *gorm.DB) contract.PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+// CreatePromotion creates a new promotion
+func (r *promotionRepository) CreatePromotion(ctx context.Context, promotion *entities.Promotion) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Create(promotion).Error; err != nil {
+		return exception.NewAppError(err, "failed to create promotion")
+	}
+	return nil
+}
+
+// GetPromotionByID retrieves a promotion by its ID
+func (r *promotionRepository) GetPromotionByID(ctx context.Context, id utils.BinaryUUID) (*entities.Promotion, *exception.AppError) {
+	var promotion entities.Promotion
+	if err := dbFromContext(ctx, r.db).First(&promotion, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, exception.NewAppError(err, "promotion not found", exception.CodeNotFound)
+		}
+		return nil, exception.NewAppError(err, "failed to get promotion")
+	}
+	return &promotion, nil
+}
+
+// GetPromotionByCode retrieves a promotion by its coupon code, including deleted promotions since
+// their codes stay reserved
+func (r *promotionRepository) GetPromotionByCode(ctx context.Context, code string) (*entities.Promotion, *exception.AppError) {
+	var promotion entities.Promotion
+	if err := dbFromContext(ctx, r.db).Unscoped().First(&promotion, "code = ?", code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Unknown codes are not an error for the caller
+		}
+		return nil, exception.NewAppError(err, "failed to get promotion by code")
+	}
+	return &promotion, nil
+}
+
+// GetAllPromotions retrieves promotions with pagination, newest first
+func (r *promotionRepository) GetAllPromotions(ctx context.Context, offset, limit int) ([]entities.Promotion, int64, *exception.AppError) {
+	var promotions []entities.Promotion
+	var count int64
+
+	query := dbFromContext(ctx, r.db).Model(&entities.Promotion{})
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, exception.NewAppError(err, "failed to count promotions")
+	}
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&promotions).Error; err != nil {
+		return nil, 0, exception.NewAppError(err, "failed to get promotions")
+	}
+	return promotions, count, nil
+}
+
+// GetAutomaticPromotions retrieves the active promotions without a coupon code
+func (r *promotionRepository) GetAutomaticPromotions(ctx context.Context) ([]entities.Promotion, *exception.AppError) {
+	var promotions []entities.Promotion
+	err := dbFromContext(ctx, r.db).
+		Where("code IS NULL AND is_active = ?", true).
+		Order("id ASC").
+		Find(&promotions).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get automatic promotions")
+	}
+	return promotions, nil
+}
+
+// LockPromotionsByIDs retrieves promotions and locks their rows, in a consistent order
+func (r *promotionRepository) LockPromotionsByIDs(ctx context.Context, ids []utils.BinaryUUID) ([]entities.Promotion, *exception.AppError) {
+	var promotions []entities.Promotion
+	if len(ids) == 0 {
+		return promotions, nil
+	}
+	err := dbFromContext(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id ASC").
+		Find(&promotions).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to lock promotions")
+	}
+	return promotions, nil
+}
+
+// GetPromotionsByIDs retrieves promotions by their IDs, including deleted ones
+func (r *promotionRepository) GetPromotionsByIDs(ctx context.Context, ids []utils.BinaryUUID) ([]entities.Promotion, *exception.AppError) {
+	var promotions []entities.Promotion
+	if len(ids) == 0 {
+		return promotions, nil
+	}
+	if err := dbFromContext(ctx, r.db).Unscoped().Where("id IN ?", ids).Order("id ASC").Find(&promotions).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to get promotions")
+	}
+	return promotions, nil
+}
+
+// UpdatePromotion updates an existing promotion
+func (r *promotionRepository) UpdatePromotion(ctx context.Context, promotion *entities.Promotion) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Save(promotion).Error; err != nil {
+		return exception.NewAppError(err, "failed to update promotion")
+	}
+	return nil
+}
+
+// DeletePromotion soft deletes a promotion
+func (r *promotionRepository) DeletePromotion(ctx context.Context, id utils.BinaryUUID) *exception.AppError {
+	result := dbFromContext(ctx, r.db).Delete(&entities.Promotion{}, "id = ?", id)
+	if result.Error != nil {
+		return exception.NewAppError(result.Error, "failed to delete promotion")
+	}
+	if result.RowsAffected == 0 {
+		return exception.NewAppError(nil, "promotion not found", exception.CodeNotFound)
+	}
+	return nil
+}
+
+// AdjustUsageCount adds delta to the usage count of a promotion, never going below zero
+func (r *promotionRepository) AdjustUsageCount(ctx context.Context, id utils.BinaryUUID, delta int) *exception.AppError {
+	err := dbFromContext(ctx, r.db).Unscoped().Model(&entities.Promotion{}).
+		Where("id = ?", id).
+		Update("usage_count", gorm.Expr("GREATEST(usage_count + ?, 0)", delta)).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to update promotion usage")
+	}
+	return nil
+}
+
+// ClaimPromotionUse adds one to the usage count of a promotion unless that would exceed its usage
+// limit, and reports whether it did
+func (r *promotionRepository) ClaimPromotionUse(ctx context.Context, id utils.BinaryUUID) (bool, *exception.AppError) {
+	result := dbFromContext(ctx, r.db).Model(&entities.Promotion{}).
+		Where("id = ? AND (usage_limit IS NULL OR usage_count < usage_limit)", id).
+		Update("usage_count", gorm.Expr("usage_count + 1"))
+	if result.Error != nil {
+		return false, exception.NewAppError(result.Error, "failed to update promotion usage")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountUserRedemptions counts how often a user has used a promotion
+func (r *promotionRepository) CountUserRedemptions(ctx context.Context, promotionID, userID utils.BinaryUUID) (int64, *exception.AppError) {
+	var count int64
+	err := dbFromContext(ctx, r.db).Model(&entities.PromotionRedemption{}).
+		Where("promotion_id = ? AND user_id = ?", promotionID, userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, exception.NewAppError(err, "failed to count promotion redemptions")
+	}
+	return count, nil
+}
+
+// CountUserRedemptionsForUpdate counts how often a user has used a promotion with a locking read,
+// which also sees the redemptions committed after the surrounding transaction started
+func (r *promotionRepository) CountUserRedemptionsForUpdate(ctx context.Context, promotionID, userID utils.BinaryUUID) (int64, *exception.AppError) {
+	var count int64
+	err := dbFromContext(ctx, r.db).Model(&entities.PromotionRedemption{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("promotion_id = ? AND user_id = ?", promotionID, userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, exception.NewAppError(err, "failed to count promotion redemptions")
+	}
+	return count, nil
+}
+
+// CreateRedemptions records promotion uses in batch
+func (r *promotionRepository) CreateRedemptions(ctx context.Context, redemptions []entities.PromotionRedemption) *exception.AppError {
+	if len(redemptions) == 0 {
+		return nil
+	}
+	if err := dbFromContext(ctx, r.db).Create(&redemptions).Error; err != nil {
+		return exception.NewAppError(err, "failed to record promotion redemptions")
+	}
+	return nil
+}
+
+// GetRedemptionsByOrderID retrieves the promotion uses of an order
+func (r *promotionRepository) GetRedemptionsByOrderID(ctx context.Context, orderID utils.BinaryUUID) ([]entities.PromotionRedemption, *exception.AppError) {
+	var redemptions []entities.PromotionRedemption
+	if err := dbFromContext(ctx, r.db).Where("order_id = ?", orderID).Find(&redemptions).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to get promotion redemptions")
+	}
+	return redemptions, nil
+}
+
+// DeleteRedemption removes the use of a promotion by an order
+func (r *promotionRepository) DeleteRedemption(ctx context.Context, orderID, promotionID utils.BinaryUUID) *exception.AppError {
+	err := dbFromContext(ctx, r.db).
+		Where("order_id = ? AND promotion_id = ?", orderID, promotionID).
+		Delete(&entities.PromotionRedemption{}).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to delete promotion redemption")
+	}
+	return nil
+}
+
+// DeleteRedemptionsByOrderID removes the promotion uses of an order
+func (r *promotionRepository) DeleteRedemptionsByOrderID(ctx context.Context, orderID utils.BinaryUUID) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Where("order_id = ?", orderID).Delete(&entities.PromotionRedemption{}).Error; err != nil {
+		return exception.NewAppError(err, "failed to delete promotion redemptions")
+	}
+	return nil
+}
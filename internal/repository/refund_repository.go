@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// refundRepository implements the contract.RefundRepository interface
+type refundRepository struct {
+	db *gorm.DB
+}
+
+// NewRefundRepository creates a new instance of the refund repository
+func NewRefundRepository(db *gorm.DB) contract.RefundRepository {
+	return &refundRepository{db: db}
+}
+
+// CreateRefund creates a refund together with its items
+func (r *refundRepository) CreateRefund(ctx context.Context, refund *entities.Refund) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Create(refund).Error; err != nil {
+		return exception.NewAppError(err, "failed to create refund")
+	}
+	return nil
+}
+
+// GetRefundsByOrderID retrieves the refunds of an order, oldest first
+func (r *refundRepository) GetRefundsByOrderID(ctx context.Context, orderID utils.BinaryUUID) ([]entities.Refund, *exception.AppError) {
+	var refunds []entities.Refund
+	if err := dbFromContext(ctx, r.db).Preload("Items").Where("order_id = ?", orderID).
+		Order("created_at ASC").Find(&refunds).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to get refunds")
+	}
+	return refunds, nil
+}
+
+// GetRefundByID retrieves a refund together with its items
+func (r *refundRepository) GetRefundByID(ctx context.Context, id utils.BinaryUUID) (*entities.Refund, *exception.AppError) {
+	var refund entities.Refund
+	if err := dbFromContext(ctx, r.db).Preload("Items").First(&refund, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, exception.NewAppError(err, "refund not found", exception.CodeNotFound)
+		}
+		return nil, exception.NewAppError(err, "failed to get refund")
+	}
+	return &refund, nil
+}
+
+// GetRefundByIDForUpdate retrieves a refund and locks it until the surrounding transaction ends
+func (r *refundRepository) GetRefundByIDForUpdate(ctx context.Context, id utils.BinaryUUID) (*entities.Refund, *exception.AppError) {
+	var refund entities.Refund
+	err := dbFromContext(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&refund, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, exception.NewAppError(err, "refund not found", exception.CodeNotFound)
+		}
+		return nil, exception.NewAppError(err, "failed to lock refund")
+	}
+	return &refund, nil
+}
+
+// GetPendingRefundIDs retrieves the IDs of up to limit refunds created before the given time that are still pending
+func (r *refundRepository) GetPendingRefundIDs(ctx context.Context, createdBefore time.Time, limit int) ([]utils.BinaryUUID, *exception.AppError) {
+	var ids []utils.BinaryUUID
+	err := dbFromContext(ctx, r.db).Model(&entities.Refund{}).
+		Where("status = ? AND created_at < ?", entities.RefundPending, createdBefore).
+		Order("created_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get pending refunds")
+	}
+	return ids, nil
+}
+
+// AddAmountRefunded adds a refunded amount to the running total of an order
+func (r *refundRepository) AddAmountRefunded(ctx context.Context, orderID utils.BinaryUUID, amount *utils.GormDecimal) *exception.AppError {
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).Where("id = ?", orderID).
+		Update("amount_refunded", gorm.Expr("amount_refunded + ?", amount)).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to update refunded amount")
+	}
+	return nil
+}
+
+// AddRefundedQuantity adds to the refunded quantity of an order item
+func (r *refundRepository) AddRefundedQuantity(ctx context.Context, orderItemID utils.BinaryUUID, quantity int) *exception.AppError {
+	err := dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).Where("id = ?", orderItemID).
+		Update("refunded_quantity", gorm.Expr("refunded_quantity + ?", quantity)).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to update refunded quantity")
+	}
+	return nil
+}
+
+// AddRestockedQuantity adds to the quantity of an order item put back into stock by refunds
+func (r *refundRepository) AddRestockedQuantity(ctx context.Context, orderItemID utils.BinaryUUID, quantity int) *exception.AppError {
+	err := dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).Where("id = ?", orderItemID).
+		Update("restocked_quantity", gorm.Expr("restocked_quantity + ?", quantity)).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to update restocked quantity")
+	}
+	return nil
+}
+
+// UpdateRefundOutcome stores the status, failure reason, provider reference and restock flag of a refund
+func (r *refundRepository) UpdateRefundOutcome(ctx context.Context, refund *entities.Refund) *exception.AppError {
+	err := dbFromContext(ctx, r.db).Model(&entities.Refund{}).Where("id = ?", refund.ID).
+		Updates(map[string]interface{}{
+			"status":             refund.Status,
+			"failure_reason":     refund.FailureReason,
+			"provider_reference": refund.ProviderReference,
+			"restocked":          refund.Restocked,
+		}).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to update refund")
+	}
+	return nil
+}
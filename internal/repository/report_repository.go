@@ -11,6 +11,20 @@ import (
 	"time"
 )
 
+// keptShare is the part of an order's total that was not refunded. Refunds are taken to include
+// tax in the same proportion as the order.
+const keptShare = "(CASE WHEN total_amount > 0 THEN (total_amount - amount_refunded) / total_amount ELSE 1 END)"
+
+// salesColumns selects gross sales, net sales (without tax), tax, discounts, refunds and order
+// count of a set of orders. Sales and tax are net of refunds.
+const salesColumns = "COALESCE(SUM(total_amount - amount_refunded), 0) as total_sales, " +
+	"COALESCE(ROUND(SUM((total_amount - tax_amount) * " + keptShare + "), 2), 0) as net_sales, " +
+	"COALESCE(ROUND(SUM(tax_amount * " + keptShare + "), 2), 0) as tax_amount, COALESCE(SUM(discount_amount), 0) as discounts, " +
+	"COALESCE(SUM(amount_refunded), 0) as refunds, COUNT(id) as order_count"
+
+// soldQuantity is the quantity of an order item that was sold and not refunded
+const soldQuantity = "(order_items.quantity - order_items.refunded_quantity)"
+
 // reportRepository implements the contract.ReportRepository interface
 type reportRepository struct {
 	db *gorm.DB
@@ -27,8 +41,8 @@ func (r *reportRepository) GetDailySales(ctx context.Context, date time.Time) (*
 	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
-	err := r.db.WithContext(ctx).Model(&entities.Order{}).
-		Select("? as date, COALESCE(SUM(total_amount), 0) as total_sales, COUNT(id) as order_count", startOfDay).
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Select("? as date, "+salesColumns, startOfDay).
 		Where("created_at >= ? AND created_at < ? AND status = ?", startOfDay, endOfDay, entities.StatusDelivered).
 		First(&result).Error
 
@@ -37,9 +51,9 @@ func (r *reportRepository) GetDailySales(ctx context.Context, date time.Time) (*
 	}
 
 	// Get total items sold
-	err = r.db.WithContext(ctx).Model(&entities.OrderItem{}).
+	err = dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).
 		Joins("JOIN orders ON orders.id = order_items.order_id").
-		Select("COALESCE(SUM(order_items.quantity), 0) as items_sold").
+		Select("COALESCE(SUM("+soldQuantity+"), 0) as items_sold").
 		Where("orders.created_at >= ? AND orders.created_at < ? AND orders.status = ?", startOfDay, endOfDay, entities.StatusDelivered).
 		Scan(&result.ItemsSold).Error
 
@@ -53,8 +67,8 @@ func (r *reportRepository) GetDailySales(ctx context.Context, date time.Time) (*
 // GetSalesByDateRange retrieves sales data for a date range
 func (r *reportRepository) GetSalesByDateRange(ctx context.Context, startDate, endDate time.Time) ([]contract.SalesReportData, *exception.AppError) {
 	var results []contract.SalesReportData
-	err := r.db.WithContext(ctx).Model(&entities.Order{}).
-		Select("DATE(created_at) as date, SUM(total_amount) as total_sales, COUNT(id) as order_count").
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Select("DATE(created_at) as date, "+salesColumns).
 		Where("created_at BETWEEN ? AND ? AND status = ?", startDate, endDate, entities.StatusDelivered).
 		Group("DATE(created_at)").
 		Order("date ASC").
@@ -69,9 +83,9 @@ func (r *reportRepository) GetSalesByDateRange(ctx context.Context, startDate, e
 		var itemsSold int64
 		startOfDay := results[i].Date
 		endOfDay := startOfDay.Add(24 * time.Hour)
-		err = r.db.WithContext(ctx).Model(&entities.OrderItem{}).
+		err = dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).
 			Joins("JOIN orders ON orders.id = order_items.order_id").
-			Select("COALESCE(SUM(order_items.quantity), 0)").
+			Select("COALESCE(SUM("+soldQuantity+"), 0)").
 			Where("orders.created_at >= ? AND orders.created_at < ? AND orders.status = ?", startOfDay, endOfDay, entities.StatusDelivered).
 			Scan(&itemsSold).Error
 		if err != nil {
@@ -89,8 +103,8 @@ func (r *reportRepository) GetMonthlySales(ctx context.Context, year int, month
 	startDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
 	endDate := startDate.AddDate(0, 1, 0)
 
-	err := r.db.WithContext(ctx).Model(&entities.Order{}).
-		Select("? as date, COALESCE(SUM(total_amount), 0) as total_sales, COUNT(id) as order_count", startDate).
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Select("? as date, "+salesColumns, startDate).
 		Where("created_at >= ? AND created_at < ? AND status = ?", startDate, endDate, entities.StatusDelivered).
 		First(&result).Error
 
@@ -98,9 +112,9 @@ func (r *reportRepository) GetMonthlySales(ctx context.Context, year int, month
 		return nil, exception.NewAppError(err, "failed to get monthly sales")
 	}
 
-	err = r.db.WithContext(ctx).Model(&entities.OrderItem{}).
+	err = dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).
 		Joins("JOIN orders ON orders.id = order_items.order_id").
-		Select("COALESCE(SUM(order_items.quantity), 0) as items_sold").
+		Select("COALESCE(SUM("+soldQuantity+"), 0) as items_sold").
 		Where("orders.created_at >= ? AND orders.created_at < ? AND orders.status = ?", startDate, endDate, entities.StatusDelivered).
 		Scan(&result.ItemsSold).Error
 
@@ -117,8 +131,8 @@ func (r *reportRepository) GetYearlySales(ctx context.Context, year int) (*contr
 	startDate := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := startDate.AddDate(1, 0, 0)
 
-	err := r.db.WithContext(ctx).Model(&entities.Order{}).
-		Select("? as date, COALESCE(SUM(total_amount), 0) as total_sales, COUNT(id) as order_count", startDate).
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Select("? as date, "+salesColumns, startDate).
 		Where("created_at >= ? AND created_at < ? AND status = ?", startDate, endDate, entities.StatusDelivered).
 		First(&result).Error
 
@@ -126,9 +140,9 @@ func (r *reportRepository) GetYearlySales(ctx context.Context, year int) (*contr
 		return nil, exception.NewAppError(err, "failed to get yearly sales")
 	}
 
-	err = r.db.WithContext(ctx).Model(&entities.OrderItem{}).
+	err = dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).
 		Joins("JOIN orders ON orders.id = order_items.order_id").
-		Select("COALESCE(SUM(order_items.quantity), 0) as items_sold").
+		Select("COALESCE(SUM("+soldQuantity+"), 0) as items_sold").
 		Where("orders.created_at >= ? AND orders.created_at < ? AND orders.status = ?", startDate, endDate, entities.StatusDelivered).
 		Scan(&result.ItemsSold).Error
 
@@ -142,8 +156,8 @@ func (r *reportRepository) GetYearlySales(ctx context.Context, year int) (*contr
 // GetBestSellingItems retrieves best selling items within a date range
 func (r *reportRepository) GetBestSellingItems(ctx context.Context, startDate, endDate time.Time, limit int) ([]contract.BestSellingItem, *exception.AppError) {
 	var results []contract.BestSellingItem
-	err := r.db.WithContext(ctx).Model(&entities.OrderItem{}).
-		Select("order_items.menu_id, menus.name as menu_name, menus.category, SUM(order_items.quantity) as total_sold, SUM(order_items.price * order_items.quantity) as total_revenue").
+	err := dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).
+		Select("order_items.menu_id, menus.name as menu_name, menus.category, SUM("+soldQuantity+") as total_sold, SUM(order_items.price * "+soldQuantity+") as total_revenue").
 		Joins("JOIN orders ON orders.id = order_items.order_id").
 		Joins("JOIN menus ON menus.id = order_items.menu_id").
 		Where("orders.created_at BETWEEN ? AND ? AND orders.status = ?", startDate, endDate, entities.StatusDelivered).
@@ -164,17 +178,20 @@ func (r *reportRepository) GetSalesAnalytics(ctx context.Context, startDate, end
 	analytics.PeriodStart = startDate
 	analytics.PeriodEnd = endDate
 
-	err := r.db.WithContext(ctx).Model(&entities.Order{}).
-		Select("COALESCE(SUM(total_amount), 0) as total_revenue, COUNT(id) as total_orders").
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Select("COALESCE(SUM(total_amount - amount_refunded), 0) as total_revenue, "+
+			"COALESCE(ROUND(SUM((total_amount - tax_amount) * "+keptShare+"), 2), 0) as net_revenue, "+
+			"COALESCE(ROUND(SUM(tax_amount * "+keptShare+"), 2), 0) as total_tax, COALESCE(SUM(discount_amount), 0) as total_discounts, "+
+			"COALESCE(SUM(amount_refunded), 0) as total_refunds, COUNT(id) as total_orders").
 		Where("created_at BETWEEN ? AND ? AND status = ?", startDate, endDate, entities.StatusDelivered).
 		First(&analytics).Error
 	if err != nil {
 		return nil, exception.NewAppError(err, "failed to get sales analytics revenue and orders")
 	}
 
-	err = r.db.WithContext(ctx).Model(&entities.OrderItem{}).
+	err = dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).
 		Joins("JOIN orders ON orders.id = order_items.order_id").
-		Select("COALESCE(SUM(order_items.quantity), 0) as total_items").
+		Select("COALESCE(SUM("+soldQuantity+"), 0) as total_items").
 		Where("orders.created_at BETWEEN ? AND ? AND orders.status = ?", startDate, endDate, entities.StatusDelivered).
 		Scan(&analytics.TotalItems).Error
 	if err != nil {
@@ -182,20 +199,19 @@ func (r *reportRepository) GetSalesAnalytics(ctx context.Context, startDate, end
 	}
 
 	if analytics.TotalOrders > 0 {
-		totalRevenue, _ := utils.GormDecimalToFloat64(*analytics.TotalRevenue)
-		avg, _ := utils.Float64ToGormDecimal(totalRevenue / float64(analytics.TotalOrders))
-		analytics.AverageOrderValue = avg
+		orders := utils.NewMoney(analytics.TotalOrders, 0)
+		analytics.AverageOrderValue = analytics.TotalRevenue.Div(orders, 2, utils.RoundHalfUp)
 	} else {
-		analytics.AverageOrderValue = utils.MustNewGormDecimal("0")
+		analytics.AverageOrderValue = utils.NewMoney(0, 2)
 	}
 
-	err = r.db.WithContext(ctx).Model(&entities.OrderItem{}).
+	err = dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).
 		Select("menus.category").
 		Joins("JOIN menus ON menus.id = order_items.menu_id").
 		Joins("JOIN orders ON orders.id = order_items.order_id").
 		Where("orders.created_at BETWEEN ? AND ? AND orders.status = ?", startDate, endDate, entities.StatusDelivered).
 		Group("menus.category").
-		Order("SUM(order_items.quantity) DESC").
+		Order("SUM(" + soldQuantity + ") DESC").
 		Limit(1).
 		Scan(&analytics.TopCategory).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -212,8 +228,8 @@ func (r *reportRepository) GetSalesByCategory(ctx context.Context, startDate, en
 		Total    *utils.GormDecimal
 	}
 	var results []CategorySale
-	err := r.db.WithContext(ctx).Model(&entities.OrderItem{}).
-		Select("menus.category, SUM(order_items.price * order_items.quantity) as total").
+	err := dbFromContext(ctx, r.db).Model(&entities.OrderItem{}).
+		Select("menus.category, SUM(order_items.price * "+soldQuantity+") as total").
 		Joins("JOIN menus ON menus.id = order_items.menu_id").
 		Joins("JOIN orders ON orders.id = order_items.order_id").
 		Where("orders.created_at BETWEEN ? AND ? AND orders.status = ?", startDate, endDate, entities.StatusDelivered).
@@ -246,7 +262,7 @@ func (r *reportRepository) GetHourlyOrderDistribution(ctx context.Context, start
 		Count int64
 	}
 	var results []HourlyResult
-	err := r.db.WithContext(ctx).Model(&entities.Order{}).
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
 		Select("EXTRACT(HOUR FROM created_at) as hour, COUNT(id) as count").
 		Where("created_at BETWEEN ? AND ? AND status = ?", startDate, endDate, entities.StatusDelivered).
 		Group("hour").
@@ -268,32 +284,107 @@ func (r *reportRepository) GetHourlyOrderDistribution(ctx context.Context, start
 func (r *reportRepository) GetRevenueGrowth(ctx context.Context, currentStart, currentEnd, previousStart, previousEnd time.Time) (float64, *exception.AppError) {
 	var currentRevenue, previousRevenue utils.GormDecimal
 
-	err := r.db.WithContext(ctx).Model(&entities.Order{}).
-		Select("COALESCE(SUM(total_amount), 0)").
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Select("COALESCE(SUM(total_amount - amount_refunded), 0)").
 		Where("created_at BETWEEN ? AND ? AND status = ?", currentStart, currentEnd, entities.StatusDelivered).
 		Scan(&currentRevenue).Error
 	if err != nil {
 		return 0, exception.NewAppError(err, "failed to get current period revenue for growth calculation")
 	}
 
-	err = r.db.WithContext(ctx).Model(&entities.Order{}).
-		Select("COALESCE(SUM(total_amount), 0)").
+	err = dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Select("COALESCE(SUM(total_amount - amount_refunded), 0)").
 		Where("created_at BETWEEN ? AND ? AND status = ?", previousStart, previousEnd, entities.StatusDelivered).
 		Scan(&previousRevenue).Error
 	if err != nil {
 		return 0, exception.NewAppError(err, "failed to get previous period revenue for growth calculation")
 	}
 
-	current, _ := utils.GormDecimalToFloat64(currentRevenue)
-	previous, _ := utils.GormDecimalToFloat64(previousRevenue)
-
-	if previous == 0 {
-		if current > 0 {
+	if previousRevenue.IsZero() {
+		if currentRevenue.Sign() > 0 {
 			return 100.0, nil // Infinite growth, represent as 100%
 		}
 		return 0.0, nil
 	}
 
-	growth := ((current - previous) / previous) * 100
-	return growth, nil
+	// Worked out exactly; only the resulting percentage is a float
+	change := currentRevenue.Sub(&previousRevenue).MulInt(100)
+	return change.Div(&previousRevenue, 4, utils.RoundHalfEven).Float64(), nil
+}
+
+// GetSlotLoad retrieves the orders and items scheduled into each slot starting in [from, to)
+func (r *reportRepository) GetSlotLoad(ctx context.Context, from, to time.Time) ([]contract.SlotLoad, *exception.AppError) {
+	var results []contract.SlotLoad
+	err := dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Select("orders.scheduled_for as slot_start, COUNT(DISTINCT orders.id) as order_count, COALESCE(SUM(order_items.quantity), 0) as item_count").
+		Joins("LEFT JOIN order_items ON order_items.order_id = orders.id").
+		Where("orders.scheduled_for >= ? AND orders.scheduled_for < ? AND orders.status <> ?", from, to, entities.StatusCancelled).
+		Group("orders.scheduled_for").
+		Order("slot_start ASC").
+		Scan(&results).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get slot load")
+	}
+	return results, nil
+}
+
+// GetAbandonedCartStats retrieves the carts abandoned in a period with their value, how many of
+// their owners were reminded and how many were recovered, and the orders placed in the period
+// from carts that were never abandoned
+func (r *reportRepository) GetAbandonedCartStats(ctx context.Context, startDate, endDate time.Time) (*contract.AbandonedCartReport, *exception.AppError) {
+	type AbandonedStats struct {
+		AbandonedCarts int64
+		AbandonedValue *utils.GormDecimal
+		RemindersSent  int64
+		RecoveredCarts int64
+		RecoveredValue *utils.GormDecimal
+	}
+	var stats AbandonedStats
+	err := dbFromContext(ctx, r.db).Model(&entities.AbandonedCart{}).
+		Select("COUNT(id) as abandoned_carts, COALESCE(SUM(value), 0) as abandoned_value, "+
+			"COUNT(reminder_sent_at) as reminders_sent, COUNT(recovered_at) as recovered_carts, "+
+			"COALESCE(SUM(CASE WHEN recovered_at IS NOT NULL THEN value ELSE 0 END), 0) as recovered_value").
+		Where("abandoned_at BETWEEN ? AND ?", startDate, endDate).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get abandoned cart stats")
+	}
+
+	report := contract.AbandonedCartReport{
+		AbandonedCarts: stats.AbandonedCarts,
+		AbandonedValue: stats.AbandonedValue,
+		RemindersSent:  stats.RemindersSent,
+		RecoveredCarts: stats.RecoveredCarts,
+		RecoveredValue: stats.RecoveredValue,
+		PeriodStart:    startDate,
+		PeriodEnd:      endDate,
+	}
+
+	err = dbFromContext(ctx, r.db).Model(&entities.Order{}).
+		Where("created_at BETWEEN ? AND ?", startDate, endDate).
+		Where("NOT EXISTS (SELECT 1 FROM abandoned_carts WHERE abandoned_carts.order_id = orders.id)").
+		Count(&report.CheckedOutCarts).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get checked out carts")
+	}
+	return &report, nil
+}
+
+// GetMostAbandonedItems retrieves the menu items that were in the most carts abandoned in a period
+func (r *reportRepository) GetMostAbandonedItems(ctx context.Context, startDate, endDate time.Time, limit int) ([]contract.AbandonedItem, *exception.AppError) {
+	results := []contract.AbandonedItem{}
+	err := dbFromContext(ctx, r.db).Model(&entities.AbandonedCartItem{}).
+		Select("abandoned_cart_items.menu_id, MAX(abandoned_cart_items.menu_name) as menu_name, "+
+			"COUNT(DISTINCT abandoned_cart_items.abandoned_cart_id) as times_abandoned, SUM(abandoned_cart_items.quantity) as quantity, "+
+			"SUM(abandoned_cart_items.price * abandoned_cart_items.quantity) as value").
+		Joins("JOIN abandoned_carts ON abandoned_carts.id = abandoned_cart_items.abandoned_cart_id").
+		Where("abandoned_carts.abandoned_at BETWEEN ? AND ?", startDate, endDate).
+		Group("abandoned_cart_items.menu_id").
+		Order("times_abandoned DESC, quantity DESC").
+		Limit(limit).
+		Scan(&results).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get most abandoned items")
+	}
+	return results, nil
 }
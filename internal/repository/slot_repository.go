@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"time"
+)
+
+// slotRepository implements the contract.SlotRepository interface
+type slotRepository struct {
+	db *gorm.DB
+}
+
+// NewSlotRepository creates a new instance of the slot repository
+func NewSlotRepository(db *gorm.DB) contract.SlotRepository {
+	return &slotRepository{db: db}
+}
+
+// LockSlotBooking retrieves the booking of a slot, creating it when needed, and locks its row
+func (r *slotRepository) LockSlotBooking(ctx context.Context, slotStart time.Time) (*entities.SlotBooking, *exception.AppError) {
+	db := dbFromContext(ctx, r.db)
+
+	// Concurrent first bookings of a slot race on the unique index; the loser keeps the winner's row
+	booking := entities.SlotBooking{SlotStart: slotStart}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&booking).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to create slot booking")
+	}
+
+	var locked entities.SlotBooking
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("slot_start = ?", slotStart).
+		First(&locked).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to lock slot booking")
+	}
+	return &locked, nil
+}
+
+// UpdateSlotBookingCounts stores the order and item counts of a booking
+func (r *slotRepository) UpdateSlotBookingCounts(ctx context.Context, booking *entities.SlotBooking) *exception.AppError {
+	err := dbFromContext(ctx, r.db).Model(&entities.SlotBooking{}).
+		Where("id = ?", booking.ID).
+		Updates(map[string]interface{}{"order_count": booking.OrderCount, "item_count": booking.ItemCount}).Error
+	if err != nil {
+		return exception.NewAppError(err, "failed to update slot booking")
+	}
+	return nil
+}
+
+// GetSlotBookings retrieves the bookings of the slots starting in [from, to)
+func (r *slotRepository) GetSlotBookings(ctx context.Context, from, to time.Time) ([]entities.SlotBooking, *exception.AppError) {
+	var bookings []entities.SlotBooking
+	err := dbFromContext(ctx, r.db).
+		Where("slot_start >= ? AND slot_start < ?", from, to).
+		Order("slot_start ASC").
+		Find(&bookings).Error
+	if err != nil {
+		return nil, exception.NewAppError(err, "failed to get slot bookings")
+	}
+	return bookings, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// stockHoldRepository implements the contract.StockHoldRepository interface
+type stockHoldRepository struct {
+	db *gorm.DB
+}
+
+// NewStockHoldRepository creates a new instance of the stock hold repository
+func NewStockHoldRepository(db *gorm.DB) contract.StockHoldRepository {
+	return &stockHoldRepository{db: db}
+}
+
+// heldQuantity is one row of a sum of holds
+type heldQuantity struct {
+	ID       utils.BinaryUUID
+	Quantity int
+}
+
+// GetHeldStock sums the active holds on menu items and options, optionally leaving out one cart
+func (r *stockHoldRepository) GetHeldStock(ctx context.Context, menuIDs, optionIDs []utils.BinaryUUID, now time.Time, exceptCartID *utils.BinaryUUID) (*contract.HeldStock, *exception.AppError) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		if exceptCartID != nil {
+			return db.Where("stock_holds.cart_id <> ?", *exceptCartID)
+		}
+		return db
+	}
+	return r.sumHolds(ctx, menuIDs, optionIDs, true, now, scope)
+}
+
+// GetCartHeldStock sums the active holds of one cart
+func (r *stockHoldRepository) GetCartHeldStock(ctx context.Context, cartID utils.BinaryUUID, now time.Time) (*contract.HeldStock, *exception.AppError) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		return db.Where("stock_holds.cart_id = ?", cartID)
+	}
+	return r.sumHolds(ctx, nil, nil, false, now, scope)
+}
+
+// sumHolds sums the holds active at now per menu item and per option, only for the given IDs when
+// filterIDs is set. The options of a hold are those chosen for its cart item.
+func (r *stockHoldRepository) sumHolds(ctx context.Context, menuIDs, optionIDs []utils.BinaryUUID, filterIDs bool, now time.Time, scope func(*gorm.DB) *gorm.DB) (*contract.HeldStock, *exception.AppError) {
+	held := &contract.HeldStock{
+		Menus:   make(map[utils.BinaryUUID]int),
+		Options: make(map[utils.BinaryUUID]int),
+	}
+
+	if !filterIDs || len(menuIDs) > 0 {
+		var rows []heldQuantity
+		query := dbFromContext(ctx, r.db).Model(&entities.StockHold{}).
+			Select("stock_holds.menu_id AS id, SUM(stock_holds.quantity) AS quantity").
+			Where("stock_holds.expires_at > ?", now).
+			Scopes(scope)
+		if filterIDs {
+			query = query.Where("stock_holds.menu_id IN ?", menuIDs)
+		}
+		if err := query.Group("stock_holds.menu_id").Scan(&rows).Error; err != nil {
+			return nil, exception.NewAppError(err, "failed to sum held menu stock")
+		}
+		for _, row := range rows {
+			held.Menus[row.ID] = row.Quantity
+		}
+	}
+
+	if !filterIDs || len(optionIDs) > 0 {
+		var rows []heldQuantity
+		query := dbFromContext(ctx, r.db).Model(&entities.StockHold{}).
+			Select("cart_item_options.option_id AS id, SUM(stock_holds.quantity) AS quantity").
+			Joins("JOIN cart_item_options ON cart_item_options.cart_item_id = stock_holds.cart_item_id").
+			Where("stock_holds.expires_at > ?", now).
+			Scopes(scope)
+		if filterIDs {
+			query = query.Where("cart_item_options.option_id IN ?", optionIDs)
+		}
+		if err := query.Group("cart_item_options.option_id").Scan(&rows).Error; err != nil {
+			return nil, exception.NewAppError(err, "failed to sum held option stock")
+		}
+		for _, row := range rows {
+			held.Options[row.ID] = row.Quantity
+		}
+	}
+
+	return held, nil
+}
+
+// ReplaceCartHolds deletes the holds of a cart and stores the given ones in their place
+func (r *stockHoldRepository) ReplaceCartHolds(ctx context.Context, cartID utils.BinaryUUID, holds []entities.StockHold) *exception.AppError {
+	if err := r.DeleteCartHolds(ctx, cartID); err != nil {
+		return err
+	}
+	if len(holds) == 0 {
+		return nil
+	}
+	if err := dbFromContext(ctx, r.db).Omit(clause.Associations).Create(&holds).Error; err != nil {
+		return exception.NewAppError(err, "failed to create stock holds")
+	}
+	return nil
+}
+
+// DeleteCartHolds removes all holds of a cart
+func (r *stockHoldRepository) DeleteCartHolds(ctx context.Context, cartID utils.BinaryUUID) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Where("cart_id = ?", cartID).Delete(&entities.StockHold{}).Error; err != nil {
+		return exception.NewAppError(err, "failed to delete cart stock holds")
+	}
+	return nil
+}
+
+// DeleteExpiredHolds removes the holds that have expired by now
+func (r *stockHoldRepository) DeleteExpiredHolds(ctx context.Context, now time.Time) (int64, *exception.AppError) {
+	result := dbFromContext(ctx, r.db).Where("expires_at <= ?", now).Delete(&entities.StockHold{})
+	if result.Error != nil {
+		return 0, exception.NewAppError(result.Error, "failed to delete expired stock holds")
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// taxRuleRepository implements the contract.TaxRuleRepository interface
+type taxRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewTaxRuleRepository creates a new instance of the tax rule repository
+func NewTaxRuleRepository(db *gorm.DB) contract.TaxRuleRepository {
+	return &taxRuleRepository{db: db}
+}
+
+// CreateTaxRule creates a new tax rule
+func (r *taxRuleRepository) CreateTaxRule(ctx context.Context, rule *entities.TaxRule) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Create(rule).Error; err != nil {
+		return exception.NewAppError(err, "failed to create tax rule")
+	}
+	return nil
+}
+
+// GetTaxRuleByID retrieves a tax rule by its ID
+func (r *taxRuleRepository) GetTaxRuleByID(ctx context.Context, id utils.BinaryUUID) (*entities.TaxRule, *exception.AppError) {
+	var rule entities.TaxRule
+	if err := dbFromContext(ctx, r.db).First(&rule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, exception.NewAppError(err, "tax rule not found", exception.CodeNotFound)
+		}
+		return nil, exception.NewAppError(err, "failed to get tax rule")
+	}
+	return &rule, nil
+}
+
+// GetAllTaxRules retrieves all tax rules
+func (r *taxRuleRepository) GetAllTaxRules(ctx context.Context) ([]entities.TaxRule, *exception.AppError) {
+	var rules []entities.TaxRule
+	if err := dbFromContext(ctx, r.db).Order("name ASC").Find(&rules).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to get tax rules")
+	}
+	return rules, nil
+}
+
+// GetActiveTaxRules retrieves the active tax rules, in a stable order
+func (r *taxRuleRepository) GetActiveTaxRules(ctx context.Context) ([]entities.TaxRule, *exception.AppError) {
+	var rules []entities.TaxRule
+	if err := dbFromContext(ctx, r.db).Where("is_active = ?", true).Order("name ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, exception.NewAppError(err, "failed to get active tax rules")
+	}
+	return rules, nil
+}
+
+// UpdateTaxRule updates an existing tax rule
+func (r *taxRuleRepository) UpdateTaxRule(ctx context.Context, rule *entities.TaxRule) *exception.AppError {
+	if err := dbFromContext(ctx, r.db).Save(rule).Error; err != nil {
+		return exception.NewAppError(err, "failed to update tax rule")
+	}
+	return nil
+}
+
+// DeleteTaxRule deletes a tax rule
+func (r *taxRuleRepository) DeleteTaxRule(ctx context.Context, id utils.BinaryUUID) *exception.AppError {
+	result := dbFromContext(ctx, r.db).Delete(&entities.TaxRule{}, "id = ?", id)
+	if result.Error != nil {
+		return exception.NewAppError(result.Error, "failed to delete tax rule")
+	}
+	if result.RowsAffected == 0 {
+		return exception.NewAppError(nil, "tax rule not found", exception.CodeNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/exception"
+)
+
+// txContextKey is the context key under which the active transaction is stored
+type txContextKey struct{}
+
+// txState is the active transaction together with the callbacks to run once it commits
+type txState struct {
+	tx          *gorm.DB
+	afterCommit []func()
+}
+
+// transactionManager implements the contract.TransactionManager interface
+type transactionManager struct {
+	db *gorm.DB
+}
+
+// NewTransactionManager creates a new instance of the transaction manager
+func NewTransactionManager(db *gorm.DB) contract.TransactionManager {
+	return &transactionManager{db: db}
+}
+
+// WithinTransaction runs fn inside a database transaction shared through the context
+func (m *transactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) *exception.AppError) *exception.AppError {
+	// Join the outer transaction if one is already running
+	if _, ok := ctx.Value(txContextKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	state := &txState{}
+	var appErr *exception.AppError
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		state.tx = tx
+		if appErr = fn(context.WithValue(ctx, txContextKey{}, state)); appErr != nil {
+			return appErr
+		}
+		return nil
+	})
+
+	if appErr != nil {
+		return appErr
+	}
+	if err != nil {
+		return exception.NewAppError(err, "failed to commit transaction", exception.CodeDatabaseError)
+	}
+
+	for _, callback := range state.afterCommit {
+		callback()
+	}
+	return nil
+}
+
+// WithinSavepoint runs fn inside a savepoint of the transaction in ctx, which gorm creates for a
+// transaction nested in another
+func (m *transactionManager) WithinSavepoint(ctx context.Context, fn func(ctx context.Context) *exception.AppError) *exception.AppError {
+	state, ok := ctx.Value(txContextKey{}).(*txState)
+	if !ok {
+		return m.WithinTransaction(ctx, fn)
+	}
+
+	outer, callbacks := state.tx, len(state.afterCommit)
+	var appErr *exception.AppError
+	err := outer.Transaction(func(tx *gorm.DB) error {
+		state.tx = tx
+		appErr = fn(ctx)
+		if appErr != nil {
+			return appErr
+		}
+		return nil
+	})
+	state.tx = outer
+
+	if appErr != nil {
+		// What fn deferred until the commit was rolled back with it
+		state.afterCommit = state.afterCommit[:callbacks]
+		return appErr
+	}
+	if err != nil {
+		state.afterCommit = state.afterCommit[:callbacks]
+		return exception.NewAppError(err, "failed to release savepoint", exception.CodeDatabaseError)
+	}
+	return nil
+}
+
+// AfterCommit defers fn until the transaction in ctx has committed, or runs it right away
+func (m *transactionManager) AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txContextKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// dbFromContext returns the transaction stored in ctx, or db when no transaction is active
+func dbFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if state, ok := ctx.Value(txContextKey{}).(*txState); ok {
+		return state.tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -22,7 +22,7 @@ func NewUserRepository(db *gorm.DB) contract.UserRepository {
 
 // CreateUser creates a new user in the database
 func (r *userRepository) CreateUser(ctx context.Context, user *entities.User) *exception.AppError {
-	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Create(user).Error; err != nil {
 		return exception.NewAppError(err, "failed to create user")
 	}
 	return nil
@@ -31,7 +31,7 @@ func (r *userRepository) CreateUser(ctx context.Context, user *entities.User) *e
 // GetUserByID retrieves a user by their ID
 func (r *userRepository) GetUserByID(ctx context.Context, id utils.BinaryUUID) (*entities.User, *exception.AppError) {
 	var user entities.User
-	if err := r.db.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).First(&user, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, exception.NewAppError(err, "user not found")
 		}
@@ -43,7 +43,7 @@ func (r *userRepository) GetUserByID(ctx context.Context, id utils.BinaryUUID) (
 // GetUserByEmail retrieves a user by their email address
 func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*entities.User, *exception.AppError) {
 	var user entities.User
-	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, exception.NewAppError(err, "user not found")
 		}
@@ -54,7 +54,7 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*ent
 
 // UpdateUser updates an existing user's information
 func (r *userRepository) UpdateUser(ctx context.Context, user *entities.User) *exception.AppError {
-	if err := r.db.WithContext(ctx).Save(user).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Save(user).Error; err != nil {
 		return exception.NewAppError(err, "failed to update user")
 	}
 	return nil
@@ -63,7 +63,7 @@ func (r *userRepository) UpdateUser(ctx context.Context, user *entities.User) *e
 // DeleteUser soft deletes a user (if the entity has gorm.DeletedAt field)
 // Note: The current User entity does not have soft delete. This will be a hard delete.
 func (r *userRepository) DeleteUser(ctx context.Context, id utils.BinaryUUID) *exception.AppError {
-	if err := r.db.WithContext(ctx).Delete(&entities.User{}, "id = ?", id).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Delete(&entities.User{}, "id = ?", id).Error; err != nil {
 		return exception.NewAppError(err, "failed to delete user")
 	}
 	return nil
@@ -72,7 +72,7 @@ func (r *userRepository) DeleteUser(ctx context.Context, id utils.BinaryUUID) *e
 // EmailExists checks if an email already exists in the database
 func (r *userRepository) EmailExists(ctx context.Context, email string) (bool, *exception.AppError) {
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&entities.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&entities.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
 		return false, exception.NewAppError(err, "failed to check if email exists")
 	}
 	return count > 0, nil
@@ -83,7 +83,7 @@ func (r *userRepository) GetUsersByRole(ctx context.Context, role entities.UserR
 	var users []entities.User
 	var count int64
 
-	query := r.db.WithContext(ctx).Model(&entities.User{}).Where("role = ?", role)
+	query := dbFromContext(ctx, r.db).Model(&entities.User{}).Where("role = ?", role)
 
 	// Get total count
 	if err := query.Count(&count).Error; err != nil {
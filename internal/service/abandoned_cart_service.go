@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"log"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+// abandonedCartBatchSize caps the carts handled per run; the rest are picked up on the next run
+const abandonedCartBatchSize = 100
+
+type abandonedCartService struct {
+	cartRepo  contract.CartRepository
+	txManager contract.TransactionManager
+	notifier  contract.CartReminderNotifier // Nil when reminders are off
+}
+
+func NewAbandonedCartService(cartRepo contract.CartRepository, txManager contract.TransactionManager, notifier contract.CartReminderNotifier) contract.AbandonedCartService {
+	return &abandonedCartService{cartRepo: cartRepo, txManager: txManager, notifier: notifier}
+}
+
+func (s *abandonedCartService) DetectAbandonedCarts(ctx context.Context, idleFor time.Duration) (int, *exception.AppError) {
+	now := time.Now()
+	carts, err := s.cartRepo.FindIdleCarts(ctx, now.Add(-idleFor), abandonedCartBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	detected := 0
+	for i := range carts {
+		cart := &carts[i]
+		abandoned := abandonedCartSnapshot(cart, now)
+		marked := false
+		err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+			var err *exception.AppError
+			marked, err = s.cartRepo.MarkCartAbandoned(ctx, abandoned)
+			return err
+		})
+		if err != nil {
+			return detected, err
+		}
+		// The customer came back in the meantime
+		if !marked {
+			continue
+		}
+		detected++
+		s.remind(ctx, cart, abandoned)
+	}
+	return detected, nil
+}
+
+// remind sends the owner of an abandoned cart a reminder, when reminders are on and the owner can
+// be reached. A failed reminder is only logged; it is not retried, so nobody gets it twice.
+func (s *abandonedCartService) remind(ctx context.Context, cart *entities.Cart, abandoned *entities.AbandonedCart) {
+	if s.notifier == nil || cart.UserID == nil {
+		return
+	}
+	if err := s.notifier.RemindAbandonedCart(ctx, &cart.User, abandoned); err != nil {
+		log.Printf("failed to send reminder for abandoned cart %s: %v", cart.ID, err)
+		return
+	}
+	if err := s.cartRepo.SetAbandonedCartReminded(ctx, abandoned.ID, time.Now()); err != nil {
+		log.Printf("failed to record reminder for abandoned cart %s: %v", cart.ID, err)
+	}
+}
+
+// abandonedCartSnapshot records what is in a cart found abandoned at the given time
+func abandonedCartSnapshot(cart *entities.Cart, at time.Time) *entities.AbandonedCart {
+	lastActivity := cart.LastActivityAt
+	if lastActivity.IsZero() {
+		lastActivity = cart.UpdatedAt // A cart from before activity was recorded
+	}
+	abandoned := &entities.AbandonedCart{
+		CartID:         cart.ID,
+		UserID:         cart.UserID,
+		Value:          utils.NewMoney(0, 2),
+		LastActivityAt: lastActivity,
+		AbandonedAt:    at,
+		Items:          make([]entities.AbandonedCartItem, 0, len(cart.CartItems)),
+	}
+	for _, item := range cart.CartItems {
+		abandoned.ItemCount += item.Quantity
+		abandoned.Value = abandoned.Value.Add(item.GetSubtotal())
+		abandoned.Items = append(abandoned.Items, entities.AbandonedCartItem{
+			MenuID:   item.MenuID,
+			MenuName: item.Menu.Name,
+			Quantity: item.Quantity,
+			Price:    item.Price,
+		})
+	}
+	return abandoned
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"strings"
+)
+
+type addressService struct {
+	addressRepo contract.AddressRepository
+	txManager   contract.TransactionManager
+}
+
+func NewAddressService(addressRepo contract.AddressRepository, txManager contract.TransactionManager) contract.AddressService {
+	return &addressService{addressRepo: addressRepo, txManager: txManager}
+}
+
+func (s *addressService) CreateAddress(ctx context.Context, userID utils.BinaryUUID, address *entities.Address) (*entities.Address, *exception.AppError) {
+	if err := validateAddress(address); err != nil {
+		return nil, err
+	}
+	address.UserID = userID
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		current, err := s.addressRepo.GetDefaultAddress(ctx, userID)
+		if err != nil {
+			return err
+		}
+		makeDefault := address.IsDefault || current == nil
+		address.IsDefault = false
+		if err := s.addressRepo.CreateAddress(ctx, address); err != nil {
+			return err
+		}
+		if makeDefault {
+			address.IsDefault = true
+			return s.addressRepo.SetDefaultAddress(ctx, userID, address.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (s *addressService) GetAddresses(ctx context.Context, userID utils.BinaryUUID) ([]entities.Address, *exception.AppError) {
+	return s.addressRepo.GetAddressesByUserID(ctx, userID)
+}
+
+func (s *addressService) GetAddress(ctx context.Context, userID, addressID utils.BinaryUUID) (*entities.Address, *exception.AppError) {
+	return s.addressRepo.GetAddressByID(ctx, userID, addressID)
+}
+
+func (s *addressService) UpdateAddress(ctx context.Context, userID utils.BinaryUUID, address *entities.Address) (*entities.Address, *exception.AppError) {
+	if err := validateAddress(address); err != nil {
+		return nil, err
+	}
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		existing, err := s.addressRepo.GetAddressByID(ctx, userID, address.ID)
+		if err != nil {
+			return err
+		}
+		makeDefault := address.IsDefault && !existing.IsDefault
+		// The default can only be moved to another address, not cleared
+		address.IsDefault = existing.IsDefault
+		address.UserID = userID
+		address.CreatedAt = existing.CreatedAt
+		if err := s.addressRepo.UpdateAddress(ctx, address); err != nil {
+			return err
+		}
+		if makeDefault {
+			address.IsDefault = true
+			return s.addressRepo.SetDefaultAddress(ctx, userID, address.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (s *addressService) DeleteAddress(ctx context.Context, userID, addressID utils.BinaryUUID) *exception.AppError {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		address, err := s.addressRepo.GetAddressByID(ctx, userID, addressID)
+		if err != nil {
+			return err
+		}
+		if err := s.addressRepo.DeleteAddress(ctx, userID, addressID); err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		remaining, err := s.addressRepo.GetAddressesByUserID(ctx, userID)
+		if err != nil || len(remaining) == 0 {
+			return err
+		}
+		return s.addressRepo.SetDefaultAddress(ctx, userID, remaining[0].ID)
+	})
+}
+
+func (s *addressService) SetDefaultAddress(ctx context.Context, userID, addressID utils.BinaryUUID) (*entities.Address, *exception.AppError) {
+	var address *entities.Address
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		var err *exception.AppError
+		if address, err = s.addressRepo.GetAddressByID(ctx, userID, addressID); err != nil {
+			return err
+		}
+		address.IsDefault = true
+		return s.addressRepo.SetDefaultAddress(ctx, userID, addressID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (s *addressService) ResolveDeliveryAddress(ctx context.Context, userID utils.BinaryUUID, addressID *utils.BinaryUUID) (*entities.Address, *exception.AppError) {
+	if addressID != nil {
+		return s.addressRepo.GetAddressByID(ctx, userID, *addressID)
+	}
+	address, err := s.addressRepo.GetDefaultAddress(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if address == nil {
+		return nil, exception.NewValidationError("choose a delivery address or add one to your address book")
+	}
+	return address, nil
+}
+
+// validateAddress normalizes the country code and checks the coordinates
+func validateAddress(address *entities.Address) *exception.AppError {
+	address.Country = strings.ToUpper(address.Country)
+	if (address.Latitude == nil) != (address.Longitude == nil) {
+		return exception.NewValidationError("latitude and longitude must be given together")
+	}
+	if address.Latitude != nil && (*address.Latitude < -90 || *address.Latitude > 90 || *address.Longitude < -180 || *address.Longitude > 180) {
+		return exception.NewValidationError("coordinates are out of range")
+	}
+	return nil
+}
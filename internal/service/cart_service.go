@@ -2,22 +2,28 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"shopify-app/internal/contract"
 	"shopify-app/internal/entities"
 	"shopify-app/internal/exception"
 	"shopify-app/internal/utils"
+	"time"
 )
 
 type cartService struct {
-	cartRepo contract.CartRepository
-	menuRepo contract.MenuRepository
+	cartRepo     contract.CartRepository
+	menuRepo     contract.MenuRepository
+	taxSvc       contract.TaxService
+	promotionSvc contract.PromotionService
+	holdSvc      contract.StockHoldService
+	txManager    contract.TransactionManager
 }
 
-func NewCartService(cartRepo contract.CartRepository, menuRepo contract.MenuRepository) contract.CartService {
-	return &cartService{cartRepo: cartRepo, menuRepo: menuRepo}
+func NewCartService(cartRepo contract.CartRepository, menuRepo contract.MenuRepository, taxSvc contract.TaxService, promotionSvc contract.PromotionService, holdSvc contract.StockHoldService, txManager contract.TransactionManager) contract.CartService {
+	return &cartService{cartRepo: cartRepo, menuRepo: menuRepo, taxSvc: taxSvc, promotionSvc: promotionSvc, holdSvc: holdSvc, txManager: txManager}
 }
 
-func (s *cartService) AddItemToCart(ctx context.Context, userID, menuID utils.BinaryUUID, quantity int) *exception.AppError {
+func (s *cartService) AddItemToCart(ctx context.Context, owner contract.CartOwner, menuID utils.BinaryUUID, quantity int, optionIDs []utils.BinaryUUID) *exception.AppError {
 	if quantity <= 0 {
 		return exception.NewAppError(nil, "quantity must be positive")
 	}
@@ -31,19 +37,64 @@ func (s *cartService) AddItemToCart(ctx context.Context, userID, menuID utils.Bi
 		return exception.NewAppError(nil, "item is out of stock")
 	}
 
-	cart, err := s.cartRepo.GetOrCreateCart(ctx, userID)
+	selected, selectErr := menu.SelectOptions(optionIDs)
+	if selectErr != nil {
+		return exception.NewValidationError(selectErr.Error())
+	}
+
+	// The cart line price is the menu price plus the price deltas of the chosen options
+	price := menu.Price
+	options := make([]entities.CartItemOption, 0, len(selected))
+	for _, choice := range selected {
+		if !choice.Option.IsInStock(quantity) {
+			return exception.NewAppError(nil, fmt.Sprintf("option %s is out of stock", choice.Option.Name))
+		}
+		price = price.Add(choice.Option.PriceDelta)
+		options = append(options, entities.CartItemOption{
+			OptionID:   choice.Option.ID,
+			GroupName:  choice.Group.Name,
+			OptionName: choice.Option.Name,
+			PriceDelta: choice.Option.PriceDelta,
+		})
+	}
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		cart, err := s.cartRepo.GetOrCreateCart(ctx, owner)
+		if err != nil {
+			return err
+		}
+		if err := s.cartRepo.AddItemToCart(ctx, cart.ID, menuID, quantity, price, options); err != nil {
+			return err
+		}
+		return s.cartChanged(ctx, owner)
+	})
+}
+
+// cartChanged records the customer's activity after the contents of their cart changed, and renews
+// the stock holds of the cart when holds are enabled. Must run inside the transaction that changed
+// the cart, so a failed hold undoes the change.
+func (s *cartService) cartChanged(ctx context.Context, owner contract.CartOwner) *exception.AppError {
+	if err := s.cartRepo.TouchCart(ctx, owner, time.Now()); err != nil {
+		return err
+	}
+	if !s.holdSvc.Enabled() {
+		return nil
+	}
+	cart, err := s.cartRepo.GetCartWithItems(ctx, owner)
 	if err != nil {
 		return err
 	}
-
-	return s.cartRepo.AddItemToCart(ctx, cart.ID, menuID, quantity, menu.Price)
+	return s.holdSvc.HoldCart(ctx, cart)
 }
 
-func (s *cartService) GetUserCart(ctx context.Context, userID utils.BinaryUUID) (*entities.Cart, *utils.GormDecimal, *exception.AppError) {
-	cart, err := s.cartRepo.GetCartWithItems(ctx, userID)
+func (s *cartService) GetUserCart(ctx context.Context, owner contract.CartOwner) (*entities.Cart, *utils.GormDecimal, *exception.AppError) {
+	cart, err := s.cartRepo.GetCartWithItems(ctx, owner)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := s.inspectCart(ctx, cart); err != nil {
+		return nil, nil, err
+	}
 
 	total, err := s.cartRepo.GetCartTotal(ctx, cart.ID)
 	if err != nil {
@@ -53,12 +104,12 @@ func (s *cartService) GetUserCart(ctx context.Context, userID utils.BinaryUUID)
 	return cart, total, nil
 }
 
-func (s *cartService) UpdateCartItem(ctx context.Context, userID, cartItemID utils.BinaryUUID, quantity int) *exception.AppError {
+func (s *cartService) UpdateCartItem(ctx context.Context, owner contract.CartOwner, cartItemID utils.BinaryUUID, quantity int) *exception.AppError {
 	if quantity <= 0 {
-		return s.RemoveCartItem(ctx, userID, cartItemID)
+		return s.RemoveCartItem(ctx, owner, cartItemID)
 	}
 
-	owned, err := s.cartRepo.ValidateCartOwnership(ctx, cartItemID, userID)
+	owned, err := s.cartRepo.ValidateCartOwnership(ctx, cartItemID, owner)
 	if err != nil {
 		return err
 	}
@@ -76,15 +127,20 @@ func (s *cartService) UpdateCartItem(ctx context.Context, userID, cartItemID uti
 		return err
 	}
 
-	if !menu.IsInStock(quantity) {
+	if !menu.IsInStock(quantity) || !optionsInStock(menu, item, quantity) {
 		return exception.NewAppError(nil, "not enough stock")
 	}
 
-	return s.cartRepo.UpdateCartItemQuantity(ctx, cartItemID, quantity)
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		if err := s.cartRepo.UpdateCartItemQuantity(ctx, cartItemID, quantity); err != nil {
+			return err
+		}
+		return s.cartChanged(ctx, owner)
+	})
 }
 
-func (s *cartService) RemoveCartItem(ctx context.Context, userID, cartItemID utils.BinaryUUID) *exception.AppError {
-	owned, err := s.cartRepo.ValidateCartOwnership(ctx, cartItemID, userID)
+func (s *cartService) RemoveCartItem(ctx context.Context, owner contract.CartOwner, cartItemID utils.BinaryUUID) *exception.AppError {
+	owned, err := s.cartRepo.ValidateCartOwnership(ctx, cartItemID, owner)
 	if err != nil {
 		return err
 	}
@@ -92,15 +148,30 @@ func (s *cartService) RemoveCartItem(ctx context.Context, userID, cartItemID uti
 		return exception.NewAppError(nil, "cart item not found or not owned by user")
 	}
 
-	return s.cartRepo.RemoveCartItem(ctx, cartItemID)
+	// The removed line's hold goes with it; the rest of the cart is held for longer
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		if err := s.cartRepo.RemoveCartItem(ctx, cartItemID); err != nil {
+			return err
+		}
+		return s.cartChanged(ctx, owner)
+	})
+}
+
+func (s *cartService) ClearCart(ctx context.Context, owner contract.CartOwner) *exception.AppError {
+	return s.cartRepo.ClearCart(ctx, owner)
 }
 
-func (s *cartService) ClearCart(ctx context.Context, userID utils.BinaryUUID) *exception.AppError {
-	return s.cartRepo.ClearCart(ctx, userID)
+func (s *cartService) CompleteCheckout(ctx context.Context, owner contract.CartOwner, cart *entities.Cart, orderID utils.BinaryUUID) *exception.AppError {
+	if cart.AbandonedAt != nil {
+		if err := s.cartRepo.RecoverAbandonedCart(ctx, cart.ID, orderID, time.Now()); err != nil {
+			return err
+		}
+	}
+	return s.cartRepo.ClearCart(ctx, owner)
 }
 
-func (s *cartService) ValidateCartForCheckout(ctx context.Context, userID utils.BinaryUUID) (*entities.Cart, *utils.GormDecimal, *exception.AppError) {
-	cart, total, err := s.GetUserCart(ctx, userID)
+func (s *cartService) ValidateCartForCheckout(ctx context.Context, owner contract.CartOwner) (*entities.Cart, *utils.GormDecimal, *exception.AppError) {
+	cart, total, err := s.GetUserCart(ctx, owner)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -109,37 +180,334 @@ func (s *cartService) ValidateCartForCheckout(ctx context.Context, userID utils.
 		return nil, nil, exception.NewAppError(nil, "cart is empty")
 	}
 
-	for _, item := range cart.CartItems {
-		if !item.Menu.IsInStock(item.Quantity) {
+	var changed []contract.CartLineChange
+	for i := range cart.CartItems {
+		item := &cart.CartItems[i]
+		if !item.IsOrderable() || item.HasIssue(entities.CartItemInsufficientStock) {
 			return nil, nil, exception.NewAppError(nil, "one or more items are out of stock")
 		}
+		if item.HasIssue(entities.CartItemPriceChanged) {
+			changed = append(changed, contract.CartLineChange{
+				CartItemID: item.ID,
+				MenuID:     item.MenuID,
+				MenuName:   item.Menu.Name,
+				Issues:     item.Issues,
+				OldPrice:   item.Price,
+				NewPrice:   item.CurrentPrice,
+			})
+		}
+	}
+	// Never charge a price the customer has not seen; they confirm the new one by revalidating
+	if len(changed) > 0 {
+		return nil, nil, exception.NewPriceChangedError("prices in the cart have changed, revalidate the cart before checking out", changed)
 	}
 
 	return cart, total, nil
 }
 
-func (s *cartService) GetCartItemCount(ctx context.Context, userID utils.BinaryUUID) (int, *exception.AppError) {
-	cart, err := s.cartRepo.GetCartWithItems(ctx, userID)
+// inspectCart checks every item of a cart against the current menu and stock, and flags what
+// changed since it was added. The stock left after other carts' holds is handed out line by
+// line, so when lines share a menu item or option the later ones come up short.
+func (s *cartService) inspectCart(ctx context.Context, cart *entities.Cart) *exception.AppError {
+	menuLines := make(map[utils.BinaryUUID]int)
+	optionLines := make(map[utils.BinaryUUID]int)
+	for _, item := range cart.CartItems {
+		menuLines[item.MenuID]++
+		for _, option := range item.Options {
+			optionLines[option.OptionID]++
+		}
+	}
+	held, err := s.holdSvc.HeldByOthers(ctx, cart.ID, sortedIDs(menuLines), sortedIDs(optionLines))
+	if err != nil {
+		return err
+	}
+
+	menuLeft := make(map[utils.BinaryUUID]int)
+	optionLeft := make(map[utils.BinaryUUID]int)
+	for i := range cart.CartItems {
+		item := &cart.CartItems[i]
+		item.Issues, item.CurrentPrice, item.AvailableQuantity = nil, nil, nil
+
+		price := inspectCartItem(item)
+		if !item.IsOrderable() {
+			continue
+		}
+		if price.Cmp(item.Price) != 0 {
+			item.Issues = append(item.Issues, entities.CartItemPriceChanged)
+			item.CurrentPrice = price
+		}
+
+		left, ok := menuLeft[item.MenuID]
+		if !ok {
+			left = available(item.Menu.Stock, held.Menus[item.MenuID])
+		}
+		allowed := min(item.Quantity, left)
+		for _, chosen := range item.Options {
+			_, option, _ := item.Menu.FindOption(chosen.OptionID)
+			if !option.TracksStock() {
+				continue
+			}
+			if _, ok := optionLeft[option.ID]; !ok {
+				optionLeft[option.ID] = available(*option.Stock, held.Options[option.ID])
+			}
+			allowed = min(allowed, optionLeft[option.ID])
+		}
+
+		menuLeft[item.MenuID] = left - allowed
+		for _, chosen := range item.Options {
+			if _, ok := optionLeft[chosen.OptionID]; ok {
+				optionLeft[chosen.OptionID] -= allowed
+			}
+		}
+		if allowed < item.Quantity {
+			item.Issues = append(item.Issues, entities.CartItemInsufficientStock)
+			item.AvailableQuantity = &allowed
+		}
+	}
+	return nil
+}
+
+// inspectCartItem flags a cart item whose menu item or options were deactivated or deleted, and
+// returns what its unit price is now. The menu must be loaded including deleted ones.
+func inspectCartItem(item *entities.CartItem) *utils.GormDecimal {
+	menu := &item.Menu
+	if menu.ID != item.MenuID || menu.DeletedAt.Valid {
+		item.Issues = append(item.Issues, entities.CartItemDeleted)
+		return nil
+	}
+	if !menu.IsActive {
+		item.Issues = append(item.Issues, entities.CartItemUnavailable)
+	}
+
+	price := menu.Price
+	for _, chosen := range item.Options {
+		_, option, ok := menu.FindOption(chosen.OptionID)
+		if !ok {
+			item.Issues = append(item.Issues, entities.CartItemDeleted)
+			continue
+		}
+		if !option.IsActive && !item.HasIssue(entities.CartItemUnavailable) {
+			item.Issues = append(item.Issues, entities.CartItemUnavailable)
+		}
+		price = price.Add(option.PriceDelta)
+	}
+	return price
+}
+
+// optionsInStock checks that every option chosen for a cart item is still offered by the
+// menu item and available for the given quantity. The menu's option groups must be loaded.
+func optionsInStock(menu *entities.Menu, item *entities.CartItem, quantity int) bool {
+	for _, chosen := range item.Options {
+		_, option, ok := menu.FindOption(chosen.OptionID)
+		if !ok || !option.IsInStock(quantity) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *cartService) PriceCart(ctx context.Context, owner contract.CartOwner, cart *entities.Cart) (*contract.CartPricing, *exception.AppError) {
+	pricedLines := make([]contract.PricedLine, 0, len(cart.CartItems))
+	for i := range cart.CartItems {
+		item := &cart.CartItems[i]
+		pricedLines = append(pricedLines, contract.PricedLine{
+			MenuID:    item.MenuID,
+			Category:  item.Menu.Category,
+			UnitPrice: item.Price,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	subtotal := linesSubtotal(pricedLines)
+	discounts, err := s.promotionSvc.CalculateDiscounts(ctx, owner.CustomerID(), cart.CouponCode, pricedLines)
+	if err != nil {
+		return nil, err
+	}
+	tax, err := taxDiscountedLines(ctx, s.taxSvc, pricedLines, discounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &contract.CartPricing{
+		Subtotal:       subtotal,
+		Discounts:      discounts.Discounts,
+		DiscountAmount: discounts.Amount,
+		LineDiscounts:  discounts.LineDiscounts,
+		CouponCode:     cart.CouponCode,
+		CouponError:    discounts.CouponError,
+		Tax:            tax,
+		Total:          tax.Total,
+	}, nil
+}
+
+func (s *cartService) ApplyCoupon(ctx context.Context, owner contract.CartOwner, code string) *exception.AppError {
+	promotion, err := s.promotionSvc.ValidateCoupon(ctx, owner.CustomerID(), code)
+	if err != nil {
+		return err
+	}
+	cart, err := s.cartRepo.GetOrCreateCart(ctx, owner)
+	if err != nil {
+		return err
+	}
+	return s.cartRepo.SetCartCouponCode(ctx, cart.ID, *promotion.Code)
+}
+
+func (s *cartService) RemoveCoupon(ctx context.Context, owner contract.CartOwner) *exception.AppError {
+	cart, err := s.cartRepo.GetOrCreateCart(ctx, owner)
+	if err != nil {
+		return err
+	}
+	return s.cartRepo.SetCartCouponCode(ctx, cart.ID, "")
+}
+
+func (s *cartService) GetCartItemCount(ctx context.Context, owner contract.CartOwner) (int, *exception.AppError) {
+	cart, err := s.cartRepo.GetCartWithItems(ctx, owner)
 	if err != nil {
 		return 0, err
 	}
 	return len(cart.CartItems), nil
 }
 
-func (s *cartService) SyncCartItemPrices(ctx context.Context, userID utils.BinaryUUID) *exception.AppError {
-	cart, err := s.cartRepo.GetCartWithItems(ctx, userID)
+func (s *cartService) SyncCartItemPrices(ctx context.Context, owner contract.CartOwner) *exception.AppError {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		cart, _, err := s.GetUserCart(ctx, owner)
+		if err != nil {
+			return err
+		}
+		for i := range cart.CartItems {
+			item := &cart.CartItems[i]
+			if item.HasIssue(entities.CartItemPriceChanged) {
+				if err := s.cartRepo.UpdateCartItemPrice(ctx, item.ID, item.CurrentPrice); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *cartService) RevalidateCart(ctx context.Context, owner contract.CartOwner) ([]contract.CartLineChange, *exception.AppError) {
+	changes := []contract.CartLineChange{}
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		cart, _, err := s.GetUserCart(ctx, owner)
+		if err != nil {
+			return err
+		}
+
+		for i := range cart.CartItems {
+			item := &cart.CartItems[i]
+			if len(item.Issues) == 0 {
+				continue
+			}
+			change := contract.CartLineChange{
+				CartItemID: item.ID,
+				MenuID:     item.MenuID,
+				MenuName:   item.Menu.Name,
+				Issues:     item.Issues,
+			}
+
+			// Nothing left to order of this line
+			if !item.IsOrderable() || (item.AvailableQuantity != nil && *item.AvailableQuantity == 0) {
+				if err := s.cartRepo.RemoveCartItem(ctx, item.ID); err != nil {
+					return err
+				}
+				change.Removed = true
+				changes = append(changes, change)
+				continue
+			}
+
+			if item.HasIssue(entities.CartItemPriceChanged) {
+				if err := s.cartRepo.UpdateCartItemPrice(ctx, item.ID, item.CurrentPrice); err != nil {
+					return err
+				}
+				change.OldPrice, change.NewPrice = item.Price, item.CurrentPrice
+			}
+			if item.HasIssue(entities.CartItemInsufficientStock) {
+				if err := s.cartRepo.UpdateCartItemQuantity(ctx, item.ID, *item.AvailableQuantity); err != nil {
+					return err
+				}
+				change.OldQuantity, change.NewQuantity = item.Quantity, *item.AvailableQuantity
+			}
+			changes = append(changes, change)
+		}
+
+		if len(changes) == 0 {
+			return nil
+		}
+		return s.cartChanged(ctx, owner)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return changes, nil
+}
 
-	for _, item := range cart.CartItems {
-		if !utils.GormDecimalEquals(*item.Price, *item.Menu.Price) {
-			item.Price = item.Menu.Price
-			if err := s.cartRepo.UpdateCartItemQuantity(ctx, item.ID, item.Quantity); err != nil {
-				// In a real app, you might want to handle this more gracefully
-				return exception.NewAppError(err, "failed to sync cart item price")
+func (s *cartService) MergeGuestCart(ctx context.Context, guestID, userID utils.BinaryUUID) (*contract.CartMergeResult, *exception.AppError) {
+	result := &contract.CartMergeResult{Merged: []contract.ReorderItem{}, Skipped: []contract.ReorderItem{}}
+	userOwner := contract.UserCartOwner(userID)
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		guestCart, err := s.cartRepo.FindCartWithItems(ctx, contract.GuestCartOwner(guestID))
+		if err != nil || guestCart == nil {
+			return err
+		}
+		// Free what the guest cart holds, or it would stand in the way of moving it to the user cart
+		if err := s.holdSvc.ReleaseCart(ctx, guestCart.ID); err != nil {
+			return err
+		}
+		userCart, err := s.cartRepo.GetCartWithItems(ctx, userOwner)
+		if err != nil {
+			return err
+		}
+
+		for i := range guestCart.CartItems {
+			item := &guestCart.CartItems[i]
+			merged := contract.ReorderItem{MenuID: item.MenuID, MenuName: item.Menu.Name, Quantity: item.Quantity}
+
+			// Only top up to the larger quantity; AddItemToCart checks the menu and options again. A
+			// line that can't be added, e.g. because its hold conflicts, is rolled back on its own.
+			missing := item.Quantity - sameCartLine(userCart, item)
+			if missing > 0 {
+				err := s.txManager.WithinSavepoint(ctx, func(ctx context.Context) *exception.AppError {
+					return s.AddItemToCart(ctx, userOwner, item.MenuID, missing, item.OptionIDs())
+				})
+				if err != nil {
+					// Sold out, taken off the menu or its options changed, for example
+					merged.Reason = err.Message
+					result.Skipped = append(result.Skipped, merged)
+					continue
+				}
+			}
+			result.Merged = append(result.Merged, merged)
+		}
+
+		if userCart.CouponCode == "" && guestCart.CouponCode != "" {
+			// The coupon may not be usable by this user, e.g. when they have used it up already
+			if _, err := s.promotionSvc.ValidateCoupon(ctx, userID, guestCart.CouponCode); err == nil {
+				if err := s.cartRepo.SetCartCouponCode(ctx, userCart.ID, guestCart.CouponCode); err != nil {
+					return err
+				}
 			}
 		}
+
+		return s.cartRepo.DeleteCart(ctx, guestCart.ID)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return result, nil
+}
+
+// sameCartLine returns the quantity of the line in cart with the same menu item and options as item
+func sameCartLine(cart *entities.Cart, item *entities.CartItem) int {
+	for _, line := range cart.CartItems {
+		if line.MenuID == item.MenuID && line.OptionsKey == item.OptionsKey {
+			return line.Quantity
+		}
+	}
+	return 0
+}
+
+func (s *cartService) PurgeStaleCarts(ctx context.Context, retention time.Duration) (int64, *exception.AppError) {
+	return s.cartRepo.DeleteStaleCarts(ctx, time.Now().Add(-retention))
 }
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"sort"
+)
+
+type deliveryZoneService struct {
+	zoneRepo  contract.DeliveryZoneRepository
+	txManager contract.TransactionManager
+	store     utils.GeoPoint
+}
+
+func NewDeliveryZoneService(zoneRepo contract.DeliveryZoneRepository, txManager contract.TransactionManager, store utils.GeoPoint) contract.DeliveryZoneService {
+	return &deliveryZoneService{zoneRepo: zoneRepo, txManager: txManager, store: store}
+}
+
+func (s *deliveryZoneService) CreateDeliveryZone(ctx context.Context, zone *entities.DeliveryZone) (*entities.DeliveryZone, *exception.AppError) {
+	if err := validateDeliveryZone(zone); err != nil {
+		return nil, err
+	}
+	if err := s.zoneRepo.CreateDeliveryZone(ctx, zone); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (s *deliveryZoneService) UpdateDeliveryZone(ctx context.Context, zone *entities.DeliveryZone) (*entities.DeliveryZone, *exception.AppError) {
+	if err := validateDeliveryZone(zone); err != nil {
+		return nil, err
+	}
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		existing, err := s.zoneRepo.GetDeliveryZoneByID(ctx, zone.ID)
+		if err != nil {
+			return err
+		}
+		if existing.DeletedAt.Valid {
+			return exception.NewAppError(nil, "delivery zone not found", exception.CodeNotFound)
+		}
+		zone.CreatedAt = existing.CreatedAt
+		return s.zoneRepo.UpdateDeliveryZone(ctx, zone)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func (s *deliveryZoneService) DeleteDeliveryZone(ctx context.Context, id utils.BinaryUUID) *exception.AppError {
+	return s.zoneRepo.DeleteDeliveryZone(ctx, id)
+}
+
+func (s *deliveryZoneService) GetDeliveryZones(ctx context.Context) ([]entities.DeliveryZone, *exception.AppError) {
+	return s.zoneRepo.GetAllDeliveryZones(ctx)
+}
+
+func (s *deliveryZoneService) GetDeliveryZone(ctx context.Context, id utils.BinaryUUID) (*entities.DeliveryZone, *exception.AppError) {
+	return s.zoneRepo.GetDeliveryZoneByID(ctx, id)
+}
+
+func (s *deliveryZoneService) QuoteDelivery(ctx context.Context, address *entities.PostalAddress) (*contract.DeliveryQuote, *exception.AppError) {
+	if address.Latitude == nil || address.Longitude == nil {
+		return nil, exception.NewValidationError("the delivery address needs a latitude and longitude")
+	}
+	point := utils.GeoPoint{Lat: *address.Latitude, Lng: *address.Longitude}
+	distance := int(math.Round(utils.DistanceMeters(s.store, point)))
+
+	zones, err := s.zoneRepo.GetActiveDeliveryZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range zones {
+		zone := &zones[i]
+		covered, convErr := zoneCovers(zone, point, distance)
+		if convErr != nil {
+			return nil, exception.NewAppError(convErr, "invalid area on delivery zone "+zone.Name)
+		}
+		if !covered {
+			continue
+		}
+		// A zone may reach further than its fee schedule; it doesn't deliver beyond the last tier
+		fee, ok := zone.FeeFor(distance)
+		if !ok {
+			continue
+		}
+		return &contract.DeliveryQuote{
+			ZoneID:           zone.ID,
+			ZoneName:         zone.Name,
+			DistanceMeters:   distance,
+			Fee:              fee,
+			MinOrderAmount:   zone.MinOrderAmount,
+			EstimatedMinutes: zone.EstimatedMinutes,
+		}, nil
+	}
+	return nil, exception.NewValidationError("we don't deliver to this address")
+}
+
+// zoneCovers checks if a point, distance meters from the store, lies within the zone
+func zoneCovers(zone *entities.DeliveryZone, point utils.GeoPoint, distance int) (bool, error) {
+	if zone.Type == entities.DeliveryZoneRadius {
+		return zone.RadiusMeters != nil && distance <= *zone.RadiusMeters, nil
+	}
+	area, err := utils.ParseGeoArea([]byte(zone.Area))
+	if err != nil {
+		return false, err
+	}
+	return area.Contains(point), nil
+}
+
+// validateDeliveryZone checks the area and the fee schedule, and sorts the fee tiers by distance
+func validateDeliveryZone(zone *entities.DeliveryZone) *exception.AppError {
+	switch zone.Type {
+	case entities.DeliveryZonePolygon:
+		if _, err := utils.ParseGeoArea([]byte(zone.Area)); err != nil {
+			return exception.NewValidationError(err.Error())
+		}
+		zone.RadiusMeters = nil
+	case entities.DeliveryZoneRadius:
+		if zone.RadiusMeters == nil || *zone.RadiusMeters <= 0 {
+			return exception.NewValidationError("a radius zone needs a positive radius_meters")
+		}
+		zone.Area = ""
+	default:
+		return exception.NewValidationError(fmt.Sprintf("invalid delivery zone type '%s'", zone.Type))
+	}
+
+	if zone.MinOrderAmount == nil {
+		zone.MinOrderAmount = utils.MustNewGormDecimal("0")
+	}
+	if len(zone.FeeTiers) == 0 {
+		return exception.NewValidationError("a delivery zone needs at least one fee tier")
+	}
+	sort.Slice(zone.FeeTiers, func(i, j int) bool {
+		return zone.FeeTiers[i].UpToMeters < zone.FeeTiers[j].UpToMeters
+	})
+	for i, tier := range zone.FeeTiers {
+		if i > 0 && tier.UpToMeters == zone.FeeTiers[i-1].UpToMeters {
+			return exception.NewValidationError(fmt.Sprintf("more than one fee tier up to %d meters", tier.UpToMeters))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+type idempotencyService struct {
+	idempotencyRepo contract.IdempotencyRepository
+	ttl             time.Duration
+}
+
+func NewIdempotencyService(idempotencyRepo contract.IdempotencyRepository, ttl time.Duration) contract.IdempotencyService {
+	return &idempotencyService{idempotencyRepo: idempotencyRepo, ttl: ttl}
+}
+
+func (s *idempotencyService) BeginRequest(ctx context.Context, userID utils.BinaryUUID, key, fingerprint string) (*entities.IdempotencyKey, *exception.AppError) {
+	if len(key) > 255 {
+		return nil, exception.NewValidationError("Idempotency-Key must be at most 255 characters")
+	}
+
+	// Two attempts: the second one runs after an expired key with the same value was dropped
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		created, err := s.idempotencyRepo.CreateKeyIfAbsent(ctx, &entities.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			Status:      entities.IdempotencyProcessing,
+			ExpiresAt:   now.Add(s.ttl),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			return nil, nil
+		}
+
+		existing, err := s.idempotencyRepo.GetKey(ctx, userID, key)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			// Released by a concurrent request between our insert and read
+			continue
+		}
+
+		if existing.IsExpired(now) {
+			if err := s.idempotencyRepo.DeleteKey(ctx, existing.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if existing.Fingerprint != fingerprint {
+			return nil, exception.NewValidationError("Idempotency-Key has already been used with a different request")
+		}
+		if existing.Status != entities.IdempotencyCompleted {
+			return nil, exception.NewConflictError("a request with this Idempotency-Key is still being processed")
+		}
+		return existing, nil
+	}
+
+	return nil, exception.NewConflictError("a request with this Idempotency-Key is still being processed")
+}
+
+func (s *idempotencyService) CompleteRequest(ctx context.Context, userID utils.BinaryUUID, key string, responseStatus int, responseBody []byte) *exception.AppError {
+	existing, err := s.idempotencyRepo.GetKey(ctx, userID, key)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return exception.NewAppError(nil, "idempotency key not found", exception.CodeNotFound)
+	}
+	return s.idempotencyRepo.CompleteKey(ctx, existing.ID, responseStatus, responseBody)
+}
+
+func (s *idempotencyService) ReleaseRequest(ctx context.Context, userID utils.BinaryUUID, key string) *exception.AppError {
+	existing, err := s.idempotencyRepo.GetKey(ctx, userID, key)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return nil
+	}
+	return s.idempotencyRepo.DeleteKey(ctx, existing.ID)
+}
+
+func (s *idempotencyService) PurgeExpiredKeys(ctx context.Context) (int64, *exception.AppError) {
+	return s.idempotencyRepo.DeleteExpiredKeys(ctx, time.Now())
+}
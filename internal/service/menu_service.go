@@ -1,20 +1,24 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"shopify-app/internal/contract"
 	"shopify-app/internal/entities"
 	"shopify-app/internal/exception"
 	"shopify-app/internal/utils"
+	"sort"
 )
 
 type menuService struct {
-	menuRepo contract.MenuRepository
+	menuRepo  contract.MenuRepository
+	txManager contract.TransactionManager
+	holdSvc   contract.StockHoldService
 }
 
-func NewMenuService(menuRepo contract.MenuRepository) contract.MenuService {
-	return &menuService{menuRepo: menuRepo}
+func NewMenuService(menuRepo contract.MenuRepository, txManager contract.TransactionManager, holdSvc contract.StockHoldService) contract.MenuService {
+	return &menuService{menuRepo: menuRepo, txManager: txManager, holdSvc: holdSvc}
 }
 
 func (s *menuService) AddMenu(ctx context.Context, name, description string, price *utils.GormDecimal, category string, stock int, imageURL string) (*entities.Menu, *exception.AppError) {
@@ -35,11 +39,23 @@ func (s *menuService) AddMenu(ctx context.Context, name, description string, pri
 }
 
 func (s *menuService) GetMenus(ctx context.Context, offset, limit int, search, category string, activeOnly bool) ([]entities.Menu, int64, *exception.AppError) {
-	return s.menuRepo.GetAllMenus(ctx, offset, limit, search, category, activeOnly)
+	menus, total, err := s.menuRepo.GetAllMenus(ctx, offset, limit, search, category, activeOnly)
+	if err != nil {
+		return nil, 0, err
+	}
+	return menus, total, s.holdSvc.ApplyAvailableStock(ctx, menus)
 }
 
 func (s *menuService) GetMenuByID(ctx context.Context, id utils.BinaryUUID) (*entities.Menu, *exception.AppError) {
-	return s.menuRepo.GetMenuByID(ctx, id)
+	menu, err := s.menuRepo.GetMenuByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	menus := []entities.Menu{*menu}
+	if err := s.holdSvc.ApplyAvailableStock(ctx, menus); err != nil {
+		return nil, err
+	}
+	return &menus[0], nil
 }
 
 func (s *menuService) UpdateMenu(ctx context.Context, id utils.BinaryUUID, name, description string, price *utils.GormDecimal, category string, stock int, imageURL string) (*entities.Menu, *exception.AppError) {
@@ -104,22 +120,126 @@ func (s *menuService) CheckMenuAvailability(ctx context.Context, items map[utils
 }
 
 func (s *menuService) ReserveMenuStock(ctx context.Context, items map[utils.BinaryUUID]int) *exception.AppError {
-	// This should be transactional in a real application
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		held, err := s.holdSvc.LockHeldByOthers(ctx, nil, sortedIDs(items), nil)
+		if err != nil {
+			return err
+		}
+		return reserveStock(ctx, s.menuRepo, items, held.Menus)
+	})
+}
+
+// reserveStock locks the given menu rows and reduces their stock. It must run inside a
+// transaction so that concurrent callers wait on the row locks instead of overselling.
+// The quantities in held are kept aside by cart holds and can't be taken.
+func reserveStock(ctx context.Context, menuRepo contract.MenuRepository, items, held map[utils.BinaryUUID]int) *exception.AppError {
+	menus, err := menuRepo.LockMenusByIDs(ctx, sortedIDs(items))
+	if err != nil {
+		return err
+	}
+
+	menuMap := make(map[utils.BinaryUUID]entities.Menu, len(menus))
+	for _, menu := range menus {
+		menuMap[menu.ID] = menu
+	}
+
 	for id, quantity := range items {
-		if err := s.menuRepo.ReduceMenuStock(ctx, id, quantity); err != nil {
-			// Here you would ideally roll back previous stock reductions
-			return exception.NewAppError(err, fmt.Sprintf("failed to reserve stock for menu item %s", id))
+		menu, ok := menuMap[id]
+		if !ok {
+			return exception.NewAppError(nil, fmt.Sprintf("menu item %s not found", id), exception.CodeNotFound)
+		}
+		if !menu.IsInStock(quantity + held[id]) {
+			return exception.NewConflictError(fmt.Sprintf("not enough stock for %s", menu.Name), id.String())
+		}
+		if err := menuRepo.ReduceMenuStock(ctx, id, quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reserveOptionStock locks the given option rows and reduces the stock of those that track it.
+// Callers lock menus before options, always in that order. Like reserveStock it leaves held alone.
+func reserveOptionStock(ctx context.Context, menuRepo contract.MenuRepository, options, held map[utils.BinaryUUID]int) *exception.AppError {
+	if len(options) == 0 {
+		return nil
+	}
+
+	locked, err := menuRepo.LockMenuOptionsByIDs(ctx, sortedIDs(options))
+	if err != nil {
+		return err
+	}
+
+	optionMap := make(map[utils.BinaryUUID]entities.MenuOption, len(locked))
+	for _, option := range locked {
+		optionMap[option.ID] = option
+	}
+
+	for id, quantity := range options {
+		option, ok := optionMap[id]
+		if !ok {
+			return exception.NewAppError(nil, fmt.Sprintf("menu option %s not found", id), exception.CodeNotFound)
+		}
+		if !option.IsInStock(quantity + held[id]) {
+			return exception.NewConflictError(fmt.Sprintf("not enough stock for %s", option.Name), id.String())
+		}
+		if !option.TracksStock() {
+			continue
+		}
+		if err := menuRepo.ReduceMenuOptionStock(ctx, id, quantity); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// releaseStock gives previously reserved quantities back to the menus. Rows are updated in
+// primary key order, the same order reserveStock locks them in, to avoid deadlocks.
+func releaseStock(ctx context.Context, menuRepo contract.MenuRepository, items map[utils.BinaryUUID]int) *exception.AppError {
+	for _, id := range sortedIDs(items) {
+		if err := menuRepo.IncreaseMenuStock(ctx, id, items[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseOptionStock gives previously reserved quantities back to the options that track stock
+func releaseOptionStock(ctx context.Context, menuRepo contract.MenuRepository, options map[utils.BinaryUUID]int) *exception.AppError {
+	for _, id := range sortedIDs(options) {
+		if err := menuRepo.IncreaseMenuOptionStock(ctx, id, options[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedIDs returns the keys of items in primary key order
+func sortedIDs(items map[utils.BinaryUUID]int) []utils.BinaryUUID {
+	ids := make([]utils.BinaryUUID, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+	return ids
+}
+
 func (s *menuService) GetMenusByCategory(ctx context.Context, category string, offset, limit int) ([]entities.Menu, int64, *exception.AppError) {
-	return s.menuRepo.GetMenusByCategory(ctx, category, offset, limit)
+	menus, total, err := s.menuRepo.GetMenusByCategory(ctx, category, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return menus, total, s.holdSvc.ApplyAvailableStock(ctx, menus)
 }
 
 func (s *menuService) SearchMenus(ctx context.Context, query string, offset, limit int) ([]entities.Menu, int64, *exception.AppError) {
-	return s.menuRepo.SearchMenus(ctx, query, offset, limit)
+	menus, total, err := s.menuRepo.SearchMenus(ctx, query, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return menus, total, s.holdSvc.ApplyAvailableStock(ctx, menus)
 }
 
 func (s *menuService) GetCategories(ctx context.Context) ([]string, *exception.AppError) {
@@ -137,3 +257,38 @@ func (s *menuService) ToggleMenuStatus(ctx context.Context, id utils.BinaryUUID)
 	}
 	return menu, nil
 }
+
+func (s *menuService) AddOptionGroup(ctx context.Context, menuID utils.BinaryUUID, group *entities.MenuOptionGroup) (*entities.MenuOptionGroup, *exception.AppError) {
+	if _, err := s.menuRepo.GetMenuByID(ctx, menuID); err != nil {
+		return nil, err
+	}
+	if group.MaxSelect < group.MinSelect {
+		return nil, exception.NewValidationError("max_select cannot be less than min_select")
+	}
+	if group.MinSelect > len(group.Options) {
+		return nil, exception.NewValidationError("min_select cannot be more than the number of options")
+	}
+
+	group.MenuID = menuID
+	if err := s.menuRepo.CreateOptionGroup(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *menuService) DeleteOptionGroup(ctx context.Context, menuID, groupID utils.BinaryUUID) *exception.AppError {
+	return s.menuRepo.DeleteOptionGroup(ctx, menuID, groupID)
+}
+
+func (s *menuService) UpdateMenuOptionStock(ctx context.Context, menuID, optionID utils.BinaryUUID, stock *int) (*entities.MenuOption, *exception.AppError) {
+	if stock != nil && *stock < 0 {
+		return nil, exception.NewValidationError("stock cannot be negative")
+	}
+	if _, err := s.menuRepo.GetMenuOption(ctx, menuID, optionID); err != nil {
+		return nil, err
+	}
+	if err := s.menuRepo.UpdateMenuOptionStock(ctx, optionID, stock); err != nil {
+		return nil, err
+	}
+	return s.menuRepo.GetMenuOption(ctx, menuID, optionID)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"shopify-app/internal/contract"
+	"sync"
+	"time"
+)
+
+// subscriberBufferSize is how many undelivered events a subscriber may have before it is dropped
+const subscriberBufferSize = 64
+
+type orderEventSubscriber struct {
+	events chan contract.OrderEvent
+	match  func(contract.OrderEvent) bool
+}
+
+type orderEventHub struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []contract.OrderEvent // Most recent events, oldest first
+	historySize int
+	subscribers map[*orderEventSubscriber]struct{}
+	closed      bool
+}
+
+// NewOrderEventHub creates a hub that keeps the last historySize events for replay
+func NewOrderEventHub(historySize int) contract.OrderEventHub {
+	return &orderEventHub{
+		// Start from the clock so IDs keep growing across restarts and a stale Last-Event-ID
+		// from before a restart replays everything buffered since
+		lastID:      uint64(time.Now().UnixMilli()),
+		historySize: historySize,
+		subscribers: make(map[*orderEventSubscriber]struct{}),
+	}
+}
+
+func (h *orderEventHub) Publish(event contract.OrderEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	event.ID = h.lastID
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	h.history = append(h.history, event)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for sub := range h.subscribers {
+		if sub.match != nil && !sub.match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Never block publishers on a slow client; it can reconnect and resume from its last ID
+			delete(h.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (h *orderEventHub) Subscribe(lastEventID uint64, match func(contract.OrderEvent) bool) (<-chan contract.OrderEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []contract.OrderEvent
+	if lastEventID > 0 {
+		for _, event := range h.history {
+			if event.ID > lastEventID && (match == nil || match(event)) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	sub := &orderEventSubscriber{
+		events: make(chan contract.OrderEvent, len(replay)+subscriberBufferSize),
+		match:  match,
+	}
+	if h.closed {
+		close(sub.events)
+		return sub.events, func() {}
+	}
+	for _, event := range replay {
+		sub.events <- event
+	}
+	h.subscribers[sub] = struct{}{}
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[sub]; ok {
+			delete(h.subscribers, sub)
+			close(sub.events)
+		}
+	}
+	return sub.events, unsubscribe
+}
+
+func (h *orderEventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
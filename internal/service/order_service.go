@@ -2,76 +2,404 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"shopify-app/internal/contract"
 	"shopify-app/internal/entities"
 	"shopify-app/internal/exception"
 	"shopify-app/internal/utils"
+	"shopify-app/pkg/jwt"
+	"slices"
 	"time"
 )
 
+// maxOrderPageSize caps the number of orders returned by a single search
+const maxOrderPageSize = 100
+
 type orderService struct {
-	orderRepo contract.OrderRepository
-	cartSvc   contract.CartService
-	menuRepo  contract.MenuRepository
+	orderRepo    contract.OrderRepository
+	cartSvc      contract.CartService
+	menuRepo     contract.MenuRepository
+	promotionSvc contract.PromotionService
+	taxSvc       contract.TaxService
+	slotSvc      contract.SlotService
+	addressSvc   contract.AddressService
+	deliverySvc  contract.DeliveryZoneService
+	holdSvc      contract.StockHoldService
+	txManager    contract.TransactionManager
+	eventHub     contract.OrderEventHub
+	prepTime     time.Duration
+	numberFormat utils.OrderNumberFormat
+	quoteSecret  string
+	quoteTTL     time.Duration
 }
 
-func NewOrderService(orderRepo contract.OrderRepository, cartSvc contract.CartService, menuRepo contract.MenuRepository) contract.OrderService {
-	return &orderService{orderRepo: orderRepo, cartSvc: cartSvc, menuRepo: menuRepo}
+func NewOrderService(orderRepo contract.OrderRepository, cartSvc contract.CartService, menuRepo contract.MenuRepository, promotionSvc contract.PromotionService, taxSvc contract.TaxService, slotSvc contract.SlotService, addressSvc contract.AddressService, deliverySvc contract.DeliveryZoneService, holdSvc contract.StockHoldService, txManager contract.TransactionManager, eventHub contract.OrderEventHub, prepTime time.Duration, numberFormat utils.OrderNumberFormat, quoteSecret string, quoteTTL time.Duration) contract.OrderService {
+	return &orderService{orderRepo: orderRepo, cartSvc: cartSvc, menuRepo: menuRepo, promotionSvc: promotionSvc, taxSvc: taxSvc, slotSvc: slotSvc, addressSvc: addressSvc, deliverySvc: deliverySvc, holdSvc: holdSvc, txManager: txManager, eventHub: eventHub, prepTime: prepTime, numberFormat: numberFormat, quoteSecret: quoteSecret, quoteTTL: quoteTTL}
 }
 
-func (s *orderService) CheckoutCart(ctx context.Context, userID utils.BinaryUUID) (*entities.Order, *exception.AppError) {
-	cart, total, err := s.cartSvc.ValidateCartForCheckout(ctx, userID)
+func (s *orderService) CheckoutCart(ctx context.Context, userID utils.BinaryUUID, options contract.CheckoutOptions) (*entities.Order, *exception.AppError) {
+	owner := contract.UserCartOwner(userID)
+	quoteOptions := contract.QuoteOptions{
+		FulfilmentType: options.FulfilmentType,
+		AddressID:      options.AddressID,
+		Tip:            options.Tip,
+	}
+	var pinned *jwt.QuoteClaims
+	if options.QuoteID != "" {
+		claims, err := jwt.ValidateQuoteToken(options.QuoteID, s.quoteSecret)
+		if err != nil || claims.Subject != quoteSubject(owner) {
+			return nil, exception.NewValidationError("the quote is invalid or has expired, request a new quote")
+		}
+		pinned = claims
+	}
+	if options.ScheduledFor != nil {
+		if err := s.slotSvc.ValidateSlot(*options.ScheduledFor); err != nil {
+			return nil, err
+		}
+	}
+	deliveryAddress, delivery, err := s.resolveFulfilment(ctx, owner, &quoteOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var order *entities.Order
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		cart, _, err := s.cartSvc.ValidateCartForCheckout(ctx, owner)
+		if err != nil {
+			return err
+		}
+		quote, err := s.priceQuote(ctx, owner, cart, delivery, quoteOptions.Tip)
+		if err != nil {
+			return err
+		}
+		// Don't silently charge full price when the customer expects a coupon to apply
+		if quote.CouponError != "" {
+			return exception.NewValidationError(quote.CouponError)
+		}
+		if pinned != nil {
+			digest, err := quoteDigest(quote)
+			if err != nil {
+				return err
+			}
+			if digest != pinned.Digest {
+				return exception.NewPriceChangedError("the price has changed since the quote, request a new quote", quote)
+			}
+		}
+
+		// Lock the menu rows and take the stock first, so a concurrent checkout for the
+		// same items blocks here until we commit or roll back. Other carts' holds stay untouched;
+		// this cart's own holds turn into the stock taken here.
+		stockReduction := make(map[utils.BinaryUUID]int)
+		optionStockReduction := make(map[utils.BinaryUUID]int)
+		itemCount := 0
+		for _, cartItem := range cart.CartItems {
+			stockReduction[cartItem.MenuID] += cartItem.Quantity
+			itemCount += cartItem.Quantity
+			for _, option := range cartItem.Options {
+				optionStockReduction[option.OptionID] += cartItem.Quantity
+			}
+		}
+		held, err := s.holdSvc.LockHeldByOthers(ctx, &cart.ID, sortedIDs(stockReduction), sortedIDs(optionStockReduction))
+		if err != nil {
+			return err
+		}
+		if err := reserveStock(ctx, s.menuRepo, stockReduction, held.Menus); err != nil {
+			return err
+		}
+		if err := reserveOptionStock(ctx, s.menuRepo, optionStockReduction, held.Options); err != nil {
+			return err
+		}
+		if err := s.holdSvc.ReleaseCart(ctx, cart.ID); err != nil {
+			return err
+		}
+		if options.ScheduledFor != nil {
+			if err := s.slotSvc.BookSlot(ctx, *options.ScheduledFor, 1, itemCount); err != nil {
+				return err
+			}
+		}
+
+		fees := make([]entities.OrderFee, 0, len(quote.Fees))
+		for _, fee := range quote.Fees {
+			fees = append(fees, entities.OrderFee{Kind: fee.Kind, Name: fee.Name, Amount: fee.Amount})
+		}
+		order = &entities.Order{
+			UserID:           userID,
+			Subtotal:         quote.Subtotal,
+			DiscountAmount:   quote.DiscountAmount,
+			TaxAmount:        quote.TaxAmount,
+			FeeAmount:        quote.FeeAmount,
+			TotalAmount:      quote.Total,
+			PricesIncludeTax: quote.PricesIncludeTax,
+			Status:           entities.StatusPending,
+			FulfilmentType:   quoteOptions.FulfilmentType,
+			ScheduledFor:     options.ScheduledFor,
+			DeliveryAddress:  deliveryAddress,
+			Fees:             fees,
+		}
+		// Numbered last, since the day's counter stays locked until the checkout commits
+		if err := s.assignOrderNumber(ctx, order, time.Now()); err != nil {
+			return err
+		}
+		if err := s.orderRepo.CreateOrder(ctx, order); err != nil {
+			return err
+		}
+
+		taxLines := make([]entities.OrderTaxLine, 0, len(quote.TaxLines))
+		for _, line := range quote.TaxLines {
+			ruleID := line.TaxRuleID
+			taxLines = append(taxLines, entities.OrderTaxLine{
+				OrderID:       order.ID,
+				TaxRuleID:     &ruleID,
+				Name:          line.Name,
+				Rate:          line.Rate,
+				TaxableAmount: line.TaxableAmount,
+				Amount:        line.Amount,
+			})
+		}
+		if err := s.orderRepo.CreateOrderTaxLines(ctx, taxLines); err != nil {
+			return err
+		}
+
+		discounts := make([]entities.OrderDiscount, 0, len(quote.Discounts))
+		for _, discount := range quote.Discounts {
+			discounts = append(discounts, entities.OrderDiscount{
+				OrderID:     order.ID,
+				PromotionID: discount.PromotionID,
+				Code:        discount.Code,
+				Name:        discount.Name,
+				Amount:      discount.Amount,
+			})
+		}
+		if err := s.orderRepo.CreateOrderDiscounts(ctx, discounts); err != nil {
+			return err
+		}
+		if err := s.promotionSvc.RedeemPromotions(ctx, userID, order.ID, quote.Discounts); err != nil {
+			return err
+		}
+
+		var orderItems []entities.OrderItem
+		for _, cartItem := range cart.CartItems {
+			options := make([]entities.OrderItemOption, 0, len(cartItem.Options))
+			for _, option := range cartItem.Options {
+				options = append(options, entities.OrderItemOption{
+					OptionID:   option.OptionID,
+					GroupName:  option.GroupName,
+					OptionName: option.OptionName,
+					PriceDelta: option.PriceDelta,
+				})
+			}
+			orderItems = append(orderItems, entities.OrderItem{
+				OrderID:  order.ID,
+				MenuID:   cartItem.MenuID,
+				Quantity: cartItem.Quantity,
+				Price:    cartItem.Price,
+				MenuName: cartItem.Menu.Name,
+				Options:  options,
+			})
+		}
+		if err := s.orderRepo.CreateOrderItems(ctx, orderItems); err != nil {
+			return err
+		}
+
+		if err := s.orderRepo.CreateStatusHistory(ctx, &entities.OrderStatusHistory{
+			OrderID:   order.ID,
+			ToStatus:  entities.StatusPending,
+			ChangedBy: &userID,
+			Reason:    "order placed",
+		}); err != nil {
+			return err
+		}
+
+		return s.cartSvc.CompleteCheckout(ctx, owner, cart, order.ID)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// In a real app, this whole block should be a single database transaction
-	order := &entities.Order{
-		UserID:      userID,
-		TotalAmount: total,
-		Status:      entities.StatusPending,
+	placed, err := s.orderRepo.GetOrderWithItems(ctx, order.ID)
+	if err != nil {
+		return nil, err
 	}
+	s.eventHub.Publish(contract.OrderEvent{
+		Type:    contract.OrderEventCreated,
+		OrderID: placed.ID,
+		UserID:  placed.UserID,
+		Status:  placed.Status,
+		Order:   placed,
+	})
+	return placed, nil
+}
 
-	if err := s.orderRepo.CreateOrder(ctx, order); err != nil {
+func (s *orderService) QuoteCart(ctx context.Context, owner contract.CartOwner, options contract.QuoteOptions) (*contract.Quote, *exception.AppError) {
+	_, delivery, err := s.resolveFulfilment(ctx, owner, &options)
+	if err != nil {
+		return nil, err
+	}
+	cart, _, err := s.cartSvc.ValidateCartForCheckout(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := s.priceQuote(ctx, owner, cart, delivery, options.Tip)
+	if err != nil {
 		return nil, err
 	}
 
-	var orderItems []entities.OrderItem
-	var stockReduction = make(map[utils.BinaryUUID]int)
-	for _, cartItem := range cart.CartItems {
-		orderItems = append(orderItems, entities.OrderItem{
-			OrderID:  order.ID,
-			MenuID:   cartItem.MenuID,
-			Quantity: cartItem.Quantity,
-			Price:    cartItem.Price,
-			MenuName: cartItem.Menu.Name,
-		})
-		stockReduction[cartItem.MenuID] = cartItem.Quantity
+	digest, err := quoteDigest(quote)
+	if err != nil {
+		return nil, err
+	}
+	id, expiresAt, signErr := jwt.GenerateQuoteToken(quoteSubject(owner), digest, quote.Total.String(), s.quoteSecret, s.quoteTTL)
+	if signErr != nil {
+		return nil, exception.NewAppError(signErr, "failed to sign quote")
+	}
+	quote.ID, quote.ExpiresAt = id, expiresAt
+	return quote, nil
+}
+
+// resolveFulfilment checks the checkout choices that change the price, defaulting the
+// fulfilment to pickup and rounding the tip to cents. For delivery it resolves the address and
+// its zone; the address comes back as a copy so later changes to the address book don't alter
+// the order.
+func (s *orderService) resolveFulfilment(ctx context.Context, owner contract.CartOwner, options *contract.QuoteOptions) (*entities.OrderAddress, *contract.DeliveryQuote, *exception.AppError) {
+	if options.FulfilmentType == "" {
+		options.FulfilmentType = entities.FulfilmentPickup
+	}
+	if !options.FulfilmentType.IsValid() {
+		return nil, nil, exception.NewValidationError(fmt.Sprintf("invalid fulfilment type '%s'", options.FulfilmentType))
 	}
+	if options.AddressID != nil && options.FulfilmentType != entities.FulfilmentDelivery {
+		return nil, nil, exception.NewValidationError("an address can only be given for delivery orders")
+	}
+
+	if options.Tip.Sign() < 0 {
+		return nil, nil, exception.NewValidationError("the tip can't be negative")
+	}
+	options.Tip = options.Tip.Round(2, utils.RoundHalfUp)
 
-	if err := s.orderRepo.CreateOrderItems(ctx, orderItems); err != nil {
-		// Here you would ideally roll back the order creation
+	if options.FulfilmentType != entities.FulfilmentDelivery {
+		return nil, nil, nil
+	}
+	if owner.IsGuest() {
+		return nil, nil, exception.NewValidationError("sign in to get delivery")
+	}
+	address, err := s.addressSvc.ResolveDeliveryAddress(ctx, *owner.UserID, options.AddressID)
+	if err != nil {
+		return nil, nil, err
+	}
+	delivery, err := s.deliverySvc.QuoteDelivery(ctx, &address.PostalAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	deliveryAddress := address.Snapshot()
+	deliveryAddress.DeliveryZoneID = &delivery.ZoneID
+	deliveryAddress.DistanceMeters = delivery.DistanceMeters
+	deliveryAddress.EstimatedMinutes = delivery.EstimatedMinutes
+	return deliveryAddress, delivery, nil
+}
+
+// priceQuote is the one pricing pipeline for quotes and checkout: it prices a cart loaded with
+// its items and menus after promotions and tax, then adds the delivery fee, if any, and the tip.
+// The returned quote has no ID yet.
+func (s *orderService) priceQuote(ctx context.Context, owner contract.CartOwner, cart *entities.Cart, delivery *contract.DeliveryQuote, tip *utils.GormDecimal) (*contract.Quote, *exception.AppError) {
+	pricing, err := s.cartSvc.PriceCart(ctx, owner, cart)
+	if err != nil {
 		return nil, err
 	}
+	tax := pricing.Tax
 
-	// Reduce stock
-	for menuID, quantity := range stockReduction {
-		if err := s.menuRepo.ReduceMenuStock(ctx, menuID, quantity); err != nil {
-			// And here you would roll back everything
-			return nil, exception.NewAppError(err, "failed to reduce stock during checkout")
+	lines := make([]contract.QuoteLine, 0, len(cart.CartItems))
+	for i := range cart.CartItems {
+		item := &cart.CartItems[i]
+		subtotal := item.Price.MulInt(item.Quantity)
+		total := subtotal.Sub(pricing.LineDiscounts[i])
+		if !tax.PricesIncludeTax {
+			total = total.Add(tax.LineTaxes[i])
 		}
+
+		optionNames := make([]string, 0, len(item.Options))
+		for _, option := range item.Options {
+			optionNames = append(optionNames, option.OptionName)
+		}
+		lines = append(lines, contract.QuoteLine{
+			CartItemID: item.ID,
+			MenuID:     item.MenuID,
+			MenuName:   item.Menu.Name,
+			Options:    optionNames,
+			UnitPrice:  item.Price,
+			Quantity:   item.Quantity,
+			Subtotal:   subtotal,
+			Discount:   pricing.LineDiscounts[i],
+			Tax:        tax.LineTaxes[i],
+			Total:      total,
+		})
+	}
+
+	var fees []entities.OrderFee
+	if delivery != nil {
+		if err := checkMinimumOrder(delivery.ZoneName, delivery.MinOrderAmount, pricing.Subtotal, pricing.DiscountAmount); err != nil {
+			return nil, err
+		}
+		fees = append(fees, entities.OrderFee{
+			Kind:   entities.OrderFeeDelivery,
+			Name:   fmt.Sprintf("Delivery (%s)", delivery.ZoneName),
+			Amount: delivery.Fee,
+		})
 	}
+	if tip.Sign() > 0 {
+		fees = append(fees, entities.OrderFee{
+			Kind:   entities.OrderFeeTip,
+			Name:   "Tip",
+			Amount: tip,
+		})
+	}
+	feeAmount, total := addFees(pricing.Total, fees)
+	quoteFees := make([]contract.QuoteFee, 0, len(fees))
+	for _, fee := range fees {
+		quoteFees = append(quoteFees, contract.QuoteFee{Kind: fee.Kind, Name: fee.Name, Amount: fee.Amount})
+	}
+
+	return &contract.Quote{
+		Lines:            lines,
+		Subtotal:         pricing.Subtotal,
+		Discounts:        pricing.Discounts,
+		DiscountAmount:   pricing.DiscountAmount,
+		CouponCode:       pricing.CouponCode,
+		CouponError:      pricing.CouponError,
+		TaxLines:         tax.Lines,
+		TaxAmount:        tax.TaxAmount,
+		PricesIncludeTax: tax.PricesIncludeTax,
+		Fees:             quoteFees,
+		FeeAmount:        feeAmount,
+		Tip:              tip.Round(2, utils.RoundHalfUp),
+		Total:            total,
+	}, nil
+}
 
-	// Clear the user's cart
-	if err := s.cartSvc.ClearCart(ctx, userID); err != nil {
-		// This is less critical, maybe just log it
+// quoteDigest hashes everything that makes up the price of a quote, leaving out its ID and expiry
+func quoteDigest(quote *contract.Quote) (string, *exception.AppError) {
+	unsigned := *quote
+	unsigned.ID, unsigned.ExpiresAt = "", time.Time{}
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", exception.NewAppError(err, "failed to hash quote")
 	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
 
-	return s.orderRepo.GetOrderWithItems(ctx, order.ID)
+// quoteSubject identifies whose cart a quote was made for
+func quoteSubject(owner contract.CartOwner) string {
+	if owner.IsGuest() {
+		return "guest:" + owner.GuestID.String()
+	}
+	return "user:" + owner.UserID.String()
 }
 
-func (s *orderService) GetOrderHistory(ctx context.Context, userID utils.BinaryUUID, offset, limit int) ([]entities.Order, int64, *exception.AppError) {
-	return s.orderRepo.GetOrdersByUserID(ctx, userID, offset, limit)
+func (s *orderService) GetOrderHistory(ctx context.Context, userID utils.BinaryUUID, orderNumber string, offset, limit int) ([]entities.Order, int64, *exception.AppError) {
+	return s.orderRepo.GetOrdersByUserID(ctx, userID, orderNumber, offset, limit)
 }
 
 func (s *orderService) GetOrderDetails(ctx context.Context, userID, orderID utils.BinaryUUID) (*entities.Order, *exception.AppError) {
@@ -85,15 +413,597 @@ func (s *orderService) GetOrderDetails(ctx context.Context, userID, orderID util
 	return s.orderRepo.GetOrderWithItems(ctx, orderID)
 }
 
-func (s *orderService) UpdateOrderStatus(ctx context.Context, orderID utils.BinaryUUID, status entities.OrderStatus) (*entities.Order, *exception.AppError) {
-	if _, err := s.orderRepo.GetOrderByID(ctx, orderID); err != nil {
+func (s *orderService) GetOrderTracking(ctx context.Context, userID, orderID utils.BinaryUUID) (*contract.OrderTracking, *exception.AppError) {
+	order, err := s.GetOrderDetails(ctx, userID, orderID)
+	if err != nil {
 		return nil, err
 	}
+	tracking := s.OrderTrackingFor(order)
+	return &tracking, nil
+}
+
+func (s *orderService) OrderTrackingFor(order *entities.Order) contract.OrderTracking {
+	tracking := contract.OrderTracking{
+		OrderID:   order.ID,
+		Status:    order.Status,
+		UpdatedAt: order.UpdatedAt,
+	}
+	if readyAt, ok := order.EstimatedReadyAt(s.prepTime, time.Now()); ok {
+		tracking.EstimatedReadyAt = &readyAt
+	}
+	return tracking
+}
+
+func (s *orderService) UpdateOrderStatus(ctx context.Context, orderID utils.BinaryUUID, status entities.OrderStatus, changedBy *utils.BinaryUUID, reason string) (*entities.Order, *exception.AppError) {
+	if !status.IsValid() {
+		return nil, exception.NewValidationError(fmt.Sprintf("invalid order status '%s'", status))
+	}
+
+	var updated *entities.Order
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		// Lock the order so concurrent updates are checked against the latest status
+		order, err := s.orderRepo.GetOrderByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if !order.CanTransitionTo(status) {
+			return exception.NewConflictError(fmt.Sprintf("cannot change order status from %s to %s", order.Status, status))
+		}
+		// An order for a later slot must not reach the kitchen before it is due
+		if order.Status == entities.StatusPending && status == entities.StatusConfirmed && s.confirmedStatus(order) != status {
+			return exception.NewConflictError("the order is for a later time slot and can only be confirmed as scheduled")
+		}
+
+		if err := s.orderRepo.UpdateOrderStatus(ctx, orderID, status); err != nil {
+			return err
+		}
+
+		if status == entities.StatusCancelled {
+			if err := s.restockOrder(ctx, order); err != nil {
+				return err
+			}
+			if err := s.promotionSvc.ReleasePromotions(ctx, orderID); err != nil {
+				return err
+			}
+			if err := s.releaseSlot(ctx, order); err != nil {
+				return err
+			}
+		}
+
+		if err := s.orderRepo.CreateStatusHistory(ctx, &entities.OrderStatusHistory{
+			OrderID:    orderID,
+			FromStatus: order.Status,
+			ToStatus:   status,
+			ChangedBy:  changedBy,
+			Reason:     reason,
+		}); err != nil {
+			return err
+		}
+
+		if updated, err = s.orderRepo.GetOrderWithItems(ctx, orderID); err != nil {
+			return err
+		}
 
-	if err := s.orderRepo.UpdateOrderStatus(ctx, orderID, status); err != nil {
+		// Subscribers must never see a change that is later rolled back
+		event := contract.OrderEvent{
+			Type:       contract.OrderEventStatusChanged,
+			OrderID:    orderID,
+			UserID:     updated.UserID,
+			FromStatus: order.Status,
+			Status:     status,
+			Order:      updated,
+		}
+		s.txManager.AfterCommit(ctx, func() { s.eventHub.Publish(event) })
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return s.orderRepo.GetOrderWithItems(ctx, orderID)
+	return updated, nil
+}
+
+func (s *orderService) ConfirmOrder(ctx context.Context, orderID utils.BinaryUUID, changedBy *utils.BinaryUUID, reason string) (*entities.Order, *exception.AppError) {
+	var updated *entities.Order
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		order, err := s.orderRepo.GetOrderByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		updated, err = s.UpdateOrderStatus(ctx, orderID, s.confirmedStatus(order), changedBy, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// confirmedStatus returns the status a pending order moves to when it is confirmed. Orders for a
+// later slot wait as scheduled until they are due for the kitchen.
+func (s *orderService) confirmedStatus(order *entities.Order) entities.OrderStatus {
+	if order.ScheduledFor != nil && order.ScheduledFor.After(s.slotSvc.ReleaseCutoff(time.Now())) {
+		return entities.StatusScheduled
+	}
+	return entities.StatusConfirmed
+}
+
+func (s *orderService) ReleaseDueScheduledOrders(ctx context.Context) (int, *exception.AppError) {
+	orderIDs, err := s.orderRepo.GetDueScheduledOrderIDs(ctx, s.slotSvc.ReleaseCutoff(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, orderID := range orderIDs {
+		if _, err := s.UpdateOrderStatus(ctx, orderID, entities.StatusConfirmed, nil, "scheduled slot is coming up"); err != nil {
+			// Cancelled or released concurrently; the other orders still go ahead
+			if err.Code == exception.CodeConflict {
+				continue
+			}
+			return released, err
+		}
+		released++
+	}
+	return released, nil
+}
+
+func (s *orderService) ExpireStalePendingOrders(ctx context.Context, timeout time.Duration) (int, *exception.AppError) {
+	orderIDs, err := s.orderRepo.GetStalePendingOrderIDs(ctx, time.Now().Add(-timeout))
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, orderID := range orderIDs {
+		if _, err := s.UpdateOrderStatus(ctx, orderID, entities.StatusCancelled, nil, "not confirmed in time"); err != nil {
+			// Confirmed or cancelled in the meantime; the other orders still expire
+			if err.Code == exception.CodeConflict {
+				continue
+			}
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+func (s *orderService) AdvanceOrderStatus(ctx context.Context, orderID utils.BinaryUUID, changedBy *utils.BinaryUUID) (*entities.Order, *exception.AppError) {
+	var updated *entities.Order
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		// Hold the lock while picking the next status, so two bumps can't skip a step
+		order, err := s.orderRepo.GetOrderByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		next, ok := order.NextStatus()
+		if !ok {
+			return exception.NewConflictError(fmt.Sprintf("order in status %s cannot be advanced", order.Status))
+		}
+		if order.Status == entities.StatusPending {
+			next = s.confirmedStatus(order)
+		}
+
+		updated, err = s.UpdateOrderStatus(ctx, orderID, next, changedBy, "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *orderService) ReorderOrder(ctx context.Context, userID, orderID utils.BinaryUUID) (*contract.ReorderResult, *exception.AppError) {
+	owned, err := s.orderRepo.ValidateOrderOwnership(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !owned {
+		return nil, exception.NewAppError(nil, "order not found or not owned by user")
+	}
+
+	order, err := s.orderRepo.GetOrderWithItems(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stock kept aside by other carts can't be reordered, and what is already in the customer's
+	// cart comes out of the same stock
+	cart, _, err := s.cartSvc.GetUserCart(ctx, contract.UserCartOwner(userID))
+	if err != nil {
+		return nil, err
+	}
+	inCart := make(map[utils.BinaryUUID]int)
+	for _, item := range cart.CartItems {
+		inCart[item.MenuID] += item.Quantity
+	}
+	menuIDs := make(map[utils.BinaryUUID]int)
+	for _, item := range order.OrderItems {
+		menuIDs[item.MenuID]++
+	}
+	held, err := s.holdSvc.HeldByOthers(ctx, cart.ID, sortedIDs(menuIDs), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &contract.ReorderResult{Added: []contract.ReorderItem{}, Skipped: []contract.ReorderItem{}}
+	for _, item := range order.OrderItems {
+		line := contract.ReorderItem{MenuID: item.MenuID, MenuName: item.MenuName, Quantity: item.Quantity}
+
+		// Check the common reasons up front so the customer gets a clear message per item
+		menu, err := s.menuRepo.GetMenuByID(ctx, item.MenuID)
+		switch {
+		case err != nil && err.Code == exception.CodeNotFound:
+			line.Reason = "item is no longer on the menu"
+		case err != nil:
+			return nil, err
+		case !menu.IsActive:
+			line.Reason = "item is currently unavailable"
+		default:
+			if left := max(available(menu.Stock, held.Menus[item.MenuID])-inCart[item.MenuID], 0); left < item.Quantity {
+				line.Reason = fmt.Sprintf("only %d left in stock", left)
+			}
+		}
+		if line.Reason != "" {
+			result.Skipped = append(result.Skipped, line)
+			continue
+		}
+
+		optionIDs := make([]utils.BinaryUUID, 0, len(item.Options))
+		for _, option := range item.Options {
+			optionIDs = append(optionIDs, option.OptionID)
+		}
+		if err := s.cartSvc.AddItemToCart(ctx, contract.UserCartOwner(userID), item.MenuID, item.Quantity, optionIDs); err != nil {
+			// Options that were removed or sold out, for example
+			line.Reason = err.Message
+			result.Skipped = append(result.Skipped, line)
+			continue
+		}
+		inCart[item.MenuID] += item.Quantity
+		result.Added = append(result.Added, line)
+	}
+	return result, nil
+}
+
+func (s *orderService) EditOrderItems(ctx context.Context, userID, orderID utils.BinaryUUID, userRole entities.UserRole, changes []contract.OrderItemChange) (*entities.Order, *exception.AppError) {
+	if len(changes) == 0 {
+		return nil, exception.NewValidationError("no changes given")
+	}
+	allowed, err := s.ValidateOrderAccess(ctx, userID, orderID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, exception.NewAppError(nil, "order not found or not owned by user")
+	}
+
+	var updated *entities.Order
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		// Lock the order so the edit can't race a confirmation or another edit
+		order, err := s.orderRepo.GetOrderByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if !order.CanBeEdited() {
+			return exception.NewConflictError(fmt.Sprintf("order in status %s can no longer be edited", order.Status))
+		}
+
+		current, err := s.orderRepo.GetOrderWithItems(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		items := make(map[utils.BinaryUUID]*entities.OrderItem, len(current.OrderItems))
+		for i := range current.OrderItems {
+			items[current.OrderItems[i].ID] = &current.OrderItems[i]
+		}
+
+		// Net stock change per menu item and option; positive values are taken from stock
+		stockDelta := make(map[utils.BinaryUUID]int)
+		optionDelta := make(map[utils.BinaryUUID]int)
+		var added []entities.OrderItem
+		var edited []*entities.OrderItem
+		var revisionChanges []entities.OrderRevisionChange
+
+		for _, change := range changes {
+			if change.Quantity < 0 {
+				return exception.NewValidationError("quantity cannot be negative")
+			}
+
+			if change.ItemID != nil {
+				item, ok := items[*change.ItemID]
+				if !ok {
+					return exception.NewAppError(nil, "order item not found", exception.CodeNotFound)
+				}
+				if change.Quantity == item.Quantity {
+					continue
+				}
+				delta := change.Quantity - item.Quantity
+				stockDelta[item.MenuID] += delta
+				for _, option := range item.Options {
+					optionDelta[option.OptionID] += delta
+				}
+				revisionChanges = append(revisionChanges, entities.OrderRevisionChange{
+					OrderItemID:  item.ID,
+					MenuID:       item.MenuID,
+					MenuName:     item.MenuName,
+					FromQuantity: item.Quantity,
+					ToQuantity:   change.Quantity,
+				})
+				item.Quantity = change.Quantity
+				edited = append(edited, item)
+				continue
+			}
+
+			if change.Quantity == 0 {
+				return exception.NewValidationError("quantity must be positive")
+			}
+			item, err := s.newOrderItem(ctx, orderID, change)
+			if err != nil {
+				return err
+			}
+			stockDelta[item.MenuID] += item.Quantity
+			for _, option := range item.Options {
+				optionDelta[option.OptionID] += item.Quantity
+			}
+			revisionChanges = append(revisionChanges, entities.OrderRevisionChange{
+				OrderItemID: item.ID,
+				MenuID:      item.MenuID,
+				MenuName:    item.MenuName,
+				ToQuantity:  item.Quantity,
+			})
+			added = append(added, *item)
+		}
+
+		if len(revisionChanges) == 0 {
+			updated = current
+			return nil
+		}
+
+		var remaining []entities.OrderItem
+		for _, item := range current.OrderItems {
+			if item.Quantity > 0 {
+				remaining = append(remaining, item)
+			}
+		}
+		remaining = append(remaining, added...)
+		if len(remaining) == 0 {
+			return exception.NewValidationError("an order needs at least one item; cancel the order instead")
+		}
+
+		if err := s.adjustStock(ctx, stockDelta, optionDelta); err != nil {
+			return err
+		}
+		if order.ScheduledFor != nil {
+			itemDelta := 0
+			for _, delta := range stockDelta {
+				itemDelta += delta
+			}
+			if err := s.slotSvc.BookSlot(ctx, *order.ScheduledFor, 0, itemDelta); err != nil {
+				return err
+			}
+		}
+
+		for _, item := range edited {
+			if item.Quantity == 0 {
+				err = s.orderRepo.DeleteOrderItem(ctx, item.ID)
+			} else {
+				err = s.orderRepo.UpdateOrderItemQuantity(ctx, item.ID, item.Quantity)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if len(added) > 0 {
+			if err := s.orderRepo.CreateOrderItems(ctx, added); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repriceOrder(ctx, current, remaining); err != nil {
+			return err
+		}
+
+		revision := &entities.OrderRevision{
+			OrderID:       orderID,
+			ChangedBy:     &userID,
+			PreviousTotal: order.TotalAmount,
+			NewTotal:      current.TotalAmount,
+			Changes:       revisionChanges,
+		}
+		if err := s.orderRepo.CreateOrderRevision(ctx, revision); err != nil {
+			return err
+		}
+
+		if updated, err = s.orderRepo.GetOrderWithItems(ctx, orderID); err != nil {
+			return err
+		}
+
+		event := contract.OrderEvent{
+			Type:     contract.OrderEventItemsChanged,
+			OrderID:  orderID,
+			UserID:   updated.UserID,
+			Status:   updated.Status,
+			Order:    updated,
+			Revision: revision,
+		}
+		s.txManager.AfterCommit(ctx, func() { s.eventHub.Publish(event) })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// newOrderItem builds an order item for a menu item added to an order, priced like a cart line:
+// the current menu price plus the price deltas of the chosen options
+func (s *orderService) newOrderItem(ctx context.Context, orderID utils.BinaryUUID, change contract.OrderItemChange) (*entities.OrderItem, *exception.AppError) {
+	menu, err := s.menuRepo.GetMenuByID(ctx, change.MenuID)
+	if err != nil {
+		return nil, err
+	}
+	if !menu.IsActive {
+		return nil, exception.NewValidationError(fmt.Sprintf("%s is currently unavailable", menu.Name))
+	}
+	selected, selectErr := menu.SelectOptions(change.OptionIDs)
+	if selectErr != nil {
+		return nil, exception.NewValidationError(selectErr.Error())
+	}
+
+	price := menu.Price
+	options := make([]entities.OrderItemOption, 0, len(selected))
+	for _, choice := range selected {
+		price = price.Add(choice.Option.PriceDelta)
+		options = append(options, entities.OrderItemOption{
+			OptionID:   choice.Option.ID,
+			GroupName:  choice.Group.Name,
+			OptionName: choice.Option.Name,
+			PriceDelta: choice.Option.PriceDelta,
+		})
+	}
+
+	return &entities.OrderItem{
+		ID:       utils.NewBinaryUUID(),
+		OrderID:  orderID,
+		MenuID:   menu.ID,
+		Quantity: change.Quantity,
+		Price:    price,
+		MenuName: menu.Name,
+		Menu:     *menu,
+		Options:  options,
+	}, nil
+}
+
+// adjustStock takes the positive quantities from stock and gives the negative ones back
+func (s *orderService) adjustStock(ctx context.Context, stockDelta, optionDelta map[utils.BinaryUUID]int) *exception.AppError {
+	take, give := splitDeltas(stockDelta)
+	takeOptions, giveOptions := splitDeltas(optionDelta)
+	// What carts hold is promised to their customers, so edits can't take it either
+	held, err := s.holdSvc.LockHeldByOthers(ctx, nil, sortedIDs(take), sortedIDs(takeOptions))
+	if err != nil {
+		return err
+	}
+	if err := reserveStock(ctx, s.menuRepo, take, held.Menus); err != nil {
+		return err
+	}
+	if err := reserveOptionStock(ctx, s.menuRepo, takeOptions, held.Options); err != nil {
+		return err
+	}
+	if err := releaseStock(ctx, s.menuRepo, give); err != nil {
+		return err
+	}
+	return releaseOptionStock(ctx, s.menuRepo, giveOptions)
+}
+
+// splitDeltas separates positive and negative quantity changes, dropping zeros
+func splitDeltas(deltas map[utils.BinaryUUID]int) (map[utils.BinaryUUID]int, map[utils.BinaryUUID]int) {
+	positive := make(map[utils.BinaryUUID]int)
+	negative := make(map[utils.BinaryUUID]int)
+	for id, delta := range deltas {
+		switch {
+		case delta > 0:
+			positive[id] = delta
+		case delta < 0:
+			negative[id] = -delta
+		}
+	}
+	return positive, negative
+}
+
+// repriceOrder recalculates the amounts of an order from its items. The promotions the order
+// has are applied again to the new items, along with automatic promotions it now qualifies for,
+// and the promotion uses are brought in line with the result; tax follows the current rules.
+func (s *orderService) repriceOrder(ctx context.Context, order *entities.Order, items []entities.OrderItem) *exception.AppError {
+	lines := make([]contract.PricedLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, contract.PricedLine{
+			MenuID:    item.MenuID,
+			Category:  item.Menu.Category,
+			UnitPrice: item.Price,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	subtotal := linesSubtotal(lines)
+	promotionIDs := make([]utils.BinaryUUID, 0, len(order.Discounts))
+	for _, discount := range order.Discounts {
+		promotionIDs = append(promotionIDs, discount.PromotionID)
+	}
+	discounts, err := s.promotionSvc.RecalculateDiscounts(ctx, order.UserID, promotionIDs, lines)
+	if err != nil {
+		return err
+	}
+	if err := s.promotionSvc.SyncRedemptions(ctx, order.UserID, order.ID, discounts.Discounts); err != nil {
+		return err
+	}
+	tax, err := taxDiscountedLines(ctx, s.taxSvc, lines, discounts)
+	if err != nil {
+		return err
+	}
+
+	order.Subtotal = subtotal
+	order.DiscountAmount = discounts.Amount
+	order.TaxAmount = tax.TaxAmount
+	// The fees stay as charged at checkout, but the order must still meet the delivery minimum
+	if order.DeliveryAddress != nil && order.DeliveryAddress.DeliveryZoneID != nil {
+		zone, err := s.deliverySvc.GetDeliveryZone(ctx, *order.DeliveryAddress.DeliveryZoneID)
+		if err != nil {
+			return err
+		}
+		if err := checkMinimumOrder(zone.Name, zone.MinOrderAmount, order.Subtotal, order.DiscountAmount); err != nil {
+			return err
+		}
+	}
+	_, order.TotalAmount = addFees(tax.Total, order.Fees)
+
+	taxLines := make([]entities.OrderTaxLine, 0, len(tax.Lines))
+	for _, line := range tax.Lines {
+		ruleID := line.TaxRuleID
+		taxLines = append(taxLines, entities.OrderTaxLine{
+			OrderID:       order.ID,
+			TaxRuleID:     &ruleID,
+			Name:          line.Name,
+			Rate:          line.Rate,
+			TaxableAmount: line.TaxableAmount,
+			Amount:        line.Amount,
+		})
+	}
+	orderDiscounts := make([]entities.OrderDiscount, 0, len(discounts.Discounts))
+	for _, discount := range discounts.Discounts {
+		orderDiscounts = append(orderDiscounts, entities.OrderDiscount{
+			OrderID:     order.ID,
+			PromotionID: discount.PromotionID,
+			Code:        discount.Code,
+			Name:        discount.Name,
+			Amount:      discount.Amount,
+		})
+	}
+	if err := s.orderRepo.UpdateOrderPricing(ctx, order, taxLines, orderDiscounts); err != nil {
+		return err
+	}
+	order.TaxLines = taxLines
+	order.Discounts = orderDiscounts
+	return nil
+}
+
+// checkMinimumOrder checks that what the customer pays for the items, after discounts, reaches
+// the minimum order amount of a delivery zone
+func checkMinimumOrder(zoneName string, minimum, subtotal, discount *utils.GormDecimal) *exception.AppError {
+	if subtotal.Sub(discount).Cmp(minimum) < 0 {
+		return exception.NewValidationError(fmt.Sprintf("delivery to %s needs an order of at least %s after discounts", zoneName, minimum.Round(2, utils.RoundHalfUp)))
+	}
+	return nil
+}
+
+// addFees adds the fee lines to an amount and returns the fee total and the new amount
+func addFees(amount *utils.GormDecimal, fees []entities.OrderFee) (*utils.GormDecimal, *utils.GormDecimal) {
+	feeTotal := utils.NewMoney(0, 2)
+	for _, fee := range fees {
+		feeTotal = feeTotal.Add(fee.Amount)
+	}
+	return feeTotal, amount.Add(feeTotal)
 }
 
 func (s *orderService) CancelOrder(ctx context.Context, userID, orderID utils.BinaryUUID) (*entities.Order, *exception.AppError) {
@@ -110,11 +1020,78 @@ func (s *orderService) CancelOrder(ctx context.Context, userID, orderID utils.Bi
 		return nil, err
 	}
 
+	// A retried cancel request must not fail or restock a second time
+	if order.Status == entities.StatusCancelled {
+		return s.orderRepo.GetOrderWithItems(ctx, orderID)
+	}
+
 	if !order.CanBeCancelled() {
-		return nil, exception.NewAppError(nil, "order cannot be cancelled in its current state")
+		return nil, exception.NewConflictError("order cannot be cancelled in its current state")
 	}
 
-	return s.UpdateOrderStatus(ctx, orderID, entities.StatusCancelled)
+	return s.UpdateOrderStatus(ctx, orderID, entities.StatusCancelled, &userID, "cancelled by customer")
+}
+
+// releaseSlot gives the capacity booked by a scheduled order back to its slot
+func (s *orderService) releaseSlot(ctx context.Context, order *entities.Order) *exception.AppError {
+	if order.ScheduledFor == nil {
+		return nil
+	}
+	orderWithItems, err := s.orderRepo.GetOrderWithItems(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	items := 0
+	for _, item := range orderWithItems.OrderItems {
+		items += item.Quantity
+	}
+	return s.slotSvc.BookSlot(ctx, *order.ScheduledFor, -1, -items)
+}
+
+// assignOrderNumber gives an order the next number of the day it is placed on. Must run inside
+// the transaction that creates the order, so that a rolled back checkout gives its number back.
+func (s *orderService) assignOrderNumber(ctx context.Context, order *entities.Order, placedAt time.Time) *exception.AppError {
+	day := utils.OrderNumberDay(placedAt)
+	seq, err := s.orderRepo.NextOrderSequence(ctx, day)
+	if err != nil {
+		return err
+	}
+	order.OrderDay = day
+	order.DailySequence = seq
+	order.OrderNumber = s.numberFormat.Format(placedAt.In(time.Local), seq)
+	return nil
+}
+
+// restockOrder puts the quantities taken at checkout back into the menus, less what refunds have
+// already restocked. The caller must hold the order row lock; RestockedAt guarantees the stock is
+// only given back once.
+func (s *orderService) restockOrder(ctx context.Context, order *entities.Order) *exception.AppError {
+	if order.RestockedAt != nil {
+		return nil
+	}
+
+	orderWithItems, err := s.orderRepo.GetOrderWithItems(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	quantities := make(map[utils.BinaryUUID]int)
+	optionQuantities := make(map[utils.BinaryUUID]int)
+	for _, item := range orderWithItems.OrderItems {
+		quantity := item.Quantity - item.RestockedQuantity
+		quantities[item.MenuID] += quantity
+		for _, option := range item.Options {
+			optionQuantities[option.OptionID] += quantity
+		}
+	}
+	if err := releaseStock(ctx, s.menuRepo, quantities); err != nil {
+		return err
+	}
+	if err := releaseOptionStock(ctx, s.menuRepo, optionQuantities); err != nil {
+		return err
+	}
+
+	return s.orderRepo.MarkOrderRestocked(ctx, order.ID)
 }
 
 func (s *orderService) GetAllOrders(ctx context.Context, offset, limit int, status entities.OrderStatus) ([]entities.Order, int64, *exception.AppError) {
@@ -131,3 +1108,36 @@ func (s *orderService) ValidateOrderAccess(ctx context.Context, userID, orderID
 	}
 	return s.orderRepo.ValidateOrderOwnership(ctx, orderID, userID)
 }
+
+func (s *orderService) GetOrderDetailsWithAccess(ctx context.Context, userID, orderID utils.BinaryUUID, userRole entities.UserRole) (*entities.Order, *exception.AppError) {
+	allowed, err := s.ValidateOrderAccess(ctx, userID, orderID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, exception.NewAppError(nil, "order not found or not owned by user")
+	}
+	return s.orderRepo.GetOrderWithItems(ctx, orderID)
+}
+
+func (s *orderService) SearchOrders(ctx context.Context, filter contract.OrderFilter) ([]entities.Order, int64, *exception.AppError) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, 0, exception.NewValidationError(fmt.Sprintf("invalid order status '%s'", filter.Status))
+	}
+	if filter.Fulfilment != "" && !filter.Fulfilment.IsValid() {
+		return nil, 0, exception.NewValidationError(fmt.Sprintf("invalid fulfilment type '%s'", filter.Fulfilment))
+	}
+	if filter.SortBy != "" && !slices.Contains(contract.OrderSortFields, filter.SortBy) {
+		return nil, 0, exception.NewValidationError(fmt.Sprintf("invalid sort field '%s'", filter.SortBy))
+	}
+	if filter.StartDate != nil && filter.EndDate != nil && filter.StartDate.After(*filter.EndDate) {
+		return nil, 0, exception.NewValidationError("start date cannot be after end date")
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	if filter.Limit <= 0 || filter.Limit > maxOrderPageSize {
+		filter.Limit = maxOrderPageSize
+	}
+	return s.orderRepo.SearchOrders(ctx, filter)
+}
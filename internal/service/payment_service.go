@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+type paymentService struct {
+	paymentRepo contract.PaymentRepository
+	orderRepo   contract.OrderRepository
+	orderSvc    contract.OrderService
+	txManager   contract.TransactionManager
+	provider    contract.PaymentProvider
+	refundSvc   contract.RefundService
+	currency    string
+}
+
+func NewPaymentService(paymentRepo contract.PaymentRepository, orderRepo contract.OrderRepository, orderSvc contract.OrderService, txManager contract.TransactionManager, provider contract.PaymentProvider, refundSvc contract.RefundService, currency string) contract.PaymentService {
+	return &paymentService{paymentRepo: paymentRepo, orderRepo: orderRepo, orderSvc: orderSvc, txManager: txManager, provider: provider, refundSvc: refundSvc, currency: currency}
+}
+
+func (s *paymentService) CreatePaymentIntent(ctx context.Context, userID, orderID utils.BinaryUUID) (*entities.Payment, *exception.AppError) {
+	owned, err := s.orderRepo.ValidateOrderOwnership(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !owned {
+		return nil, exception.NewAppError(nil, "order not found or not owned by user")
+	}
+
+	var payment *entities.Payment
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		// Lock the order so concurrent requests don't open two payments for it
+		order, err := s.orderRepo.GetOrderByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != entities.StatusPending {
+			return exception.NewConflictError("only pending orders can be paid")
+		}
+
+		if payment, err = s.paymentRepo.GetPendingPaymentByOrderID(ctx, orderID); err != nil {
+			return err
+		}
+		if payment != nil {
+			if sameAmount(payment.Amount, order.TotalAmount) {
+				return nil
+			}
+			// The order was edited since the payment was opened; don't let the old amount be paid
+			if err := s.paymentRepo.UpdatePaymentStatus(ctx, payment.ID, entities.PaymentCancelled, "order total changed"); err != nil {
+				return err
+			}
+		}
+
+		payment = &entities.Payment{
+			ID:       utils.NewBinaryUUID(),
+			OrderID:  orderID,
+			Provider: s.provider.Name(),
+			Amount:   order.TotalAmount,
+			Currency: s.currency,
+			Status:   entities.PaymentPending,
+		}
+		intent, providerErr := s.provider.CreateIntent(ctx, payment)
+		if providerErr != nil {
+			return exception.NewAppError(providerErr, "failed to create payment intent")
+		}
+		payment.ProviderReference = intent.Reference
+		payment.ClientSecret = intent.ClientSecret
+
+		return s.paymentRepo.CreatePayment(ctx, payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (s *paymentService) GetOrderPayments(ctx context.Context, userID, orderID utils.BinaryUUID) ([]entities.Payment, *exception.AppError) {
+	owned, err := s.orderRepo.ValidateOrderOwnership(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !owned {
+		return nil, exception.NewAppError(nil, "order not found or not owned by user")
+	}
+	return s.paymentRepo.GetPaymentsByOrderID(ctx, orderID)
+}
+
+func (s *paymentService) HandleWebhook(ctx context.Context, provider string, payload []byte, header http.Header) *exception.AppError {
+	if provider != s.provider.Name() {
+		return exception.NewAppError(nil, "unknown payment provider", exception.CodeNotFound)
+	}
+
+	event, parseErr := s.provider.ParseWebhook(payload, header)
+	if parseErr != nil {
+		if errors.Is(parseErr, contract.ErrInvalidWebhookSignature) {
+			return exception.NewAppError(parseErr, "invalid webhook signature", exception.CodeUnauthorized)
+		}
+		return exception.NewValidationError(parseErr.Error())
+	}
+
+	var lateOrderID *utils.BinaryUUID
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		payment, err := s.paymentRepo.GetPaymentByReferenceForUpdate(ctx, provider, event.Reference)
+		if err != nil {
+			return err
+		}
+
+		// Providers deliver webhooks at least once; a settled payment has already been handled
+		if payment.Status.IsFinal() {
+			return nil
+		}
+
+		if err := s.paymentRepo.UpdatePaymentStatus(ctx, payment.ID, event.Status, event.FailureReason); err != nil {
+			return err
+		}
+		if event.Status != entities.PaymentSucceeded {
+			return nil
+		}
+
+		order, err := s.orderRepo.GetOrderByIDForUpdate(ctx, payment.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.Status == entities.StatusCancelled {
+			// E.g. expired while the customer was paying; the money goes back once the payment is recorded
+			lateOrderID = &order.ID
+			return nil
+		}
+		if order.Status != entities.StatusPending {
+			return nil
+		}
+		_, err = s.orderSvc.ConfirmOrder(ctx, order.ID, nil, "payment succeeded")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if lateOrderID != nil {
+		s.refundLatePayment(ctx, *lateOrderID)
+	}
+	return nil
+}
+
+// refundLatePayment refunds a payment that succeeded after its order was cancelled. A failure is
+// only logged; the payment stays recorded, so an admin can still refund it by hand.
+func (s *paymentService) refundLatePayment(ctx context.Context, orderID utils.BinaryUUID) {
+	request := contract.RefundRequest{Reason: "paid after the order was cancelled"}
+	if _, err := s.refundSvc.RefundOrder(ctx, orderID, nil, request); err != nil {
+		log.Printf("failed to refund late payment of cancelled order %s: %v", orderID, err)
+	}
+}
+
+// sameAmount checks if two amounts are equal
+func sameAmount(a, b *utils.GormDecimal) bool {
+	return a.Cmp(b) == 0
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+)
+
+// hundredPercent is 100, for working with percentages
+var hundredPercent = utils.NewMoney(100, 0)
+
+// linesSubtotal adds up the listed price of the lines, before discounts
+func linesSubtotal(lines []contract.PricedLine) *utils.GormDecimal {
+	subtotal := utils.NewMoney(0, 2)
+	for _, line := range lines {
+		subtotal = subtotal.Add(line.UnitPrice.MulInt(line.Quantity))
+	}
+	return subtotal
+}
+
+// taxDiscountedLines calculates the tax on the lines after their discounts, as tax is charged on
+// what the customer actually pays for each line
+func taxDiscountedLines(ctx context.Context, taxSvc contract.TaxService, lines []contract.PricedLine, discounts *contract.DiscountResult) (*contract.TaxBreakdown, *exception.AppError) {
+	taxableLines := make([]contract.TaxableLine, 0, len(lines))
+	for i, line := range lines {
+		taxableLines = append(taxableLines, contract.TaxableLine{
+			MenuID:   line.MenuID,
+			Category: line.Category,
+			Amount:   line.UnitPrice.MulInt(line.Quantity).Sub(discounts.LineDiscounts[i]),
+		})
+	}
+	return taxSvc.CalculateTax(ctx, taxableLines)
+}
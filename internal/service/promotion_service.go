@@ -0,0 +1,523 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type promotionService struct {
+	promotionRepo contract.PromotionRepository
+	menuRepo      contract.MenuRepository
+	rounding      utils.RoundingMode
+}
+
+func NewPromotionService(promotionRepo contract.PromotionRepository, menuRepo contract.MenuRepository, rounding utils.RoundingMode) contract.PromotionService {
+	return &promotionService{promotionRepo: promotionRepo, menuRepo: menuRepo, rounding: rounding}
+}
+
+func (s *promotionService) CreatePromotion(ctx context.Context, promotion *entities.Promotion) (*entities.Promotion, *exception.AppError) {
+	if err := s.validatePromotion(ctx, promotion); err != nil {
+		return nil, err
+	}
+	if err := s.promotionRepo.CreatePromotion(ctx, promotion); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (s *promotionService) UpdatePromotion(ctx context.Context, promotion *entities.Promotion) (*entities.Promotion, *exception.AppError) {
+	existing, err := s.promotionRepo.GetPromotionByID(ctx, promotion.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validatePromotion(ctx, promotion); err != nil {
+		return nil, err
+	}
+	// The usage count is maintained by redemptions, not by admins
+	promotion.UsageCount = existing.UsageCount
+	promotion.CreatedAt = existing.CreatedAt
+	if err := s.promotionRepo.UpdatePromotion(ctx, promotion); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (s *promotionService) DeletePromotion(ctx context.Context, id utils.BinaryUUID) *exception.AppError {
+	return s.promotionRepo.DeletePromotion(ctx, id)
+}
+
+func (s *promotionService) GetPromotions(ctx context.Context, offset, limit int) ([]entities.Promotion, int64, *exception.AppError) {
+	return s.promotionRepo.GetAllPromotions(ctx, offset, limit)
+}
+
+// validatePromotion checks the settings required by the promotion type and normalizes the code
+func (s *promotionService) validatePromotion(ctx context.Context, p *entities.Promotion) *exception.AppError {
+	if !p.Type.IsValid() {
+		return exception.NewValidationError("invalid promotion type: " + string(p.Type))
+	}
+
+	value := p.Value
+	switch p.Type {
+	case entities.PromotionPercentage:
+		if value.Sign() <= 0 || value.Cmp(hundredPercent) > 0 {
+			return exception.NewValidationError("percentage must be greater than 0 and at most 100")
+		}
+	case entities.PromotionFixedAmount:
+		if value.Sign() <= 0 {
+			return exception.NewValidationError("amount off must be greater than 0")
+		}
+	case entities.PromotionBuyXGetY:
+		if p.BuyQuantity < 1 || p.GetQuantity < 1 {
+			return exception.NewValidationError("buy and get quantities must be at least 1")
+		}
+	case entities.PromotionFreeItem:
+		if p.FreeMenuID == nil {
+			return exception.NewValidationError("free item promotions need a free_menu_id")
+		}
+		if p.MinSubtotal.Sign() <= 0 {
+			return exception.NewValidationError("free item promotions need a min_subtotal greater than 0")
+		}
+		if p.GetQuantity == 0 {
+			p.GetQuantity = 1
+		}
+		if _, err := s.menuRepo.GetMenuByID(ctx, *p.FreeMenuID); err != nil {
+			return err
+		}
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return exception.NewValidationError("ends_at must be after starts_at")
+	}
+	if (p.UsageLimit != nil && *p.UsageLimit < 1) || (p.PerUserLimit != nil && *p.PerUserLimit < 1) {
+		return exception.NewValidationError("usage limits must be at least 1")
+	}
+
+	if p.Code != nil {
+		code := normalizeCouponCode(*p.Code)
+		if code == "" {
+			p.Code = nil
+			return nil
+		}
+		p.Code = &code
+
+		// Codes stay reserved after a promotion is deleted, so the unique index holds
+		existing, err := s.promotionRepo.GetPromotionByCode(ctx, code)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.ID != p.ID {
+			return exception.NewConflictError("a promotion with this code already exists", code)
+		}
+	}
+	return nil
+}
+
+// normalizeCouponCode makes coupon codes case-insensitive
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *promotionService) ValidateCoupon(ctx context.Context, userID utils.BinaryUUID, code string) (*entities.Promotion, *exception.AppError) {
+	promotion, reason, err := s.lookupCoupon(ctx, userID, code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return nil, exception.NewValidationError(reason)
+	}
+	return promotion, nil
+}
+
+// lookupCoupon finds the promotion behind a coupon code. When it cannot be used, the reason is
+// returned instead.
+func (s *promotionService) lookupCoupon(ctx context.Context, userID utils.BinaryUUID, code string, now time.Time) (*entities.Promotion, string, *exception.AppError) {
+	promotion, err := s.promotionRepo.GetPromotionByCode(ctx, normalizeCouponCode(code))
+	if err != nil {
+		return nil, "", err
+	}
+	if promotion == nil || promotion.DeletedAt.Valid {
+		return nil, "coupon code not found", nil
+	}
+	if !promotion.IsRunning(now) {
+		return nil, "coupon code is not valid at this time", nil
+	}
+	usable, err := s.withinLimits(ctx, promotion, userID, false)
+	if err != nil {
+		return nil, "", err
+	}
+	if !usable {
+		return nil, "coupon code has reached its usage limit", nil
+	}
+	return promotion, "", nil
+}
+
+// withinLimits checks the global and per-user usage limits of a promotion. With locking, the
+// promotion must have been read under lock and the user's redemptions are counted with a locking
+// read, so checkouts running at the same time cannot both take the last use.
+func (s *promotionService) withinLimits(ctx context.Context, promotion *entities.Promotion, userID utils.BinaryUUID, locking bool) (bool, *exception.AppError) {
+	if !promotion.HasUsesLeft() {
+		return false, nil
+	}
+	if promotion.PerUserLimit == nil {
+		return true, nil
+	}
+	var used int64
+	var err *exception.AppError
+	if locking {
+		used, err = s.promotionRepo.CountUserRedemptionsForUpdate(ctx, promotion.ID, userID)
+	} else {
+		used, err = s.promotionRepo.CountUserRedemptions(ctx, promotion.ID, userID)
+	}
+	if err != nil {
+		return false, err
+	}
+	return used < int64(*promotion.PerUserLimit), nil
+}
+
+// appliedPromotion is the discount a single promotion grants on each line
+type appliedPromotion struct {
+	promotion *entities.Promotion
+	perLine   []*utils.GormDecimal
+	total     *utils.GormDecimal
+}
+
+// usableAutomaticPromotions returns the automatic promotions that are running and within their
+// usage limits for a user
+func (s *promotionService) usableAutomaticPromotions(ctx context.Context, userID utils.BinaryUUID, now time.Time) ([]*entities.Promotion, *exception.AppError) {
+	automatic, err := s.promotionRepo.GetAutomaticPromotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usable := make([]*entities.Promotion, 0, len(automatic))
+	for i := range automatic {
+		promotion := &automatic[i]
+		if !promotion.IsRunning(now) {
+			continue
+		}
+		ok, err := s.withinLimits(ctx, promotion, userID, false)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			usable = append(usable, promotion)
+		}
+	}
+	return usable, nil
+}
+
+func (s *promotionService) CalculateDiscounts(ctx context.Context, userID utils.BinaryUUID, couponCode string, lines []contract.PricedLine) (*contract.DiscountResult, *exception.AppError) {
+	now := time.Now()
+	candidates, err := s.usableAutomaticPromotions(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var coupon *entities.Promotion
+	var couponError string
+	if strings.TrimSpace(couponCode) != "" {
+		coupon, couponError, err = s.lookupCoupon(ctx, userID, couponCode, now)
+		if err != nil {
+			return nil, err
+		}
+		if coupon != nil {
+			candidates = append(candidates, coupon)
+		}
+	}
+
+	result := s.combineDiscounts(candidates, coupon, lines)
+	if couponError != "" {
+		result.CouponError = couponError
+	}
+	return result, nil
+}
+
+func (s *promotionService) RecalculateDiscounts(ctx context.Context, userID utils.BinaryUUID, promotionIDs []utils.BinaryUUID, lines []contract.PricedLine) (*contract.DiscountResult, *exception.AppError) {
+	promotions, err := s.promotionRepo.GetPromotionsByIDs(ctx, promotionIDs)
+	if err != nil {
+		return nil, err
+	}
+	// Promotions already redeemed by the order are honoured even if they have since ended
+	candidates := make([]*entities.Promotion, len(promotions))
+	for i := range promotions {
+		candidates[i] = &promotions[i]
+	}
+	// The edited order may now qualify for automatic promotions it didn't get at checkout
+	automatic, err := s.usableAutomaticPromotions(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, promotion := range automatic {
+		if !slices.Contains(promotionIDs, promotion.ID) {
+			candidates = append(candidates, promotion)
+		}
+	}
+	return s.combineDiscounts(candidates, nil, lines), nil
+}
+
+// combineDiscounts applies the candidate promotions to the lines following the stacking rules.
+// coupon is the candidate entered by the customer, if any, so its outcome can be reported.
+func (s *promotionService) combineDiscounts(candidates []*entities.Promotion, coupon *entities.Promotion, lines []contract.PricedLine) *contract.DiscountResult {
+	result := &contract.DiscountResult{
+		Discounts:     []contract.DiscountLine{},
+		LineDiscounts: make([]*utils.GormDecimal, len(lines)),
+	}
+
+	lineAmounts := make([]*utils.GormDecimal, len(lines))
+	for i, line := range lines {
+		lineAmounts[i] = line.UnitPrice.MulInt(line.Quantity)
+	}
+
+	var stackable []appliedPromotion
+	var best *appliedPromotion
+	for _, promotion := range candidates {
+		applied := s.applyPromotion(promotion, lines, lineAmounts)
+		if applied.total.Sign() == 0 {
+			if promotion == coupon {
+				result.CouponError = "coupon code does not apply to the items in your cart"
+			}
+			continue
+		}
+		if promotion.Stackable {
+			stackable = append(stackable, applied)
+		} else if best == nil || applied.total.Cmp(best.total) > 0 {
+			best = &applied
+		}
+	}
+
+	// A non-stackable promotion stands alone; the customer gets whichever is worth more, that
+	// promotion or all stackable promotions together
+	chosen := stackable
+	stackedTotal := utils.NewMoney(0, 2)
+	for _, applied := range stackable {
+		stackedTotal = stackedTotal.Add(applied.total)
+	}
+	if best != nil && best.total.Cmp(stackedTotal) > 0 {
+		chosen = []appliedPromotion{*best}
+	}
+
+	// Combined discounts never take a line below zero
+	remaining := slices.Clone(lineAmounts)
+	for i := range result.LineDiscounts {
+		result.LineDiscounts[i] = utils.NewMoney(0, 2)
+	}
+	grandTotal := utils.NewMoney(0, 2)
+	couponApplied := false
+	for _, applied := range chosen {
+		total := utils.NewMoney(0, 2)
+		for i, amount := range applied.perLine {
+			amount = amount.Min(remaining[i])
+			remaining[i] = remaining[i].Sub(amount)
+			result.LineDiscounts[i] = result.LineDiscounts[i].Add(amount)
+			total = total.Add(amount)
+		}
+		if total.Sign() == 0 {
+			continue
+		}
+		if applied.promotion == coupon {
+			couponApplied = true
+		}
+
+		discount := contract.DiscountLine{
+			PromotionID: applied.promotion.ID,
+			Name:        applied.promotion.Name,
+			Amount:      total,
+		}
+		if applied.promotion.Code != nil {
+			discount.Code = *applied.promotion.Code
+		}
+		result.Discounts = append(result.Discounts, discount)
+		grandTotal = grandTotal.Add(total)
+	}
+	if coupon != nil && !couponApplied && result.CouponError == "" {
+		result.CouponError = "coupon code cannot be combined with a better promotion already applied"
+	}
+
+	result.Amount = grandTotal
+	return result
+}
+
+// applyPromotion calculates the discount a promotion grants on each line, rounded to cents
+func (s *promotionService) applyPromotion(p *entities.Promotion, lines []contract.PricedLine, lineAmounts []*utils.GormDecimal) appliedPromotion {
+	applied := appliedPromotion{promotion: p, perLine: make([]*utils.GormDecimal, len(lines)), total: utils.NewMoney(0, 2)}
+	for i := range applied.perLine {
+		applied.perLine[i] = utils.NewMoney(0, 2)
+	}
+
+	eligible := make([]int, 0, len(lines))
+	eligibleSubtotal := utils.NewMoney(0, 2)
+	for i, line := range lines {
+		if p.AppliesToCategory(line.Category) {
+			eligible = append(eligible, i)
+			eligibleSubtotal = eligibleSubtotal.Add(lineAmounts[i])
+		}
+	}
+	if len(eligible) == 0 {
+		return applied
+	}
+	if p.MinSubtotal != nil && eligibleSubtotal.Cmp(p.MinSubtotal) < 0 {
+		return applied
+	}
+	value := p.Value
+
+	switch p.Type {
+	case entities.PromotionPercentage:
+		for _, i := range eligible {
+			applied.perLine[i] = lineAmounts[i].Mul(value).Div(hundredPercent, 2, s.rounding)
+		}
+
+	case entities.PromotionFixedAmount:
+		value = value.Min(eligibleSubtotal)
+		if value.Sign() == 0 {
+			break
+		}
+		// Spread the amount over the lines in proportion to their value; the last line takes
+		// the rounding difference so the parts add up to the whole. Rounding the other shares
+		// down keeps that difference from going negative.
+		allocated := utils.NewMoney(0, 2)
+		for n, i := range eligible {
+			if n == len(eligible)-1 {
+				applied.perLine[i] = value.Sub(allocated)
+				break
+			}
+			share := value.Mul(lineAmounts[i]).Div(eligibleSubtotal, 2, utils.RoundDown)
+			applied.perLine[i] = share
+			allocated = allocated.Add(share)
+		}
+
+	case entities.PromotionBuyXGetY:
+		// The cheapest units are the free ones
+		type unit struct {
+			line  int
+			price *utils.GormDecimal
+		}
+		var units []unit
+		for _, i := range eligible {
+			for q := 0; q < lines[i].Quantity; q++ {
+				units = append(units, unit{line: i, price: lines[i].UnitPrice})
+			}
+		}
+		sort.SliceStable(units, func(a, b int) bool { return units[a].price.Cmp(units[b].price) < 0 })
+		free := len(units) / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+		for _, u := range units[:free] {
+			applied.perLine[u.line] = applied.perLine[u.line].Add(u.price)
+		}
+
+	case entities.PromotionFreeItem:
+		free := p.GetQuantity
+		for i, line := range lines {
+			if free == 0 || p.FreeMenuID == nil || line.MenuID != *p.FreeMenuID {
+				continue
+			}
+			units := min(free, line.Quantity)
+			applied.perLine[i] = line.UnitPrice.MulInt(units)
+			free -= units
+		}
+	}
+
+	for _, amount := range applied.perLine {
+		applied.total = applied.total.Add(amount)
+	}
+	return applied
+}
+
+func (s *promotionService) RedeemPromotions(ctx context.Context, userID, orderID utils.BinaryUUID, discounts []contract.DiscountLine) *exception.AppError {
+	if len(discounts) == 0 {
+		return nil
+	}
+
+	ids := make([]utils.BinaryUUID, 0, len(discounts))
+	for _, discount := range discounts {
+		ids = append(ids, discount.PromotionID)
+	}
+	promotions, err := s.promotionRepo.LockPromotionsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(promotions) != len(ids) {
+		return exception.NewConflictError("a promotion in your cart is no longer available")
+	}
+
+	now := time.Now()
+	redemptions := make([]entities.PromotionRedemption, 0, len(promotions))
+	for i := range promotions {
+		promotion := &promotions[i]
+		// The limits are checked again under lock, as other checkouts may have used up the promotion
+		usable, err := s.withinLimits(ctx, promotion, userID, true)
+		if err != nil {
+			return err
+		}
+		unavailable := exception.NewConflictError(fmt.Sprintf("promotion %s is no longer available", promotion.Name), promotion.ID.String())
+		if !promotion.IsRunning(now) || !usable {
+			return unavailable
+		}
+		// Taking the use enforces the global limit once more, as the redemption is recorded
+		claimed, err := s.promotionRepo.ClaimPromotionUse(ctx, promotion.ID)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return unavailable
+		}
+		redemptions = append(redemptions, entities.PromotionRedemption{
+			PromotionID: promotion.ID,
+			UserID:      userID,
+			OrderID:     orderID,
+		})
+	}
+	return s.promotionRepo.CreateRedemptions(ctx, redemptions)
+}
+
+func (s *promotionService) SyncRedemptions(ctx context.Context, userID, orderID utils.BinaryUUID, discounts []contract.DiscountLine) *exception.AppError {
+	redemptions, err := s.promotionRepo.GetRedemptionsByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[utils.BinaryUUID]bool, len(discounts))
+	for _, discount := range discounts {
+		applied[discount.PromotionID] = true
+	}
+	redeemed := make(map[utils.BinaryUUID]bool, len(redemptions))
+	for _, redemption := range redemptions {
+		redeemed[redemption.PromotionID] = true
+		if applied[redemption.PromotionID] {
+			continue
+		}
+		// No longer applies to the order; give the use back
+		if err := s.promotionRepo.AdjustUsageCount(ctx, redemption.PromotionID, -1); err != nil {
+			return err
+		}
+		if err := s.promotionRepo.DeleteRedemption(ctx, orderID, redemption.PromotionID); err != nil {
+			return err
+		}
+	}
+
+	var added []contract.DiscountLine
+	for _, discount := range discounts {
+		if !redeemed[discount.PromotionID] {
+			added = append(added, discount)
+		}
+	}
+	return s.RedeemPromotions(ctx, userID, orderID, added)
+}
+
+func (s *promotionService) ReleasePromotions(ctx context.Context, orderID utils.BinaryUUID) *exception.AppError {
+	redemptions, err := s.promotionRepo.GetRedemptionsByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, redemption := range redemptions {
+		if err := s.promotionRepo.AdjustUsageCount(ctx, redemption.PromotionID, -1); err != nil {
+			return err
+		}
+	}
+	return s.promotionRepo.DeleteRedemptionsByOrderID(ctx, orderID)
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"strings"
+	"time"
+)
+
+// pendingRefundBatchSize caps the pending refunds retried per run; the rest are picked up on the next run
+const pendingRefundBatchSize = 50
+
+type refundService struct {
+	refundRepo  contract.RefundRepository
+	orderRepo   contract.OrderRepository
+	paymentRepo contract.PaymentRepository
+	menuRepo    contract.MenuRepository
+	txManager   contract.TransactionManager
+	provider    contract.PaymentProvider
+}
+
+func NewRefundService(refundRepo contract.RefundRepository, orderRepo contract.OrderRepository, paymentRepo contract.PaymentRepository, menuRepo contract.MenuRepository, txManager contract.TransactionManager, provider contract.PaymentProvider) contract.RefundService {
+	return &refundService{refundRepo: refundRepo, orderRepo: orderRepo, paymentRepo: paymentRepo, menuRepo: menuRepo, txManager: txManager, provider: provider}
+}
+
+func (s *refundService) RefundOrder(ctx context.Context, orderID utils.BinaryUUID, issuedBy *utils.BinaryUUID, request contract.RefundRequest) (*entities.Refund, *exception.AppError) {
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Reason == "" {
+		return nil, exception.NewValidationError("a reason is required for a refund")
+	}
+
+	var (
+		refund  *entities.Refund
+		payment *entities.Payment
+	)
+	// Record the refund as pending first, so the money is never paid back without a record of it.
+	// Locking the order keeps two refunds from both paying back the same money.
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		locked, err := s.orderRepo.GetOrderByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		order, err := s.orderRepo.GetOrderWithItems(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		var paid *utils.GormDecimal
+		payment, paid, err = s.paidAmount(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if payment == nil {
+			return exception.NewConflictError("only paid orders can be refunded")
+		}
+		refundable := paid.Sub(locked.AmountRefunded)
+		if refundable.Sign() <= 0 {
+			return exception.NewConflictError("the order has already been fully refunded")
+		}
+
+		items, amount, err := refundItems(order, request.Items)
+		if err != nil {
+			return err
+		}
+		// A full refund also pays back the fees; a partial one never more than is left
+		if len(request.Items) == 0 || amount.Cmp(refundable) > 0 {
+			amount = refundable
+		}
+
+		refund = &entities.Refund{
+			ID:               utils.NewBinaryUUID(),
+			OrderID:          orderID,
+			PaymentID:        payment.ID,
+			Amount:           amount,
+			Reason:           request.Reason,
+			Status:           entities.RefundPending,
+			CreatedBy:        issuedBy,
+			RestockRequested: request.Restock,
+			Items:            items,
+		}
+		for _, item := range items {
+			if err := s.refundRepo.AddRefundedQuantity(ctx, item.OrderItemID, item.Quantity); err != nil {
+				return err
+			}
+		}
+		if err := s.refundRepo.AddAmountRefunded(ctx, orderID, refund.Amount); err != nil {
+			return err
+		}
+		return s.refundRepo.CreateRefund(ctx, refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Once recorded, the refund is settled even if the admin's request goes away in the meantime
+	ctx = context.WithoutCancel(ctx)
+	if err := s.settleRefund(ctx, refund, payment); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (s *refundService) RetryPendingRefunds(ctx context.Context, pendingFor time.Duration) (int, *exception.AppError) {
+	refundIDs, err := s.refundRepo.GetPendingRefundIDs(ctx, time.Now().Add(-pendingFor), pendingRefundBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, refundID := range refundIDs {
+		refund, err := s.refundRepo.GetRefundByID(ctx, refundID)
+		if err != nil {
+			return settled, err
+		}
+		payments, err := s.paymentRepo.GetPaymentsByOrderID(ctx, refund.OrderID)
+		if err != nil {
+			return settled, err
+		}
+		var payment *entities.Payment
+		for i := range payments {
+			if payments[i].ID == refund.PaymentID {
+				payment = &payments[i]
+			}
+		}
+		if payment == nil {
+			return settled, exception.NewAppError(nil, fmt.Sprintf("payment of refund %s not found", refund.ID), exception.CodeNotFound)
+		}
+
+		// A provider that is still unreachable leaves the refund pending for the next run
+		if err := s.settleRefund(ctx, refund, payment); err != nil {
+			log.Printf("failed to settle pending refund %s: %v", refund.ID, err)
+			continue
+		}
+		settled++
+	}
+	return settled, nil
+}
+
+// settleRefund asks the provider to pay back a pending refund and records the outcome. A decline
+// gives the refund's items and amount back to the order. After any other error it is unknown
+// whether the money went back, so the refund stays pending and keeps its share of the order;
+// asking again under the same refund ID never pays twice.
+func (s *refundService) settleRefund(ctx context.Context, refund *entities.Refund, payment *entities.Payment) *exception.AppError {
+	if refund.Amount.Sign() > 0 {
+		reference, providerErr := s.provider.Refund(ctx, payment, refund)
+		if errors.Is(providerErr, contract.ErrRefundDeclined) {
+			if err := s.failRefund(ctx, refund, providerErr.Error()); err != nil {
+				return err
+			}
+			return exception.NewAppError(providerErr, "failed to refund payment")
+		}
+		if providerErr != nil {
+			return exception.NewAppError(providerErr, "the refund could not be confirmed with the payment provider; it stays pending and is retried")
+		}
+		refund.ProviderReference = reference
+	}
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		locked, err := s.orderRepo.GetOrderByIDForUpdate(ctx, refund.OrderID)
+		if err != nil {
+			return err
+		}
+		current, err := s.refundRepo.GetRefundByIDForUpdate(ctx, refund.ID)
+		if err != nil {
+			return err
+		}
+		// Settled by a retry in the meantime
+		if current.Status != entities.RefundPending {
+			refund.Status, refund.Restocked = current.Status, current.Restocked
+			return nil
+		}
+
+		// Items of an order that was restocked when it was cancelled are already back in stock
+		if refund.RestockRequested && locked.RestockedAt == nil {
+			order, err := s.orderRepo.GetOrderWithItems(ctx, refund.OrderID)
+			if err != nil {
+				return err
+			}
+			if err := s.restockItems(ctx, order, refund.Items); err != nil {
+				return err
+			}
+			for _, item := range refund.Items {
+				if err := s.refundRepo.AddRestockedQuantity(ctx, item.OrderItemID, item.Quantity); err != nil {
+					return err
+				}
+			}
+			refund.Restocked = true
+		}
+		refund.Status = entities.RefundSucceeded
+		return s.refundRepo.UpdateRefundOutcome(ctx, refund)
+	})
+}
+
+// failRefund records that the provider declined a refund and gives its items and amount back to
+// the order, so the refund can be issued again
+func (s *refundService) failRefund(ctx context.Context, refund *entities.Refund, reason string) *exception.AppError {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) *exception.AppError {
+		if _, err := s.orderRepo.GetOrderByIDForUpdate(ctx, refund.OrderID); err != nil {
+			return err
+		}
+		current, err := s.refundRepo.GetRefundByIDForUpdate(ctx, refund.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status != entities.RefundPending {
+			return nil
+		}
+		for _, item := range refund.Items {
+			if err := s.refundRepo.AddRefundedQuantity(ctx, item.OrderItemID, -item.Quantity); err != nil {
+				return err
+			}
+		}
+		if err := s.refundRepo.AddAmountRefunded(ctx, refund.OrderID, refund.Amount.Neg()); err != nil {
+			return err
+		}
+		refund.Status = entities.RefundFailed
+		refund.FailureReason = reason
+		if len(refund.FailureReason) > 255 {
+			refund.FailureReason = refund.FailureReason[:255]
+		}
+		return s.refundRepo.UpdateRefundOutcome(ctx, refund)
+	})
+}
+
+func (s *refundService) GetOrderRefunds(ctx context.Context, orderID utils.BinaryUUID) ([]entities.Refund, *exception.AppError) {
+	if _, err := s.orderRepo.GetOrderByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return s.refundRepo.GetRefundsByOrderID(ctx, orderID)
+}
+
+// paidAmount adds up the succeeded payments of an order and returns the latest one, which refunds
+// are paid back through. The payment is nil when the order hasn't been paid.
+func (s *refundService) paidAmount(ctx context.Context, orderID utils.BinaryUUID) (*entities.Payment, *utils.GormDecimal, *exception.AppError) {
+	payments, err := s.paymentRepo.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var latest *entities.Payment
+	paid := utils.NewMoney(0, 2)
+	for i := range payments {
+		if payments[i].Status != entities.PaymentSucceeded {
+			continue
+		}
+		paid = paid.Add(payments[i].Amount)
+		if latest == nil || payments[i].CreatedAt.After(latest.CreatedAt) {
+			latest = &payments[i]
+		}
+	}
+	return latest, paid, nil
+}
+
+// refundItems works out the items a refund covers and what the customer paid for them. Without
+// requested items, everything not refunded yet is covered. Each item is valued at its share of the
+// order total without fees, so discounts and tax are refunded in proportion.
+func refundItems(order *entities.Order, requested []contract.RefundItemRequest) ([]entities.RefundItem, *utils.GormDecimal, *exception.AppError) {
+	orderItems := make(map[utils.BinaryUUID]*entities.OrderItem, len(order.OrderItems))
+	for i := range order.OrderItems {
+		orderItems[order.OrderItems[i].ID] = &order.OrderItems[i]
+	}
+
+	quantities := make(map[utils.BinaryUUID]int)
+	var itemIDs []utils.BinaryUUID
+	if len(requested) == 0 {
+		for _, item := range order.OrderItems {
+			if remaining := item.Quantity - item.RefundedQuantity; remaining > 0 {
+				quantities[item.ID] = remaining
+				itemIDs = append(itemIDs, item.ID)
+			}
+		}
+	}
+	for _, request := range requested {
+		item, ok := orderItems[request.OrderItemID]
+		if !ok {
+			return nil, nil, exception.NewAppError(nil, "order item not found", exception.CodeNotFound)
+		}
+		if request.Quantity <= 0 {
+			return nil, nil, exception.NewValidationError("refund quantity must be greater than zero")
+		}
+		if _, seen := quantities[item.ID]; !seen {
+			itemIDs = append(itemIDs, item.ID)
+		}
+		quantities[item.ID] += request.Quantity
+		if remaining := item.Quantity - item.RefundedQuantity; quantities[item.ID] > remaining {
+			return nil, nil, exception.NewValidationError(fmt.Sprintf("only %d of %s can still be refunded", remaining, item.MenuName))
+		}
+	}
+
+	items := make([]entities.RefundItem, 0, len(itemIDs))
+	total := utils.NewMoney(0, 2)
+	for _, id := range itemIDs {
+		item := orderItems[id]
+		amount := paidShare(order, item.Price.MulInt(quantities[id]))
+		total = total.Add(amount)
+		items = append(items, entities.RefundItem{
+			OrderItemID: id,
+			MenuName:    item.MenuName,
+			Quantity:    quantities[id],
+			Amount:      amount,
+		})
+	}
+	return items, total, nil
+}
+
+// paidShare is what the customer paid for items listed at the given amount, after discounts and
+// with any added tax: their share of the order total without fees, rounded down to cents
+func paidShare(order *entities.Order, listed *utils.GormDecimal) *utils.GormDecimal {
+	if order.Subtotal.Sign() == 0 {
+		return utils.NewMoney(0, 2)
+	}
+	paid := order.TotalAmount.Sub(order.FeeAmount)
+	return listed.Mul(paid).Div(order.Subtotal, 2, utils.RoundDown)
+}
+
+// restockItems puts the refunded quantities back into the menus and options they were taken from
+func (s *refundService) restockItems(ctx context.Context, order *entities.Order, items []entities.RefundItem) *exception.AppError {
+	orderItems := make(map[utils.BinaryUUID]*entities.OrderItem, len(order.OrderItems))
+	for i := range order.OrderItems {
+		orderItems[order.OrderItems[i].ID] = &order.OrderItems[i]
+	}
+
+	quantities := make(map[utils.BinaryUUID]int)
+	optionQuantities := make(map[utils.BinaryUUID]int)
+	for _, item := range items {
+		orderItem := orderItems[item.OrderItemID]
+		quantities[orderItem.MenuID] += item.Quantity
+		for _, option := range orderItem.Options {
+			optionQuantities[option.OptionID] += item.Quantity
+		}
+	}
+	if err := releaseStock(ctx, s.menuRepo, quantities); err != nil {
+		return err
+	}
+	return releaseOptionStock(ctx, s.menuRepo, optionQuantities)
+}
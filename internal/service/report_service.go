@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"math"
 	"shopify-app/internal/contract"
 	"shopify-app/internal/exception"
 	"shopify-app/internal/utils"
@@ -13,10 +14,11 @@ import (
 
 type reportService struct {
 	reportRepo contract.ReportRepository
+	slotSvc    contract.SlotService
 }
 
-func NewReportService(reportRepo contract.ReportRepository) contract.ReportService {
-	return &reportService{reportRepo: reportRepo}
+func NewReportService(reportRepo contract.ReportRepository, slotSvc contract.SlotService) contract.ReportService {
+	return &reportService{reportRepo: reportRepo, slotSvc: slotSvc}
 }
 
 func (s *reportService) GenerateSalesReport(ctx context.Context, startDate, endDate time.Time, groupBy string) ([]contract.SalesReportData, *exception.AppError) {
@@ -96,7 +98,7 @@ func (s *reportService) ExportSalesReportCSV(ctx context.Context, startDate, end
 	w := csv.NewWriter(&b)
 
 	// Write header
-	if err := w.Write([]string{"Date", "TotalSales", "OrderCount", "ItemsSold"}); err != nil {
+	if err := w.Write([]string{"Date", "TotalSales", "NetSales", "TaxAmount", "Discounts", "Refunds", "OrderCount", "ItemsSold"}); err != nil {
 		return nil, exception.NewAppError(err, "failed to write csv header")
 	}
 
@@ -104,7 +106,11 @@ func (s *reportService) ExportSalesReportCSV(ctx context.Context, startDate, end
 	for _, record := range salesData {
 		row := []string{
 			record.Date.Format("2006-01-02"),
-			utils.GormDecimalToString(*record.TotalSales),
+			record.TotalSales.String(),
+			record.NetSales.String(),
+			record.TaxAmount.String(),
+			record.Discounts.String(),
+			record.Refunds.String(),
 			strconv.FormatInt(record.OrderCount, 10),
 			strconv.FormatInt(record.ItemsSold, 10),
 		}
@@ -121,6 +127,50 @@ func (s *reportService) ExportSalesReportCSV(ctx context.Context, startDate, end
 	return b.Bytes(), nil
 }
 
+func (s *reportService) GetSlotLoadReport(ctx context.Context, from, to time.Time) ([]contract.SlotLoad, *exception.AppError) {
+	if err := s.ValidateReportDateRange(from, to); err != nil {
+		return nil, err
+	}
+	load, err := s.reportRepo.GetSlotLoad(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	maxOrders, maxItems := s.slotSvc.Capacity()
+	for i := range load {
+		load[i].MaxOrders = maxOrders
+		load[i].MaxItems = maxItems
+	}
+	return load, nil
+}
+
+func (s *reportService) GetAbandonedCartReport(ctx context.Context, startDate, endDate time.Time, limit int) (*contract.AbandonedCartReport, *exception.AppError) {
+	if err := s.ValidateReportDateRange(startDate, endDate); err != nil {
+		return nil, err
+	}
+	report, err := s.reportRepo.GetAbandonedCartStats(ctx, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if report.TopItems, err = s.reportRepo.GetMostAbandonedItems(ctx, startDate, endDate, limit); err != nil {
+		return nil, err
+	}
+
+	// A cart either ends in an order or is abandoned; recovered carts count as abandoned
+	report.AbandonmentRate = percentage(report.AbandonedCarts, report.AbandonedCarts+report.CheckedOutCarts)
+	report.RecoveryRate = percentage(report.RecoveredCarts, report.AbandonedCarts)
+	return report, nil
+}
+
+// percentage returns part as a percentage of whole, rounded to two decimal places, or 0 when
+// whole is 0
+func percentage(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(whole)) / 100
+}
+
 func (s *reportService) ValidateReportDateRange(startDate, endDate time.Time) *exception.AppError {
 	if startDate.IsZero() || endDate.IsZero() {
 		return exception.NewAppError(nil, "start and end dates are required")
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"sync"
+	"time"
+)
+
+const (
+	// schedulerTick is how often the scheduler checks for due jobs
+	schedulerTick = time.Second
+	// jobTimeout bounds a single run. It is also how long the job stays locked, so a run left
+	// behind by a crashed instance is picked up by another one after this.
+	jobTimeout = 10 * time.Minute
+	// jobFinishTimeout bounds recording the outcome of a run, which also happens during shutdown
+	jobFinishTimeout = 5 * time.Second
+)
+
+// registeredJob is a job known to this scheduler
+type registeredJob struct {
+	name     string
+	schedule utils.Schedule
+	run      contract.JobFunc
+	next     time.Time // When this instance tries to claim the job next
+	running  bool      // A run started by this instance has not finished yet
+}
+
+type scheduler struct {
+	jobRepo contract.JobRepository
+	owner   string
+
+	mu      sync.Mutex
+	jobs    []*registeredJob
+	started bool
+	stopped bool
+
+	stop       chan struct{}
+	loopDone   chan struct{}
+	runs       sync.WaitGroup
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+}
+
+// NewScheduler creates a scheduler that coordinates with other server instances through jobRepo
+func NewScheduler(jobRepo contract.JobRepository) contract.JobScheduler {
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	return &scheduler{
+		jobRepo:    jobRepo,
+		owner:      instanceName(),
+		stop:       make(chan struct{}),
+		loopDone:   make(chan struct{}),
+		jobCtx:     jobCtx,
+		cancelJobs: cancelJobs,
+	}
+}
+
+func (s *scheduler) Register(name string, schedule utils.Schedule, job contract.JobFunc) *exception.AppError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return exception.NewConflictError("jobs cannot be registered after the scheduler has started")
+	}
+	if name == "" || len(name) > 100 {
+		return exception.NewValidationError("job name must be between 1 and 100 characters")
+	}
+	for _, existing := range s.jobs {
+		if existing.name == name {
+			return exception.NewConflictError(fmt.Sprintf("job '%s' is already registered", name))
+		}
+	}
+
+	s.jobs = append(s.jobs, &registeredJob{name: name, schedule: schedule, run: job})
+	return nil
+}
+
+func (s *scheduler) Start(ctx context.Context) *exception.AppError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return exception.NewConflictError("scheduler has already started")
+	}
+
+	now := time.Now()
+	for _, job := range s.jobs {
+		if err := s.jobRepo.RegisterJob(ctx, job.name, job.schedule.String()); err != nil {
+			return err
+		}
+		job.next = job.schedule.Next(now)
+	}
+	s.started = true
+
+	go s.loop()
+	return nil
+}
+
+func (s *scheduler) Stop(ctx context.Context) *exception.AppError {
+	s.mu.Lock()
+	if !s.started || s.stopped {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopped = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.loopDone
+
+	finished := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		s.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		// Out of time; tell the running jobs to give up. Their locks expire on their own.
+		s.cancelJobs()
+		return exception.NewAppError(ctx.Err(), "running jobs did not finish before shutdown")
+	}
+}
+
+func (s *scheduler) GetJobs(ctx context.Context) ([]contract.JobStatus, *exception.AppError) {
+	rows, err := s.jobRepo.GetJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]int, len(rows))
+	for i := range rows {
+		stored[rows[i].Name] = i
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]contract.JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		status := contract.JobStatus{
+			Name:     job.name,
+			Schedule: job.schedule.String(),
+		}
+		if !job.next.IsZero() {
+			next := job.next
+			status.NextRunAt = &next
+		}
+
+		// The database has the runs of all instances, not only this one
+		if i, ok := stored[job.name]; ok {
+			row := &rows[i]
+			if row.IsLocked(now) {
+				status.Running = true
+				status.RunningOn = row.LockedBy
+			}
+			if row.NextRunAt != nil {
+				status.NextRunAt = row.NextRunAt
+			}
+			status.LastStartedAt = row.LastStartedAt
+			status.LastFinishedAt = row.LastFinishedAt
+			status.LastDurationMs = row.LastDurationMs
+			status.LastError = row.LastError
+			status.RunCount = row.RunCount
+			status.FailureCount = row.FailureCount
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// loop starts the jobs that are due until the scheduler is stopped
+func (s *scheduler) loop() {
+	defer close(s.loopDone)
+
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.startDueJobs(now)
+		}
+	}
+}
+
+// startDueJobs starts a run of every job that is due and not still running on this instance
+func (s *scheduler) startDueJobs(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.running || job.next.IsZero() || now.Before(job.next) {
+			continue
+		}
+		job.next = job.schedule.Next(now)
+		job.running = true
+		s.runs.Add(1)
+		go s.runJob(job, now, job.next)
+	}
+}
+
+// runJob claims a job and runs it when no other instance has done so for this turn
+func (s *scheduler) runJob(job *registeredJob, now, next time.Time) {
+	defer s.runs.Done()
+	defer func() {
+		s.mu.Lock()
+		job.running = false
+		s.mu.Unlock()
+	}()
+
+	claimed, err := s.jobRepo.ClaimJob(s.jobCtx, job.name, s.owner, now, now.Add(jobTimeout), next)
+	if err != nil {
+		log.Printf("failed to claim job %s: %v", job.name, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	started := time.Now()
+	runErr := s.execute(job)
+	finished := time.Now()
+
+	message := ""
+	if runErr != nil {
+		message = runErr.Error()
+		log.Printf("job %s failed: %s", job.name, message)
+	}
+
+	// Record the outcome even when the run was cancelled by a shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), jobFinishTimeout)
+	defer cancel()
+	if err := s.jobRepo.FinishJob(ctx, job.name, s.owner, finished, finished.Sub(started), message); err != nil {
+		log.Printf("failed to record run of job %s: %v", job.name, err)
+	}
+}
+
+// execute runs a job within its timeout, turning a panic into an error
+func (s *scheduler) execute(job *registeredJob) (appErr *exception.AppError) {
+	ctx, cancel := context.WithTimeout(s.jobCtx, jobTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			appErr = exception.NewAppError(fmt.Errorf("%v", r), "job panicked")
+		}
+	}()
+	return job.run(ctx)
+}
+
+// instanceName identifies this server process in job locks
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), utils.NewBinaryUUID().String()[:8])
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/exception"
+	"time"
+)
+
+// SlotSettings configures how pickup and delivery slots are laid out and how full they may get
+type SlotSettings struct {
+	Interval    time.Duration // Length of a slot
+	OpensAt     time.Duration // Start of the first slot, as time since midnight
+	ClosesAt    time.Duration // No slot ends later than this, as time since midnight
+	MaxOrders   int           // Orders per slot; 0 for no limit
+	MaxItems    int           // Items per slot; 0 for no limit
+	BookingDays int           // How many days ahead slots can be booked
+	LeadTime    time.Duration // Scheduled orders are released to the kitchen this long before their slot
+	Location    *time.Location
+}
+
+type slotService struct {
+	slotRepo contract.SlotRepository
+	settings SlotSettings
+}
+
+func NewSlotService(slotRepo contract.SlotRepository, settings SlotSettings) contract.SlotService {
+	if settings.Location == nil {
+		settings.Location = time.Local
+	}
+	return &slotService{slotRepo: slotRepo, settings: settings}
+}
+
+func (s *slotService) GetSlots(ctx context.Context, day time.Time) ([]contract.TimeSlot, *exception.AppError) {
+	from := s.timeOfDay(day, s.settings.OpensAt)
+	to := s.timeOfDay(day, s.settings.ClosesAt)
+
+	bookings, err := s.slotRepo.GetSlotBookings(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	booked := make(map[int64]int, len(bookings))
+	for i, booking := range bookings {
+		booked[booking.SlotStart.Unix()] = i
+	}
+
+	slots := []contract.TimeSlot{}
+	for offset := s.settings.OpensAt; offset+s.settings.Interval <= s.settings.ClosesAt; offset += s.settings.Interval {
+		start := s.timeOfDay(day, offset)
+		if s.ValidateSlot(start) != nil {
+			continue
+		}
+		slot := contract.TimeSlot{Start: start, End: s.timeOfDay(day, offset+s.settings.Interval), Available: true}
+		if i, ok := booked[start.Unix()]; ok {
+			slot.OrderCount = bookings[i].OrderCount
+			slot.ItemCount = bookings[i].ItemCount
+		}
+		if s.settings.MaxOrders > 0 {
+			remaining := max(s.settings.MaxOrders-slot.OrderCount, 0)
+			slot.RemainingOrders = &remaining
+			slot.Available = remaining > 0
+		}
+		if s.settings.MaxItems > 0 {
+			remaining := max(s.settings.MaxItems-slot.ItemCount, 0)
+			slot.RemainingItems = &remaining
+			slot.Available = slot.Available && remaining > 0
+		}
+		slots = append(slots, slot)
+	}
+	return slots, nil
+}
+
+func (s *slotService) ValidateSlot(slotStart time.Time) *exception.AppError {
+	now := time.Now()
+	start := slotStart.In(s.settings.Location)
+	offset := sinceMidnight(start)
+
+	// When the clocks go back, only the first of the repeated times starts a slot
+	if offset < s.settings.OpensAt || offset+s.settings.Interval > s.settings.ClosesAt || (offset-s.settings.OpensAt)%s.settings.Interval != 0 ||
+		!s.timeOfDay(start, offset).Equal(start) {
+		return exception.NewValidationError("scheduled time is not the start of a time slot")
+	}
+	if start.Before(now.Add(s.settings.LeadTime)) {
+		return exception.NewValidationError(fmt.Sprintf("scheduled time must be at least %s from now", s.settings.LeadTime))
+	}
+	if !start.Before(s.startOfDay(now).AddDate(0, 0, s.settings.BookingDays+1)) {
+		return exception.NewValidationError(fmt.Sprintf("orders can be scheduled at most %d days ahead", s.settings.BookingDays))
+	}
+	return nil
+}
+
+func (s *slotService) BookSlot(ctx context.Context, slotStart time.Time, orders, items int) *exception.AppError {
+	booking, err := s.slotRepo.LockSlotBooking(ctx, slotStart)
+	if err != nil {
+		return err
+	}
+
+	booking.OrderCount = max(booking.OrderCount+orders, 0)
+	booking.ItemCount = max(booking.ItemCount+items, 0)
+	if orders > 0 && s.settings.MaxOrders > 0 && booking.OrderCount > s.settings.MaxOrders {
+		return exception.NewConflictError("the selected time slot is fully booked")
+	}
+	if items > 0 && s.settings.MaxItems > 0 && booking.ItemCount > s.settings.MaxItems {
+		return exception.NewConflictError("the selected time slot has no room for this many items")
+	}
+	return s.slotRepo.UpdateSlotBookingCounts(ctx, booking)
+}
+
+func (s *slotService) ReleaseCutoff(now time.Time) time.Time {
+	return now.Add(s.settings.LeadTime)
+}
+
+func (s *slotService) Capacity() (int, int) {
+	return s.settings.MaxOrders, s.settings.MaxItems
+}
+
+// timeOfDay returns the given wall-clock time, as time since midnight, on the day t falls on in the
+// store's time zone. The time is built from the calendar fields rather than added to midnight, so
+// slots keep their wall-clock times on the days the clocks change.
+func (s *slotService) timeOfDay(t time.Time, offset time.Duration) time.Time {
+	t = t.In(s.settings.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, int(offset), s.settings.Location)
+}
+
+// sinceMidnight returns the wall-clock time of t as time since midnight
+func sinceMidnight(t time.Time) time.Duration {
+	hour, minute, second := t.Clock()
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// startOfDay returns midnight of the day t falls on, in the store's time zone
+func (s *slotService) startOfDay(t time.Time) time.Time {
+	t = t.In(s.settings.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.settings.Location)
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"time"
+)
+
+type stockHoldService struct {
+	holdRepo contract.StockHoldRepository
+	menuRepo contract.MenuRepository
+	ttl      time.Duration
+}
+
+// NewStockHoldService creates the stock hold service. Carts hold their contents for ttl after
+// every change; a ttl of 0 turns holds off.
+func NewStockHoldService(holdRepo contract.StockHoldRepository, menuRepo contract.MenuRepository, ttl time.Duration) contract.StockHoldService {
+	return &stockHoldService{holdRepo: holdRepo, menuRepo: menuRepo, ttl: ttl}
+}
+
+func (s *stockHoldService) Enabled() bool {
+	return s.ttl > 0
+}
+
+func (s *stockHoldService) HoldCart(ctx context.Context, cart *entities.Cart) *exception.AppError {
+	if !s.Enabled() {
+		return nil
+	}
+
+	menuNeeds := make(map[utils.BinaryUUID]int)
+	optionNeeds := make(map[utils.BinaryUUID]int)
+	for _, item := range cart.CartItems {
+		menuNeeds[item.MenuID] += item.Quantity
+		for _, option := range item.Options {
+			optionNeeds[option.OptionID] += item.Quantity
+		}
+	}
+
+	menus, options, heldByOthers, err := s.lockHeldStock(ctx, &cart.ID, sortedIDs(menuNeeds), sortedIDs(optionNeeds))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	ownHeld, err := s.holdRepo.GetCartHeldStock(ctx, cart.ID, now)
+	if err != nil {
+		return err
+	}
+
+	// What the cart holds already stays its own, even when the stock has dropped below it since.
+	// Only the quantity on top of that has to be free.
+	for _, menu := range menus {
+		need := menuNeeds[menu.ID]
+		if need > ownHeld.Menus[menu.ID] && !menu.IsInStock(need+heldByOthers.Menus[menu.ID]) {
+			return exception.NewConflictError(fmt.Sprintf("only %d of %s available", available(menu.Stock, heldByOthers.Menus[menu.ID]), menu.Name), menu.ID.String())
+		}
+	}
+	for _, option := range options {
+		need := optionNeeds[option.ID]
+		if need > ownHeld.Options[option.ID] && !option.IsInStock(need+heldByOthers.Options[option.ID]) {
+			return exception.NewConflictError(fmt.Sprintf("only %d of %s available", available(*option.Stock, heldByOthers.Options[option.ID]), option.Name), option.ID.String())
+		}
+	}
+
+	holds := make([]entities.StockHold, 0, len(cart.CartItems))
+	for _, item := range cart.CartItems {
+		holds = append(holds, entities.StockHold{
+			CartID:     cart.ID,
+			CartItemID: item.ID,
+			MenuID:     item.MenuID,
+			Quantity:   item.Quantity,
+			ExpiresAt:  now.Add(s.ttl),
+		})
+	}
+	return s.holdRepo.ReplaceCartHolds(ctx, cart.ID, holds)
+}
+
+func (s *stockHoldService) ReleaseCart(ctx context.Context, cartID utils.BinaryUUID) *exception.AppError {
+	// Also when holds are off, so that holds left from before don't linger
+	return s.holdRepo.DeleteCartHolds(ctx, cartID)
+}
+
+func (s *stockHoldService) LockHeldByOthers(ctx context.Context, cartID *utils.BinaryUUID, menuIDs, optionIDs []utils.BinaryUUID) (*contract.HeldStock, *exception.AppError) {
+	_, _, held, err := s.lockHeldStock(ctx, cartID, menuIDs, optionIDs)
+	return held, err
+}
+
+func (s *stockHoldService) HeldByOthers(ctx context.Context, cartID utils.BinaryUUID, menuIDs, optionIDs []utils.BinaryUUID) (*contract.HeldStock, *exception.AppError) {
+	if !s.Enabled() {
+		return &contract.HeldStock{}, nil
+	}
+	return s.holdRepo.GetHeldStock(ctx, menuIDs, optionIDs, time.Now(), &cartID)
+}
+
+// lockHeldStock locks the given menu and option rows, menus first like reserveStock does, and sums
+// the active holds of the other carts on them. The locks keep new holds off these rows until the
+// transaction ends, so the sums stay valid. Options that don't track stock are left out.
+func (s *stockHoldService) lockHeldStock(ctx context.Context, cartID *utils.BinaryUUID, menuIDs, optionIDs []utils.BinaryUUID) ([]entities.Menu, []entities.MenuOption, *contract.HeldStock, *exception.AppError) {
+	if !s.Enabled() {
+		return nil, nil, &contract.HeldStock{}, nil
+	}
+
+	var menus []entities.Menu
+	if len(menuIDs) > 0 {
+		var err *exception.AppError
+		if menus, err = s.menuRepo.LockMenusByIDs(ctx, menuIDs); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var options []entities.MenuOption
+	var trackedIDs []utils.BinaryUUID
+	if len(optionIDs) > 0 {
+		locked, err := s.menuRepo.LockMenuOptionsByIDs(ctx, optionIDs)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, option := range locked {
+			if option.TracksStock() {
+				options = append(options, option)
+				trackedIDs = append(trackedIDs, option.ID)
+			}
+		}
+	}
+
+	held, err := s.holdRepo.GetHeldStock(ctx, menuIDs, trackedIDs, time.Now(), cartID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return menus, options, held, nil
+}
+
+func (s *stockHoldService) ApplyAvailableStock(ctx context.Context, menus []entities.Menu) *exception.AppError {
+	menuIDs := make([]utils.BinaryUUID, 0, len(menus))
+	var optionIDs []utils.BinaryUUID
+	for i := range menus {
+		menuIDs = append(menuIDs, menus[i].ID)
+		for _, group := range menus[i].OptionGroups {
+			for _, option := range group.Options {
+				if option.TracksStock() {
+					optionIDs = append(optionIDs, option.ID)
+				}
+			}
+		}
+	}
+
+	held := &contract.HeldStock{}
+	if s.Enabled() {
+		var err *exception.AppError
+		if held, err = s.holdRepo.GetHeldStock(ctx, menuIDs, optionIDs, time.Now(), nil); err != nil {
+			return err
+		}
+	}
+
+	for i := range menus {
+		menu := &menus[i]
+		menuAvailable := available(menu.Stock, held.Menus[menu.ID])
+		menu.AvailableStock = &menuAvailable
+		for gi := range menu.OptionGroups {
+			for oi := range menu.OptionGroups[gi].Options {
+				option := &menu.OptionGroups[gi].Options[oi]
+				if option.TracksStock() {
+					optionAvailable := available(*option.Stock, held.Options[option.ID])
+					option.AvailableStock = &optionAvailable
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *stockHoldService) ReleaseExpiredHolds(ctx context.Context) (int64, *exception.AppError) {
+	return s.holdRepo.DeleteExpiredHolds(ctx, time.Now())
+}
+
+// available returns the stock left once the held quantity is set aside
+func available(stock, held int) int {
+	return max(stock-held, 0)
+}
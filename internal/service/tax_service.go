@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"shopify-app/internal/contract"
+	"shopify-app/internal/entities"
+	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
+	"strings"
+)
+
+type taxService struct {
+	taxRepo          contract.TaxRuleRepository
+	menuRepo         contract.MenuRepository
+	pricesIncludeTax bool
+	rounding         utils.RoundingMode
+}
+
+func NewTaxService(taxRepo contract.TaxRuleRepository, menuRepo contract.MenuRepository, pricesIncludeTax bool, rounding utils.RoundingMode) contract.TaxService {
+	return &taxService{taxRepo: taxRepo, menuRepo: menuRepo, pricesIncludeTax: pricesIncludeTax, rounding: rounding}
+}
+
+func (s *taxService) CreateTaxRule(ctx context.Context, rule *entities.TaxRule) (*entities.TaxRule, *exception.AppError) {
+	if err := s.validateTaxRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	if err := s.taxRepo.CreateTaxRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *taxService) UpdateTaxRule(ctx context.Context, rule *entities.TaxRule) (*entities.TaxRule, *exception.AppError) {
+	existing, err := s.taxRepo.GetTaxRuleByID(ctx, rule.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateTaxRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	rule.CreatedAt = existing.CreatedAt
+	if err := s.taxRepo.UpdateTaxRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *taxService) DeleteTaxRule(ctx context.Context, id utils.BinaryUUID) *exception.AppError {
+	return s.taxRepo.DeleteTaxRule(ctx, id)
+}
+
+func (s *taxService) GetTaxRules(ctx context.Context) ([]entities.TaxRule, *exception.AppError) {
+	return s.taxRepo.GetAllTaxRules(ctx)
+}
+
+// validateTaxRule checks the rate and that the rule targets at most one menu item or category
+func (s *taxService) validateTaxRule(ctx context.Context, rule *entities.TaxRule) *exception.AppError {
+	if rule.Rate == nil {
+		return exception.NewValidationError("tax rate is required")
+	}
+	if rule.Rate.Sign() < 0 || rule.Rate.Cmp(hundredPercent) > 0 {
+		return exception.NewValidationError("tax rate must be between 0 and 100 percent")
+	}
+	if rule.MenuID != nil && rule.Category != "" {
+		return exception.NewValidationError("a tax rule applies to either a menu item or a category, not both")
+	}
+	if rule.MenuID != nil {
+		if _, err := s.menuRepo.GetMenuByID(ctx, *rule.MenuID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *taxService) CalculateTax(ctx context.Context, lines []contract.TaxableLine) (*contract.TaxBreakdown, *exception.AppError) {
+	rules, err := s.taxRepo.GetActiveTaxRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type ruleTotal struct {
+		taxable *utils.GormDecimal
+		amount  *utils.GormDecimal
+	}
+	totals := make(map[utils.BinaryUUID]*ruleTotal)
+	subtotal := utils.NewMoney(0, 2)
+	totalTax := utils.NewMoney(0, 2)
+	lineTaxes := make([]*utils.GormDecimal, len(lines))
+
+	for i, line := range lines {
+		subtotal = subtotal.Add(line.Amount)
+
+		lineTax := utils.NewMoney(0, 2)
+		applicable := matchTaxRules(rules, line)
+
+		// With tax-inclusive prices the listed amount is base * (1 + combined rate / 100), so each
+		// rule's tax is amount * rate / (100 + combined rate) and the base amount * 100 / (100 +
+		// combined rate). The bases are kept to 10 places until their sum is rounded.
+		divisor := hundredPercent
+		if s.pricesIncludeTax {
+			for _, rule := range applicable {
+				divisor = divisor.Add(rule.Rate)
+			}
+		}
+		base := line.Amount
+		if s.pricesIncludeTax && len(applicable) > 0 {
+			base = line.Amount.Mul(hundredPercent).Div(divisor, 10, s.rounding)
+		}
+
+		for _, rule := range applicable {
+			tax := line.Amount.Mul(rule.Rate).Div(divisor, 2, s.rounding)
+
+			total, ok := totals[rule.ID]
+			if !ok {
+				total = &ruleTotal{taxable: utils.NewMoney(0, 2), amount: utils.NewMoney(0, 2)}
+				totals[rule.ID] = total
+			}
+			total.taxable = total.taxable.Add(base)
+			total.amount = total.amount.Add(tax)
+			totalTax = totalTax.Add(tax)
+			lineTax = lineTax.Add(tax)
+		}
+		lineTaxes[i] = lineTax
+	}
+
+	grandTotal := subtotal
+	if !s.pricesIncludeTax {
+		grandTotal = grandTotal.Add(totalTax)
+	}
+
+	breakdown := &contract.TaxBreakdown{
+		Subtotal:         subtotal,
+		TaxAmount:        totalTax,
+		Total:            grandTotal,
+		PricesIncludeTax: s.pricesIncludeTax,
+		Lines:            []contract.TaxLine{},
+		LineTaxes:        lineTaxes,
+	}
+	for _, rule := range rules {
+		total, ok := totals[rule.ID]
+		if !ok {
+			continue
+		}
+		breakdown.Lines = append(breakdown.Lines, contract.TaxLine{
+			TaxRuleID:     rule.ID,
+			Name:          rule.Name,
+			Rate:          rule.Rate,
+			TaxableAmount: total.taxable.Round(2, s.rounding),
+			Amount:        total.amount,
+		})
+	}
+	return breakdown, nil
+}
+
+// matchTaxRules picks the rules for a line: rules for its menu item win over rules for its
+// category, which win over rules without a target
+func matchTaxRules(rules []entities.TaxRule, line contract.TaxableLine) []entities.TaxRule {
+	var byMenu, byCategory, general []entities.TaxRule
+	for _, rule := range rules {
+		switch {
+		case rule.MenuID != nil:
+			if *rule.MenuID == line.MenuID {
+				byMenu = append(byMenu, rule)
+			}
+		case rule.Category != "":
+			if strings.EqualFold(rule.Category, line.Category) {
+				byCategory = append(byCategory, rule)
+			}
+		default:
+			general = append(general, rule)
+		}
+	}
+
+	if len(byMenu) > 0 {
+		return byMenu
+	}
+	if len(byCategory) > 0 {
+		return byCategory
+	}
+	return general
+}
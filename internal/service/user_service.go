@@ -8,6 +8,7 @@ import (
 	"shopify-app/internal/exception"
 	"shopify-app/internal/utils"
 	"shopify-app/pkg/jwt"
+	"time"
 )
 
 type userService struct {
@@ -73,6 +74,22 @@ func (s *userService) Login(ctx context.Context, email, password string) (*entit
 	return user, token, nil
 }
 
+func (s *userService) CreateGuestToken(ctx context.Context) (string, time.Time, *exception.AppError) {
+	token, expiresAt, err := jwt.GenerateGuestToken(utils.NewBinaryUUID(), s.cfg.JWTSecret, s.cfg.GuestTokenTTL)
+	if err != nil {
+		return "", time.Time{}, exception.NewAppError(err, "failed to generate guest token")
+	}
+	return token, expiresAt, nil
+}
+
+func (s *userService) ParseGuestToken(token string) (utils.BinaryUUID, *exception.AppError) {
+	claims, err := jwt.ValidateGuestToken(token, s.cfg.JWTSecret)
+	if err != nil {
+		return utils.BinaryUUID{}, exception.NewAppError(err, "invalid guest token", exception.CodeUnauthorized)
+	}
+	return claims.GuestID, nil
+}
+
 func (s *userService) GetUserProfile(ctx context.Context, userID utils.BinaryUUID) (*entities.User, *exception.AppError) {
 	return s.userRepo.GetUserByID(ctx, userID)
 }
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// earthRadiusMeters is the mean radius of the earth used for distance calculations.
+const earthRadiusMeters = 6371008.8
+
+// GeoPoint is a position on the earth in decimal degrees.
+type GeoPoint struct {
+	Lat float64
+	Lng float64
+}
+
+// IsValid checks if the point lies within the latitude and longitude ranges.
+func (p GeoPoint) IsValid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// DistanceMeters returns the great-circle distance between two points (haversine formula).
+func DistanceMeters(a, b GeoPoint) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// GeoPolygon is an outer ring followed by optional holes. Each ring is closed (its first and last
+// points are equal).
+type GeoPolygon [][]GeoPoint
+
+// GeoArea is a set of polygons, as described by a GeoJSON Polygon or MultiPolygon.
+type GeoArea []GeoPolygon
+
+// geoJSON holds the members of a GeoJSON object needed to read polygon geometries.
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSON        `json:"geometry"`
+}
+
+// ParseGeoArea reads a GeoJSON Polygon or MultiPolygon geometry, or a Feature holding one.
+// Positions are [longitude, latitude] as the GeoJSON specification requires.
+func ParseGeoArea(data []byte) (GeoArea, error) {
+	var object geoJSON
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+	if object.Type == "Feature" {
+		if object.Geometry == nil {
+			return nil, fmt.Errorf("GeoJSON feature has no geometry")
+		}
+		object = *object.Geometry
+	}
+
+	var polygons [][][][]float64
+	switch object.Type {
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(object.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("invalid Polygon coordinates: %v", err)
+		}
+		polygons = append(polygons, polygon)
+	case "MultiPolygon":
+		if err := json.Unmarshal(object.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon coordinates: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("GeoJSON type %q is not supported; use Polygon or MultiPolygon", object.Type)
+	}
+	if len(polygons) == 0 {
+		return nil, fmt.Errorf("GeoJSON geometry has no polygons")
+	}
+
+	area := make(GeoArea, 0, len(polygons))
+	for _, polygon := range polygons {
+		if len(polygon) == 0 {
+			return nil, fmt.Errorf("GeoJSON polygon has no rings")
+		}
+		rings := make(GeoPolygon, 0, len(polygon))
+		for _, ring := range polygon {
+			if len(ring) < 4 {
+				return nil, fmt.Errorf("GeoJSON ring needs at least 4 positions")
+			}
+			points := make([]GeoPoint, 0, len(ring))
+			for _, position := range ring {
+				if len(position) < 2 {
+					return nil, fmt.Errorf("GeoJSON position needs a longitude and a latitude")
+				}
+				point := GeoPoint{Lat: position[1], Lng: position[0]}
+				if !point.IsValid() {
+					return nil, fmt.Errorf("GeoJSON position [%g, %g] is out of range", position[0], position[1])
+				}
+				points = append(points, point)
+			}
+			if points[0] != points[len(points)-1] {
+				return nil, fmt.Errorf("GeoJSON ring is not closed")
+			}
+			rings = append(rings, points)
+		}
+		area = append(area, rings)
+	}
+	return area, nil
+}
+
+// Contains checks if the point lies inside one of the polygons of the area.
+func (a GeoArea) Contains(p GeoPoint) bool {
+	for _, polygon := range a {
+		if polygon.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains checks if the point lies inside the outer ring and outside every hole.
+func (p GeoPolygon) Contains(point GeoPoint) bool {
+	if len(p) == 0 || !ringContains(p[0], point) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if ringContains(hole, point) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains checks if the point lies inside a closed ring by casting a ray towards increasing
+// longitude and counting the edges it crosses. Longitude and latitude are treated as planar
+// coordinates, which is accurate enough for delivery areas of a few kilometres.
+func ringContains(ring []GeoPoint, point GeoPoint) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) {
+			crossLng := a.Lng + (point.Lat-a.Lat)*(b.Lng-a.Lng)/(b.Lat-a.Lat)
+			if point.Lng < crossLng {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
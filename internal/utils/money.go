@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Money is an exact decimal amount of money, such as a price, a discount or a tax amount, in the
+// store's currency. Sums, differences and products are exact; only Div and Round give up
+// precision, and only in the rounding mode asked for.
+//
+// A Money is immutable: its methods return new values and never change the receiver, so amounts
+// can be shared freely. A nil *Money counts as zero.
+type Money struct {
+	coef   *big.Int // The amount times 10^places; nil for zero
+	places int32
+}
+
+// NewMoney creates the amount units / 10^places, e.g. NewMoney(1250, 2) is 12.50.
+func NewMoney(units int64, places int32) *Money {
+	if places < 0 {
+		panic("utils: negative number of decimal places")
+	}
+	return &Money{coef: big.NewInt(units), places: places}
+}
+
+// ParseMoney parses a plain decimal number such as "12.50", "-3" or "0.125" exactly.
+func ParseMoney(s string) (*Money, error) {
+	digits, negative := strings.CutPrefix(s, "-")
+	if !negative {
+		digits = strings.TrimPrefix(s, "+")
+	}
+	whole, fraction, hasPoint := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" || hasPoint && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return nil, fmt.Errorf("invalid decimal value: '%s'", s)
+	}
+	coef, _ := new(big.Int).SetString(whole+fraction, 10)
+	if negative {
+		coef.Neg(coef)
+	}
+	return &Money{coef: coef, places: int32(len(fraction))}, nil
+}
+
+// MustParseMoney is ParseMoney for amounts known to be valid; it panics on an invalid one.
+func MustParseMoney(s string) *Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// isDigits checks if s holds nothing but ASCII digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Places returns the number of decimal places the amount is kept with.
+func (m *Money) Places() int32 {
+	if m == nil {
+		return 0
+	}
+	return m.places
+}
+
+// Add returns m + o.
+func (m *Money) Add(o *Money) *Money {
+	places := max(m.Places(), o.Places())
+	sum := new(big.Int).Add(m.scaled(places), o.scaled(places))
+	return &Money{coef: sum, places: places}
+}
+
+// Sub returns m - o.
+func (m *Money) Sub(o *Money) *Money {
+	places := max(m.Places(), o.Places())
+	difference := new(big.Int).Sub(m.scaled(places), o.scaled(places))
+	return &Money{coef: difference, places: places}
+}
+
+// Neg returns -m.
+func (m *Money) Neg() *Money {
+	return &Money{coef: new(big.Int).Neg(m.coefficient()), places: m.Places()}
+}
+
+// Mul returns m * o exactly, e.g. a price times a rate.
+func (m *Money) Mul(o *Money) *Money {
+	product := new(big.Int).Mul(m.coefficient(), o.coefficient())
+	return &Money{coef: product, places: m.Places() + o.Places()}
+}
+
+// MulInt returns m * n, e.g. a unit price times a quantity.
+func (m *Money) MulInt(n int) *Money {
+	product := new(big.Int).Mul(m.coefficient(), big.NewInt(int64(n)))
+	return &Money{coef: product, places: m.Places()}
+}
+
+// Div returns m / d, with the exact quotient rounded to the given number of decimal places. It
+// panics if d is zero.
+func (m *Money) Div(d *Money, places int32, mode RoundingMode) *Money {
+	if d.Sign() == 0 {
+		panic("utils: division of money by zero")
+	}
+	if places < 0 {
+		panic("utils: negative number of decimal places")
+	}
+	// m/d = (mc / 10^mp) / (dc / 10^dp), so m/d * 10^places = mc * 10^(places+dp) / (dc * 10^mp)
+	num := new(big.Int).Mul(m.coefficient(), pow10(places+d.Places()))
+	den := new(big.Int).Mul(d.coefficient(), pow10(m.Places()))
+	return &Money{coef: roundQuo(num, den, mode), places: places}
+}
+
+// Round returns m rounded to the given number of decimal places. Amounts with fewer places are
+// padded, so the result always has exactly that many.
+func (m *Money) Round(places int32, mode RoundingMode) *Money {
+	if places < 0 {
+		panic("utils: negative number of decimal places")
+	}
+	if places >= m.Places() {
+		return &Money{coef: m.scaled(places), places: places}
+	}
+	rounded := roundQuo(m.coefficient(), pow10(m.Places()-places), mode)
+	return &Money{coef: rounded, places: places}
+}
+
+// Cmp compares m and o, returning -1, 0 or +1 like big.Int.Cmp. Trailing zeros don't matter:
+// 12.5 and 12.50 are equal.
+func (m *Money) Cmp(o *Money) int {
+	places := max(m.Places(), o.Places())
+	return m.scaled(places).Cmp(o.scaled(places))
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of m.
+func (m *Money) Sign() int {
+	return m.coefficient().Sign()
+}
+
+// IsZero checks if the amount is zero.
+func (m *Money) IsZero() bool {
+	return m.Sign() == 0
+}
+
+// Min returns the smaller of m and o.
+func (m *Money) Min(o *Money) *Money {
+	if m.Cmp(o) <= 0 {
+		return m.orZero()
+	}
+	return o.orZero()
+}
+
+// Float64 returns the nearest float64, for ratios and statistics only, never for amounts.
+func (m *Money) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(m.coefficient(), pow10(m.Places())).Float64()
+	return f
+}
+
+// String returns the amount as a plain decimal number with all its places, e.g. "12.50".
+func (m *Money) String() string {
+	digits := new(big.Int).Abs(m.coefficient()).String()
+	places := int(m.Places())
+	if len(digits) <= places {
+		digits = strings.Repeat("0", places-len(digits)+1) + digits
+	}
+	if places > 0 {
+		digits = digits[:len(digits)-places] + "." + digits[len(digits)-places:]
+	}
+	if m.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// coefficient returns the unscaled value, zero for a nil amount
+func (m *Money) coefficient() *big.Int {
+	if m == nil || m.coef == nil {
+		return new(big.Int)
+	}
+	return m.coef
+}
+
+// scaled returns the unscaled value for the given number of places, which must not be fewer
+// than the amount has
+func (m *Money) scaled(places int32) *big.Int {
+	return new(big.Int).Mul(m.coefficient(), pow10(places-m.Places()))
+}
+
+// orZero returns m, or a zero amount for nil
+func (m *Money) orZero() *Money {
+	if m == nil {
+		return NewMoney(0, 0)
+	}
+	return m
+}
+
+// pow10 returns 10^n
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+// amount is a random amount in cents for quick.Check, small enough to stay realistic
+type amount int32
+
+// money returns the amount as Money with two places
+func (a amount) money() *Money {
+	return NewMoney(int64(a), 2)
+}
+
+// rat returns the amount as an exact fraction, the reference the tests compare against
+func (a amount) rat() *big.Rat {
+	return big.NewRat(int64(a), 100)
+}
+
+// moneyRat returns m as an exact fraction
+func moneyRat(m *Money) *big.Rat {
+	r, ok := new(big.Rat).SetString(m.String())
+	if !ok {
+		panic("invalid money string " + m.String())
+	}
+	return r
+}
+
+// ratRound rounds r to the given number of places the slow way, as the reference for Div and Round
+func ratRound(r *big.Rat, places int32, mode RoundingMode) *big.Rat {
+	scale := new(big.Rat).SetInt(pow10(places))
+	scaled := new(big.Rat).Mul(r, scale)
+	floor := new(big.Int).Div(scaled.Num(), scaled.Denom()) // Euclidean: rounds towards -inf for positive denominators
+	fraction := new(big.Rat).Sub(scaled, new(big.Rat).SetInt(floor))
+
+	result := new(big.Int).Set(floor)
+	if fraction.Sign() != 0 {
+		half := fraction.Cmp(big.NewRat(1, 2))
+		negative := scaled.Sign() < 0
+		var up bool // towards +inf
+		switch mode {
+		case RoundDown:
+			up = negative
+		case RoundUp:
+			up = !negative
+		case RoundHalfUp:
+			up = half > 0 || half == 0 && !negative
+		case RoundHalfEven:
+			up = half > 0 || half == 0 && floor.Bit(0) == 1
+		}
+		if up {
+			result.Add(result, big.NewInt(1))
+		}
+	}
+	return new(big.Rat).SetFrac(result, pow10(places))
+}
+
+var roundingModes = []RoundingMode{RoundHalfUp, RoundHalfEven, RoundUp, RoundDown}
+
+func TestMoneyAddSubExact(t *testing.T) {
+	property := func(a, b, c amount) bool {
+		sum := a.money().Add(b.money()).Add(c.money())
+		want := new(big.Rat).Add(a.rat(), b.rat())
+		want.Add(want, c.rat())
+		return moneyRat(sum).Cmp(want) == 0 &&
+			a.money().Add(b.money()).Cmp(b.money().Add(a.money())) == 0 &&
+			sum.Cmp(a.money().Add(b.money().Add(c.money()))) == 0 &&
+			sum.Sub(c.money()).Sub(b.money()).Cmp(a.money()) == 0
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMoneyCartTotalExact(t *testing.T) {
+	// However many lines a cart has, its total is exactly the sum of price times quantity in cents
+	property := func(prices []amount, quantities []uint8) bool {
+		total := NewMoney(0, 2)
+		var cents int64
+		for i, price := range prices {
+			quantity := 1
+			if i < len(quantities) {
+				quantity = int(quantities[i])
+			}
+			total = total.Add(price.money().MulInt(quantity))
+			cents += int64(price) * int64(quantity)
+		}
+		return total.Cmp(NewMoney(cents, 2)) == 0 && total.String() == NewMoney(cents, 2).String()
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMoneyDecimalFractionsExact(t *testing.T) {
+	// The classic float64 failure: 0.1 + 0.2 != 0.3
+	sum := MustParseMoney("0.1").Add(MustParseMoney("0.2"))
+	if sum.Cmp(MustParseMoney("0.3")) != 0 {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", sum)
+	}
+
+	total := NewMoney(0, 2)
+	for range 1000 {
+		total = total.Add(MustParseMoney("0.01"))
+	}
+	if total.String() != "10.00" {
+		t.Errorf("1000 x 0.01 = %s, want 10.00", total)
+	}
+}
+
+func TestMoneyMulExact(t *testing.T) {
+	property := func(a, b amount) bool {
+		want := new(big.Rat).Mul(a.rat(), b.rat())
+		return moneyRat(a.money().Mul(b.money())).Cmp(want) == 0
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMoneyDivRounding(t *testing.T) {
+	property := func(a, b amount, places uint8) bool {
+		if b == 0 {
+			return true
+		}
+		p := int32(places % 6)
+		exact := new(big.Rat).Quo(a.rat(), b.rat())
+		for _, mode := range roundingModes {
+			got := a.money().Div(b.money(), p, mode)
+			if got.Places() != p || moneyRat(got).Cmp(ratRound(exact, p, mode)) != 0 {
+				t.Logf("%s / %s to %d places (%s) = %s", a.money(), b.money(), p, mode, got)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMoneyRounding(t *testing.T) {
+	property := func(a amount, places uint8) bool {
+		m := NewMoney(int64(a), 4) // e.g. a tax amount before rounding to cents
+		p := int32(places % 6)
+		for _, mode := range roundingModes {
+			got := m.Round(p, mode)
+			if got.Places() != p || moneyRat(got).Cmp(ratRound(moneyRat(m), p, mode)) != 0 {
+				t.Logf("%s to %d places (%s) = %s", m, p, mode, got)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+
+	halves := []struct {
+		value    string
+		mode     RoundingMode
+		expected string
+	}{
+		{"2.345", RoundHalfUp, "2.35"},
+		{"2.345", RoundHalfEven, "2.34"},
+		{"2.355", RoundHalfEven, "2.36"},
+		{"-2.345", RoundHalfUp, "-2.35"},
+		{"-2.345", RoundHalfEven, "-2.34"},
+		{"2.341", RoundUp, "2.35"},
+		{"-2.349", RoundDown, "-2.34"},
+	}
+	for _, tc := range halves {
+		if got := MustParseMoney(tc.value).Round(2, tc.mode).String(); got != tc.expected {
+			t.Errorf("%s rounded %s = %s, want %s", tc.value, tc.mode, got, tc.expected)
+		}
+	}
+}
+
+func TestMoneyStringRoundTrip(t *testing.T) {
+	property := func(units int64, places uint8) bool {
+		m := NewMoney(units, int32(places%12))
+		parsed, err := ParseMoney(m.String())
+		return err == nil && parsed.Cmp(m) == 0 && parsed.Places() == m.Places()
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+
+	for _, invalid := range []string{"", "-", ".", "1.", "1.2.3", "1e3", "--1", "1,50", " 1"} {
+		if _, err := ParseMoney(invalid); err == nil {
+			t.Errorf("ParseMoney(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestMoneyJSONAndScan(t *testing.T) {
+	property := func(a amount) bool {
+		m := a.money()
+		data, err := json.Marshal(m)
+		if err != nil {
+			return false
+		}
+		var decoded Money
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded.String() != m.String() {
+			return false
+		}
+
+		value, err := m.Value()
+		if err != nil {
+			return false
+		}
+		var scanned GormDecimal
+		if err := scanned.Scan([]byte(value.(string))); err != nil {
+			return false
+		}
+		return scanned.String() == m.String()
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+
+	var fromNumber Money
+	if err := json.Unmarshal([]byte("12.5"), &fromNumber); err != nil || fromNumber.String() != "12.5" {
+		t.Errorf("unmarshal of a JSON number = %s, %v; want 12.5", fromNumber.String(), err)
+	}
+	var fromFloat GormDecimal
+	if err := fromFloat.Scan(0.1); err != nil || fromFloat.String() != "0.1" {
+		t.Errorf("scan of float64 0.1 = %s, %v; want 0.1", fromFloat.String(), err)
+	}
+}
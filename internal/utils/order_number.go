@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OrderNumberFormat is the template human-friendly order numbers are built from. {seq} is the
+// number of the order within its day, optionally zero-padded as in {seq:4}, and {date} is the day
+// as YYYYMMDD. For example "#{seq:4}" gives "#0427" and "A-{date}-{seq:4}" gives "A-20261017-0042".
+type OrderNumberFormat string
+
+// DefaultOrderNumberFormat is used when no format is configured
+const DefaultOrderNumberFormat OrderNumberFormat = "#{seq:4}"
+
+// maxOrderNumberLength is the size of the column order numbers are stored in
+const maxOrderNumberLength = 32
+
+var orderNumberToken = regexp.MustCompile(`\{(seq(?::(\d+))?|date)\}`)
+
+// ParseOrderNumberFormat checks that a format contains {seq} exactly once and has no unknown placeholders
+func ParseOrderNumberFormat(format string) (OrderNumberFormat, error) {
+	seqCount := 0
+	for _, match := range orderNumberToken.FindAllStringSubmatch(format, -1) {
+		if strings.HasPrefix(match[1], "seq") {
+			seqCount++
+			if match[2] != "" {
+				if width, _ := strconv.Atoi(match[2]); width < 1 || width > 10 {
+					return "", fmt.Errorf("{seq:N} width must be between 1 and 10")
+				}
+			}
+		}
+	}
+	if seqCount != 1 {
+		return "", fmt.Errorf("format %q must contain {seq} exactly once", format)
+	}
+	if rest := orderNumberToken.ReplaceAllString(format, ""); strings.ContainsAny(rest, "{}") {
+		return "", fmt.Errorf("format %q has an unknown placeholder, use {seq}, {seq:N} or {date}", format)
+	}
+	if len(OrderNumberFormat(format).Format(time.Now(), 1<<31-1)) > maxOrderNumberLength {
+		return "", fmt.Errorf("format %q gives order numbers longer than %d characters", format, maxOrderNumberLength)
+	}
+	return OrderNumberFormat(format), nil
+}
+
+// Format builds the order number of the seq-th order of day
+func (f OrderNumberFormat) Format(day time.Time, seq int) string {
+	return orderNumberToken.ReplaceAllStringFunc(string(f), func(token string) string {
+		match := orderNumberToken.FindStringSubmatch(token)
+		if match[1] == "date" {
+			return day.Format("20060102")
+		}
+		if match[2] == "" {
+			return strconv.Itoa(seq)
+		}
+		width, _ := strconv.Atoi(match[2])
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+}
+
+// OrderNumberDay is the key of the day an order number is counted in, in the local time zone
+func OrderNumberDay(t time.Time) string {
+	return t.In(time.Local).Format("2006-01-02")
+}
@@ -0,0 +1,52 @@
+package utils
+
+import "math/big"
+
+// RoundingMode defines how amounts are rounded to a fixed number of decimal places.
+type RoundingMode string
+
+const (
+	RoundHalfUp   RoundingMode = "half_up"   // Halves away from zero
+	RoundHalfEven RoundingMode = "half_even" // Halves to the even neighbour (banker's rounding)
+	RoundUp       RoundingMode = "up"        // Away from zero
+	RoundDown     RoundingMode = "down"      // Towards zero
+)
+
+// IsValid checks if the rounding mode is one of the known modes.
+func (m RoundingMode) IsValid() bool {
+	switch m {
+	case RoundHalfUp, RoundHalfEven, RoundUp, RoundDown:
+		return true
+	}
+	return false
+}
+
+// roundQuo returns num / den rounded to an integer using mode.
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	if den.Sign() < 0 {
+		num, den = new(big.Int).Neg(num), new(big.Int).Neg(den)
+	}
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	// Compare twice the remainder with the denominator to find out which side of the half we are on
+	twiceRem := new(big.Int).Abs(rem)
+	twiceRem.Lsh(twiceRem, 1)
+	half := twiceRem.Cmp(den)
+
+	awayFromZero := false
+	switch mode {
+	case RoundUp:
+		awayFromZero = true
+	case RoundHalfUp:
+		awayFromZero = half >= 0
+	case RoundHalfEven:
+		awayFromZero = half > 0 || (half == 0 && quo.Bit(0) == 1)
+	}
+	if awayFromZero {
+		quo.Add(quo, big.NewInt(int64(num.Sign())))
+	}
+	return quo
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a recurring job runs next
+type Schedule interface {
+	// Next returns the first run time strictly after t
+	Next(t time.Time) time.Time
+	// String describes the schedule, e.g. "every 1m0s" or "0 3 * * *"
+	String() string
+}
+
+// intervalSchedule runs a job at a fixed interval
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Every creates a schedule that runs a job every interval, counted from the previous run
+func Every(interval time.Duration) (Schedule, error) {
+	if interval < time.Second {
+		return nil, fmt.Errorf("interval must be at least one second")
+	}
+	return intervalSchedule{interval: interval}, nil
+}
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+func (s intervalSchedule) String() string {
+	return "every " + s.interval.String()
+}
+
+// cronDescriptors maps the shorthand cron specs to their five field form
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSchedule is a parsed five field cron expression. Each field is a bit set of the values it matches.
+type cronSchedule struct {
+	spec   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// Standard cron matches either the day of month or the day of week when both are restricted
+	domAny bool
+	dowAny bool
+}
+
+// cronField describes the allowed values of one cron field
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7}, // 0 and 7 are both Sunday
+}
+
+// cronSearchLimit bounds how far ahead Next looks, so that impossible dates like 31 February end the search
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// ParseCron parses a cron expression with the fields minute, hour, day of month, month and day of
+// week. Fields accept *, values, ranges (1-5), lists (1,15) and steps (*/10, 8-18/2). The
+// descriptors @hourly, @daily, @weekly, @monthly and @yearly are accepted as well. Times are
+// evaluated in the location of the time passed to Next.
+func ParseCron(spec string) (Schedule, error) {
+	expr := strings.TrimSpace(spec)
+	if descriptor, ok := cronDescriptors[expr]; ok {
+		expr = descriptor
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields", spec, len(cronFields))
+	}
+
+	sets := make([]uint64, len(cronFields))
+	for i, part := range parts {
+		set, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %v", spec, err)
+		}
+		sets[i] = set
+	}
+
+	// Fold Sunday written as 7 onto 0
+	dow := sets[4]
+	if dow&(1<<7) != 0 {
+		dow = dow&^(1<<7) | 1
+	}
+
+	schedule := &cronSchedule{
+		spec:   strings.TrimSpace(spec),
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    dow,
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("cron expression %q never matches a date", spec)
+	}
+	return schedule, nil
+}
+
+// parseCronField parses one comma separated cron field into a bit set
+func parseCronField(field string, f cronField) (uint64, error) {
+	var set uint64
+	for _, term := range strings.Split(field, ",") {
+		rangePart, step := term, 1
+		if i := strings.Index(term, "/"); i >= 0 {
+			n, err := strconv.Atoi(term[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %s field %q", f.name, term)
+			}
+			rangePart, step = term[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid %s field %q", f.name, term)
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, fmt.Errorf("invalid %s field %q", f.name, term)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid %s field %q", f.name, term)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				// "5/15" means from 5 to the end of the range in steps of 15
+				hi = f.max
+			}
+		}
+
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%s field %q is out of range %d-%d", f.name, term, f.min, f.max)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for next.Before(limit) {
+		if s.month&(1<<uint(next.Month())) == 0 {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.matchesDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(next.Hour())) == 0 {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(next.Minute())) == 0 {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
+
+// matchesDay checks the day of month and day of week fields against t
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func (s *cronSchedule) String() string {
+	return s.spec
+}
@@ -1,7 +1,9 @@
 package gin_helper
 
 import (
+	"reflect"
 	"shopify-app/internal/exception"
+	"shopify-app/internal/utils"
 	//"shopify-app/pkg/web_response"
 
 	"github.com/gin-gonic/gin"
@@ -18,6 +20,8 @@ func BindAndValidate(c *gin.Context, obj interface{}) error {
 
 	// Validate the struct
 	validate := validator.New()
+	// Amounts are validated by their value, so tags like gte=0 work on them
+	validate.RegisterCustomTypeFunc(moneyValue, utils.Money{})
 	if err := validate.Struct(obj); err != nil {
 		// In a real app, you might want to format the validation errors nicely
 		return exception.NewAppError(err, "Validation failed", exception.CodeValidation)
@@ -25,3 +29,9 @@ func BindAndValidate(c *gin.Context, obj interface{}) error {
 
 	return nil
 }
+
+// moneyValue gives validator the value of an amount to check
+func moneyValue(v reflect.Value) interface{} {
+	m := v.Interface().(utils.Money)
+	return m.Float64()
+}
@@ -45,4 +45,100 @@ func ValidateToken(tokenString, jwtSecret string) (*Claims, error) {
 	}
 
 	return claims, nil
-}
\ No newline at end of file
+}
+
+// GuestClaims identifies an anonymous visitor's cart
+type GuestClaims struct {
+	GuestID utils.BinaryUUID `json:"guest_id"`
+	jwt.RegisteredClaims
+}
+
+// guestKey derives the key guest tokens are signed with, so that a guest token is never accepted
+// as a login token and a login token never as a guest token
+func guestKey(jwtSecret string) []byte {
+	return []byte("guest:" + jwtSecret)
+}
+
+func GenerateGuestToken(guestID utils.BinaryUUID, jwtSecret string, ttl time.Duration) (string, time.Time, error) {
+	expirationTime := time.Now().Add(ttl)
+	claims := &GuestClaims{
+		GuestID: guestID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(guestKey(jwtSecret))
+	return signed, expirationTime, err
+}
+
+func ValidateGuestToken(tokenString, jwtSecret string) (*GuestClaims, error) {
+	claims := &GuestClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return guestKey(jwtSecret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.GuestID == (utils.BinaryUUID{}) {
+		return nil, fmt.Errorf("invalid guest token")
+	}
+
+	return claims, nil
+}
+
+// QuoteClaims pins a price quote: checkout with the quote ID only goes ahead when the cart is
+// priced exactly as quoted
+type QuoteClaims struct {
+	Digest string `json:"digest"` // Hash of everything that makes up the quoted price
+	Total  string `json:"total"`
+	jwt.RegisteredClaims
+}
+
+// quoteKey derives the key quote IDs are signed with, keeping them apart from the other tokens
+func quoteKey(jwtSecret string) []byte {
+	return []byte("quote:" + jwtSecret)
+}
+
+func GenerateQuoteToken(owner, digest, total, jwtSecret string, ttl time.Duration) (string, time.Time, error) {
+	expirationTime := time.Now().Add(ttl)
+	claims := &QuoteClaims{
+		Digest: digest,
+		Total:  total,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        utils.NewBinaryUUID().String(),
+			Subject:   owner,
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(quoteKey(jwtSecret))
+	return signed, expirationTime, err
+}
+
+func ValidateQuoteToken(tokenString, jwtSecret string) (*QuoteClaims, error) {
+	claims := &QuoteClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return quoteKey(jwtSecret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.Digest == "" {
+		return nil, fmt.Errorf("invalid quote")
+	}
+
+	return claims, nil
+}
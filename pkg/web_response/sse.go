@@ -0,0 +1,46 @@
+package web_response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartEventStream sends the headers of a Server-Sent Events response.
+func StartEventStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// WriteEvent sends a single Server-Sent Event with a JSON payload and flushes it.
+func WriteEvent(c *gin.Context, id, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(c.Writer, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// WriteHeartbeat sends an SSE comment so idle connections are not closed by proxies.
+func WriteHeartbeat(c *gin.Context) error {
+	if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}